@@ -73,4 +73,9 @@ func initConfig() {
 	if err != nil {
 		fmt.Printf("unable to decode into config struct, %v", err)
 	}
+
+	if err = cfg.Validate(); err != nil {
+		fmt.Println("invalid config, err:", err)
+		os.Exit(1)
+	}
 }
@@ -1,5 +1,15 @@
 package config
 
+import (
+	"fmt"
+	"maps"
+	"net/url"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+)
+
 // DefaultConfig provides the default config values that are used when no config file is specified
 // Please keep defaults.ini in sync with this so there isn't any confusion
 var DefaultConfig = []byte(`
@@ -11,6 +21,33 @@ server_url = http://localhost/organizations/example/
 username = example
 key_file = /path/to/example.pem
 ssl_verify = true
+request_timeout = 30s
+default_organization =
+health_check_interval = 30s
+federated_search_timeout = 10s
+
+[auth]
+enabled = false
+provider = oidc
+issuer_url =
+client_id =
+client_secret =
+redirect_url =
+scopes = openid,profile,email
+username_claim = preferred_username
+groups_claim = groups
+session_secret =
+session_ttl = 12h
+post_logout_redirect_url =
+user_header = X-Forwarded-User
+groups_header = X-Forwarded-Groups
+email_header = X-Forwarded-Email
+
+[redaction]
+enabled = true
+paths =
+keys = (^|[_-])password$,(^|[_-])secret$,(^|[_-])token$,(^|[_-])private_key$
+groups =
 
 [logging]
 level = info
@@ -22,13 +59,112 @@ log_health_checks = true
 [server]
 base_path = /
 trusted_proxies =
+
+[ui]
+page_size = 100
+
+[node_list]
+columns =
+
+[reports]
+stale_threshold = 24h
+
+[cache]
+enabled = true
+refresh_interval = 1m
+node_ttl = 1m
+role_ttl = 5m
+environment_ttl = 5m
+cookbook_ttl = 5m
+databag_ttl = 1m
+policy_ttl = 5m
+group_ttl = 5m
+client_ttl = 5m
+user_ttl = 5m
+acl_ttl = 5m
 `)
 
 type chefConfig struct {
-	ServerURL string `mapstructure:"server_url"`
-	Username  string `mapstructure:"username"`
-	KeyFile   string `mapstructure:"key_file"`
-	SSLVerify bool   `mapstructure:"ssl_verify"`
+	ServerURL           string        `mapstructure:"server_url"`
+	Username            string        `mapstructure:"username"`
+	KeyFile             string        `mapstructure:"key_file"`
+	SSLVerify           bool          `mapstructure:"ssl_verify"`
+	RequestTimeout      time.Duration `mapstructure:"request_timeout"`
+	DefaultOrganization string        `mapstructure:"default_organization"`
+	HealthCheckInterval time.Duration `mapstructure:"health_check_interval"`
+	// FederatedSearchTimeout limits how long a search across every organization waits for each organization
+	FederatedSearchTimeout time.Duration `mapstructure:"federated_search_timeout"`
+}
+
+// organizationConfig holds the connection settings of a single organization. The server_url is required, while other
+// empty settings are inherited from the [chef] section.
+type organizationConfig struct {
+	ServerURL      string         `mapstructure:"server_url"`
+	Username       string         `mapstructure:"username"`
+	KeyFile        string         `mapstructure:"key_file"`
+	SSLVerify      *bool          `mapstructure:"ssl_verify"`
+	RequestTimeout *time.Duration `mapstructure:"request_timeout"`
+}
+
+type authConfig struct {
+	Enabled               bool                 `mapstructure:"enabled"`
+	Provider              string               `mapstructure:"provider"`
+	IssuerURL             string               `mapstructure:"issuer_url"`
+	ClientID              string               `mapstructure:"client_id"`
+	ClientSecret          string               `mapstructure:"client_secret"`
+	RedirectURL           string               `mapstructure:"redirect_url"`
+	Scopes                []string             `mapstructure:"scopes"`
+	UsernameClaim         string               `mapstructure:"username_claim"`
+	GroupsClaim           string               `mapstructure:"groups_claim"`
+	SessionSecret         string               `mapstructure:"session_secret"`
+	SessionTTL            time.Duration        `mapstructure:"session_ttl"`
+	PostLogoutRedirectURL string               `mapstructure:"post_logout_redirect_url"`
+	GroupMappings         map[int]groupMapping `mapstructure:"group_mappings"`
+	UserHeader            string               `mapstructure:"user_header"`
+	GroupsHeader          string               `mapstructure:"groups_header"`
+	EmailHeader           string               `mapstructure:"email_header"`
+}
+
+// groupMapping adds users to a group when a claim of their ID token matches any of the values
+type groupMapping struct {
+	Group  string   `mapstructure:"group"`
+	Claim  string   `mapstructure:"claim"`
+	Values []string `mapstructure:"values"`
+}
+
+// redactionConfig redacts sensitive values from the payloads of chef objects
+type redactionConfig struct {
+	Enabled bool     `mapstructure:"enabled"`
+	Paths   []string `mapstructure:"paths"`
+	Keys    []string `mapstructure:"keys"`
+	Groups  []string `mapstructure:"groups"`
+}
+
+// authorizationConfig restricts what users may see based on their groups
+type authorizationConfig struct {
+	Rules      map[int]accessRule         `mapstructure:"rules"`
+	Redactions map[int]attributeRedaction `mapstructure:"redactions"`
+}
+
+// accessRule only allows users in one of the groups to see objects of a type whose name matches the pattern
+type accessRule struct {
+	Type    string   `mapstructure:"type"`
+	Pattern string   `mapstructure:"pattern"`
+	Groups  []string `mapstructure:"groups"`
+}
+
+// encryptedDataBag decrypts the encrypted items of the data bags whose name matches the pattern for users in one of
+// the groups
+type encryptedDataBag struct {
+	Pattern    string   `mapstructure:"pattern"`
+	SecretFile string   `mapstructure:"secret_file"`
+	Groups     []string `mapstructure:"groups"`
+}
+
+// attributeRedaction hides the attribute at a path from every user who is not in one of the groups
+type attributeRedaction struct {
+	Path   string   `mapstructure:"path"`
+	Groups []string `mapstructure:"groups"`
 }
 
 type appConfig struct {
@@ -50,6 +186,33 @@ type serverConfig struct {
 	TrustedProxies string `mapstructure:"trusted_proxies"`
 }
 
+type uiConfig struct {
+	PageSize int `mapstructure:"page_size"`
+}
+
+type nodeListConfig struct {
+	Columns []string `mapstructure:"columns"`
+}
+
+type reportsConfig struct {
+	StaleThreshold time.Duration `mapstructure:"stale_threshold"`
+}
+
+type cacheConfig struct {
+	Enabled         bool          `mapstructure:"enabled"`
+	RefreshInterval time.Duration `mapstructure:"refresh_interval"`
+	NodeTTL         time.Duration `mapstructure:"node_ttl"`
+	RoleTTL         time.Duration `mapstructure:"role_ttl"`
+	EnvironmentTTL  time.Duration `mapstructure:"environment_ttl"`
+	CookbookTTL     time.Duration `mapstructure:"cookbook_ttl"`
+	DatabagTTL      time.Duration `mapstructure:"databag_ttl"`
+	PolicyTTL       time.Duration `mapstructure:"policy_ttl"`
+	GroupTTL        time.Duration `mapstructure:"group_ttl"`
+	ClientTTL       time.Duration `mapstructure:"client_ttl"`
+	UserTTL         time.Duration `mapstructure:"user_ttl"`
+	ACLTTL          time.Duration `mapstructure:"acl_ttl"`
+}
+
 type customLinksConfig struct {
 	Nodes        map[int]customLink `mapstructure:"nodes"`
 	Environments map[int]customLink `mapstructure:"environments"` // Unused, but maybe in the future
@@ -64,9 +227,101 @@ type customLink struct {
 }
 
 type Config struct {
-	App         appConfig         `mapstructure:"default"`
-	Chef        chefConfig        `mapstructure:"chef"`
-	Logging     loggingConfig     `mapstructure:"logging"`
-	Server      serverConfig      `mapstructure:"server"`
-	CustomLinks customLinksConfig `mapstructure:"custom_links"`
+	App           appConfig                     `mapstructure:"default"`
+	Chef          chefConfig                    `mapstructure:"chef"`
+	Organizations map[string]organizationConfig `mapstructure:"organizations"`
+	Auth          authConfig                    `mapstructure:"auth"`
+	Authorization authorizationConfig           `mapstructure:"authorization"`
+	Redaction     redactionConfig               `mapstructure:"redaction"`
+	// EncryptedDataBags are ordered by their number; the first one matching a data bag decrypts its items
+	EncryptedDataBags map[int]encryptedDataBag `mapstructure:"encrypted_data_bags"`
+	Logging           loggingConfig            `mapstructure:"logging"`
+	Server            serverConfig             `mapstructure:"server"`
+	UI                uiConfig                 `mapstructure:"ui"`
+	NodeList          nodeListConfig           `mapstructure:"node_list"`
+	Reports           reportsConfig            `mapstructure:"reports"`
+	Cache             cacheConfig              `mapstructure:"cache"`
+	CustomLinks       customLinksConfig        `mapstructure:"custom_links"`
+}
+
+// OrganizationNames returns the names of the configured chef organizations, sorted, with the default organization
+// first. Without any [organizations.<name>] sections the [chef] section is the only organization, named after the
+// organization in its server_url.
+func (c *Config) OrganizationNames() []string {
+	if len(c.Organizations) == 0 {
+		return []string{organizationFromURL(c.Chef.ServerURL)}
+	}
+
+	names := make([]string, 0, len(c.Organizations))
+	for name := range c.Organizations {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for i, name := range names {
+		if name == c.Chef.DefaultOrganization {
+			copy(names[1:i+1], names[:i])
+			names[0] = name
+		}
+	}
+	return names
+}
+
+// Validate returns an error for settings that can't be used, such as an organization without a server URL or
+// encrypted data bags that no one would be allowed to decrypt
+func (c *Config) Validate() error {
+	for _, name := range slices.Sorted(maps.Keys(c.Organizations)) {
+		if strings.TrimSpace(c.Organizations[name].ServerURL) == "" {
+			return fmt.Errorf("organization %q has no server_url", name)
+		}
+	}
+	for _, key := range slices.Sorted(maps.Keys(c.EncryptedDataBags)) {
+		e := c.EncryptedDataBags[key]
+		if !slices.ContainsFunc(e.Groups, func(g string) bool { return strings.TrimSpace(g) != "" }) {
+			return fmt.Errorf("encrypted_data_bags.%d (%s) has no groups allowed to see decrypted items", key, e.Pattern)
+		}
+	}
+	if org := c.Chef.DefaultOrganization; org != "" && !slices.Contains(c.OrganizationNames(), org) {
+		return fmt.Errorf("default_organization %q is not a configured organization (configured: %s)", org,
+			strings.Join(c.OrganizationNames(), ", "))
+	}
+	return nil
+}
+
+// ForOrganization returns a copy of the config whose [chef] section holds the settings of the named organization
+func (c *Config) ForOrganization(name string) *Config {
+	cfg := *c
+	org, ok := c.Organizations[name]
+	if !ok {
+		return &cfg
+	}
+
+	cfg.Chef.ServerURL = org.ServerURL
+	if org.Username != "" {
+		cfg.Chef.Username = org.Username
+	}
+	if org.KeyFile != "" {
+		cfg.Chef.KeyFile = org.KeyFile
+	}
+	if org.SSLVerify != nil {
+		cfg.Chef.SSLVerify = *org.SSLVerify
+	}
+	if org.RequestTimeout != nil {
+		cfg.Chef.RequestTimeout = *org.RequestTimeout
+	}
+	return &cfg
+}
+
+// organizationFromURL returns the organization name of a chef server URL such as
+// https://chef.example.com/organizations/example/, or "default" for servers without organizations
+func organizationFromURL(serverURL string) string {
+	u, err := url.Parse(serverURL)
+	if err != nil {
+		return "default"
+	}
+	parts := strings.Split(strings.Trim(u.Path, "/"), "/")
+	if len(parts) >= 2 && parts[len(parts)-2] == "organizations" {
+		return parts[len(parts)-1]
+	}
+	return "default"
 }
@@ -0,0 +1,96 @@
+package config
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestOrganizationNames(t *testing.T) {
+	single := &Config{}
+	single.Chef.ServerURL = "https://chef.example.com/organizations/example/"
+	if got := single.OrganizationNames(); !reflect.DeepEqual(got, []string{"example"}) {
+		t.Errorf("OrganizationNames() = %v, want [example]", got)
+	}
+
+	multi := &Config{Organizations: map[string]organizationConfig{"a": {}, "b": {}, "prod": {}}}
+	multi.Chef.DefaultOrganization = "prod"
+	if got := multi.OrganizationNames(); !reflect.DeepEqual(got, []string{"prod", "a", "b"}) {
+		t.Errorf("OrganizationNames() = %v, want [prod a b]", got)
+	}
+}
+
+func TestForOrganization(t *testing.T) {
+	insecure := false
+	timeout := 5 * time.Second
+	cfg := &Config{Organizations: map[string]organizationConfig{
+		"staging": {ServerURL: "https://staging/organizations/staging", SSLVerify: &insecure, RequestTimeout: &timeout},
+	}}
+	cfg.Chef = chefConfig{ServerURL: "https://chef/organizations/prod", Username: "browser", KeyFile: "/key.pem",
+		SSLVerify: true, RequestTimeout: 30 * time.Second}
+
+	expected := chefConfig{ServerURL: "https://staging/organizations/staging", Username: "browser",
+		KeyFile: "/key.pem", SSLVerify: false, RequestTimeout: 5 * time.Second}
+	if got := cfg.ForOrganization("staging").Chef; got != expected {
+		t.Errorf("ForOrganization() = %+v, want %+v", got, expected)
+	}
+	if cfg.Chef.ServerURL != "https://chef/organizations/prod" {
+		t.Errorf("ForOrganization() modified the original config")
+	}
+}
+
+func TestValidate(t *testing.T) {
+	orgs := map[string]organizationConfig{"prod": {ServerURL: "https://chef/organizations/prod"}}
+	tests := []struct {
+		name          string
+		orgs          map[string]organizationConfig
+		defaultOrg    string
+		expectedError bool
+	}{
+		{"single organization", nil, "", false},
+		{"default organization from the server url", nil, "example", false},
+		{"unknown default organization", nil, "prod", true},
+		{"organizations", orgs, "prod", false},
+		{"unknown default organization among organizations", orgs, "staging", true},
+		{"organization without server url", map[string]organizationConfig{"staging": {Username: "browser"}}, "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &Config{Organizations: tt.orgs}
+			cfg.Chef.ServerURL = "https://chef.example.com/organizations/example/"
+			cfg.Chef.DefaultOrganization = tt.defaultOrg
+			if err := cfg.Validate(); (err != nil) != tt.expectedError {
+				t.Errorf("Validate() = %v, expected an error: %v", err, tt.expectedError)
+			}
+		})
+	}
+}
+
+func TestValidateEncryptedDataBags(t *testing.T) {
+	cfg := &Config{EncryptedDataBags: map[int]encryptedDataBag{
+		0: {Pattern: "secrets-*", SecretFile: "/secret", Groups: []string{"sre"}},
+	}}
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("Validate() = %v, want nil", err)
+	}
+
+	cfg.EncryptedDataBags[1] = encryptedDataBag{Pattern: "vault", SecretFile: "/secret", Groups: []string{""}}
+	if err := cfg.Validate(); err == nil {
+		t.Error("expected an error for encrypted data bags without groups")
+	}
+}
+
+func TestOrganizationFromURL(t *testing.T) {
+	tests := []struct {
+		url, expected string
+	}{
+		{"https://chef.example.com/organizations/example/", "example"},
+		{"https://chef.example.com/organizations/example", "example"},
+		{"http://localhost:4545", "default"},
+	}
+	for _, tt := range tests {
+		if got := organizationFromURL(tt.url); got != tt.expected {
+			t.Errorf("organizationFromURL(%q) = %q, want %q", tt.url, got, tt.expected)
+		}
+	}
+}
@@ -3,7 +3,7 @@ module github.com/drewhammond/chefbrowser
 go 1.24.4
 
 require (
-	dario.cat/mergo v1.0.2
+	github.com/coreos/go-oidc/v3 v3.12.0
 	github.com/foolin/goview v0.3.0
 	github.com/go-chef/chef v0.30.1
 	github.com/labstack/echo/v4 v4.13.4
@@ -11,11 +11,14 @@ require (
 	github.com/spf13/viper v1.19.0
 	go.uber.org/zap v1.27.0
 	golang.org/x/mod v0.29.0
+	golang.org/x/oauth2 v0.27.0
+	golang.org/x/sync v0.18.0
 	gopkg.in/ini.v1 v1.67.0
 )
 
 require (
 	github.com/fsnotify/fsnotify v1.7.0 // indirect
+	github.com/go-jose/go-jose/v4 v4.0.5 // indirect
 	github.com/hashicorp/hcl v1.0.0 // indirect
 	github.com/inconshreveable/mousetrap v1.1.0 // indirect
 	github.com/labstack/gommon v0.4.2 // indirect
@@ -0,0 +1,42 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/drewhammond/chefbrowser/internal/chef"
+	"github.com/labstack/echo/v4"
+	"go.uber.org/zap"
+)
+
+// getACL returns the ACL of a single object, e.g. /api/acl/nodes/web-1. Data bags use the "data" type.
+func (s *Service) getACL(c echo.Context) error {
+	acl, err := s.chef.GetACL(c.Request().Context(), c.Param("type"), c.Param("name"))
+	if err != nil {
+		if errors.Is(err, chef.ErrInvalidACLType) {
+			return c.JSON(http.StatusBadRequest, ErrorResponse(err.Error()))
+		}
+		s.log.Error("failed to fetch acl", zap.Error(err))
+		return c.JSON(errorStatus(err), ErrorResponse("failed to fetch acl"))
+	}
+	return c.JSON(http.StatusOK, SuccessResponse(acl))
+}
+
+// getACLReport returns the objects of a type (?type=, defaults to roles) whose ACL differs from the default ACL of
+// their container. Add ?include_actors=true to also report actors, which include the creator of every object.
+func (s *Service) getACLReport(c echo.Context) error {
+	objectType := c.QueryParam("type")
+	if objectType == "" {
+		objectType = chef.ACLTypeRole
+	}
+
+	report, err := s.chef.GetACLReport(c.Request().Context(), objectType, c.QueryParam("include_actors") == "true")
+	if err != nil {
+		if errors.Is(err, chef.ErrInvalidACLType) {
+			return c.JSON(http.StatusBadRequest, ErrorResponse(err.Error()))
+		}
+		s.log.Error("failed to build acl report", zap.Error(err))
+		return c.JSON(errorStatus(err), ErrorResponse("failed to build acl report"))
+	}
+	return c.JSON(http.StatusOK, SuccessResponse(report))
+}
@@ -16,13 +16,17 @@ type Service struct {
 	log    *logging.Logger
 	config *config.Config
 	chef   *chef.Service
+	orgs   *chef.Organizations
+	// org is the organization in the route prefix, or empty for the unprefixed routes of the default organization
+	org    string
 	engine *echo.Echo
 }
 
-func New(config *config.Config, engine *echo.Echo, chef *chef.Service, logger *logging.Logger) *Service {
+func New(config *config.Config, engine *echo.Echo, orgs *chef.Organizations, logger *logging.Logger) *Service {
 	s := Service{
 		config: config,
-		chef:   chef,
+		chef:   orgs.Default(),
+		orgs:   orgs,
 		log:    logger,
 		engine: engine,
 	}
@@ -30,62 +34,138 @@ func New(config *config.Config, engine *echo.Echo, chef *chef.Service, logger *l
 	return &s
 }
 
+// RegisterRoutes registers the API of the default organization under /api and the API of every organization under
+// /orgs/<name>/api. Endpoints spanning every organization are only registered under /api.
 func (s *Service) RegisterRoutes() {
 	s.log.Info("registering API routes")
 
 	router := s.engine.Group(urlWithBasePath("/api"))
-	{
-		router.Use(middleware.CORS())
-		// nodes
-		router.GET("/nodes", s.getNodes)
-		router.GET("/nodes/:name", s.getNode)
-
-		// environments
-		router.GET("/environments", s.getEnvironments)
-		router.GET("/environments/:name", s.getEnvironment)
-
-		// roles
-		router.GET("/roles", s.getRoles)
-		router.GET("/roles/:name", s.getRole)
-
-		// cookbooks
-		router.GET("/cookbooks", s.getCookbooks)
-		router.GET("/cookbooks/:name", s.getCookbook)
-		router.GET("/cookbooks/:name/versions", s.getCookbookVersions)
-		router.GET("/cookbooks/:name/:version", s.getCookbookVersion)
-
-		// groups
-		router.GET("/groups", s.getGroups)
-		router.GET("/groups/:name", s.getGroup)
-
-		// databags
-		router.GET("/databags", s.getDatabags)
-		router.GET("/databags/:name", s.getDatabagItems)
-		router.GET("/databags/:name/:item", s.getDatabagItemContent)
-
-		// policies
-		router.GET("/policies", s.getPolicies)
-		router.GET("/policies/:name", s.getPolicy)
-		router.GET("/policies/:name/:revision", s.getPolicyRevision)
-		router.GET("/policy-groups", s.getPolicyGroups)
-		router.GET("/policy-groups/:name", s.getPolicyGroup)
-
-		// misc
-		router.GET("/health", getHealth)
+	s.registerRoutes(router)
+	router.GET("/federated/nodes", s.searchOrganizations)
+	for _, name := range s.orgs.Names() {
+		org := *s
+		org.org = name
+		org.chef, _ = s.orgs.Get(name)
+		org.registerRoutes(s.engine.Group(urlWithBasePath(orgPath(name) + "/api")))
 	}
 }
 
+func (s *Service) registerRoutes(router *echo.Group) {
+	router.Use(middleware.CORS())
+	// nodes
+	router.GET("/nodes", s.getNodes)
+	router.GET("/nodes/:name", s.getNode)
+	router.GET("/nodes/:name/explain", s.explainNodeAttribute)
+	router.GET("/nodes/:name/expanded-run-list", s.getNodeExpandedRunList)
+
+	// environments
+	router.GET("/environments", s.getEnvironments)
+	router.GET("/environments/:name", s.getEnvironment)
+	router.GET("/environments/:name/cookbooks", s.getEnvironmentCookbooks)
+
+	// roles
+	router.GET("/roles", s.getRoles)
+	router.GET("/roles/:name", s.getRole)
+	router.GET("/roles/:name/expanded-run-list", s.getRoleExpandedRunList)
+
+	// cookbooks
+	router.GET("/cookbooks", s.getCookbooks)
+	router.GET("/cookbooks/:name", s.getCookbook)
+	router.GET("/cookbooks/:name/versions", s.getCookbookVersions)
+	router.GET("/cookbooks/:name/compare/:range", s.compareCookbookVersions)
+	router.GET("/cookbooks/:name/usage", s.getCookbookUsage)
+	router.GET("/cookbooks/:name/:version", s.getCookbookVersion)
+	router.GET("/cookbooks/:name/:version/dependencies", s.getCookbookDependencies)
+
+	// groups
+	router.GET("/groups", s.getGroups)
+	router.GET("/groups/:name", s.getGroup)
+
+	router.GET("/clients", s.getClients)
+	router.GET("/clients/:name", s.getClient)
+
+	router.GET("/users", s.getUsers)
+	router.GET("/users/:name", s.getUser)
+
+	// databags
+	router.GET("/databags", s.getDatabags)
+	router.GET("/databags/:name", s.getDatabagItems)
+	router.GET("/databags/:name/:item", s.getDatabagItemContent)
+
+	// policies
+	router.GET("/policies", s.getPolicies)
+	router.GET("/policies/:name", s.getPolicy)
+	router.GET("/policies/:name/compare", s.comparePolicies)
+	router.GET("/policies/:name/:revision", s.getPolicyRevision)
+	router.GET("/policy-groups", s.getPolicyGroups)
+	router.GET("/policy-groups/:name", s.getPolicyGroup)
+
+	// compare
+	router.GET("/compare/nodes", s.compareNodes)
+	router.GET("/compare/environments", s.compareEnvironments)
+
+	// search
+	router.GET("/search", s.search)
+	router.GET("/search/:index", s.searchIndex)
+
+	// reports
+	router.GET("/reports/stale", s.getStaleNodes)
+	router.GET("/reports/inventory", s.getInventory)
+	router.GET("/reports/acl", s.getACLReport)
+
+	router.GET("/acl/:type/:name", s.getACL)
+
+	// misc
+	router.GET("/health", s.getHealth)
+	router.GET("/cache", s.getCacheStats)
+}
+
 func urlWithBasePath(path string) string {
 	return basePath + path
 }
 
+// orgPath returns the route prefix of an organization
+func orgPath(org string) string {
+	return "/orgs/" + org
+}
+
 type HealthResponse struct {
-	Success bool   `json:"success"`
-	Message string `json:"message"`
+	Success       bool                   `json:"success"`
+	Message       string                 `json:"message"`
+	Organizations map[string]chef.Health `json:"organizations,omitempty"`
+}
+
+// getHealth reports the health of the organization in the route prefix. The unprefixed endpoint reports every
+// organization and is ready as long as at least one of them is healthy, so a single broken organization doesn't take
+// the whole instance out of a load balancer.
+func (s *Service) getHealth(c echo.Context) error {
+	orgs := []string{s.org}
+	if s.org == "" {
+		orgs = s.orgs.Names()
+	}
+
+	resp := &HealthResponse{Message: "unavailable", Organizations: map[string]chef.Health{}}
+	for _, name := range orgs {
+		svc, _ := s.orgs.Get(name)
+		h := svc.Health()
+		resp.Organizations[name] = h
+		resp.Success = resp.Success || h.Healthy
+	}
+
+	if !resp.Success {
+		return c.JSON(http.StatusServiceUnavailable, resp)
+	}
+	resp.Message = "ready"
+	return c.JSON(http.StatusOK, resp)
+}
+
+func (s *Service) getCacheStats(c echo.Context) error {
+	return c.JSON(http.StatusOK, SuccessResponse(s.chef.CacheStats()))
 }
 
-func getHealth(c echo.Context) error {
-	return c.JSON(http.StatusOK, &HealthResponse{Success: true, Message: "ready"})
+// errorStatus returns the HTTP status that best describes an error returned by the chef service
+func errorStatus(err error) int {
+	return chef.StatusCode(err, http.StatusInternalServerError)
 }
 
 type errorResponse struct {
@@ -111,3 +191,20 @@ func SuccessResponse(body interface{}) successResponse {
 		Results: body,
 	}
 }
+
+// pagedResponse is a successResponse holding a single page of a larger list
+type pagedResponse struct {
+	successResponse
+	Total  int `json:"total"`
+	Offset int `json:"offset"`
+	Limit  int `json:"limit"`
+}
+
+func PagedResponse(body interface{}, total int, page chef.Page) pagedResponse {
+	return pagedResponse{
+		successResponse: SuccessResponse(body),
+		Total:           total,
+		Offset:          page.Start,
+		Limit:           page.Rows,
+	}
+}
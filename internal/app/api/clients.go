@@ -0,0 +1,27 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"go.uber.org/zap"
+)
+
+func (s *Service) getClients(c echo.Context) error {
+	clients, err := s.chef.GetAPIClients(c.Request().Context())
+	if err != nil {
+		s.log.Error("failed to fetch clients from server", zap.Error(err))
+		return c.JSON(errorStatus(err), ErrorResponse("failed to fetch clients from server"))
+	}
+	return c.JSON(http.StatusOK, SuccessResponse(clients))
+}
+
+// getClient returns a single API client along with the fingerprints of its public keys
+func (s *Service) getClient(c echo.Context) error {
+	client, err := s.chef.GetAPIClient(c.Request().Context(), c.Param("name"))
+	if err != nil {
+		s.log.Error("failed to fetch client from server", zap.Error(err))
+		return c.JSON(errorStatus(err), ErrorResponse("failed to fetch client from server"))
+	}
+	return c.JSON(http.StatusOK, SuccessResponse(client))
+}
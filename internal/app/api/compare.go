@@ -0,0 +1,84 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/drewhammond/chefbrowser/internal/chef"
+	"github.com/labstack/echo/v4"
+	"go.uber.org/zap"
+)
+
+// compareNodes returns the differences between nodes a and b. Add ?hide_automatic=true to leave out automatic
+// (ohai) attributes.
+func (s *Service) compareNodes(c echo.Context) error {
+	a, b := c.QueryParam("a"), c.QueryParam("b")
+	if a == "" || b == "" {
+		return c.JSON(http.StatusBadRequest, ErrorResponse("missing nodes to compare (a and b)"))
+	}
+
+	comparison, err := s.chef.CompareNodes(c.Request().Context(), a, b, c.QueryParam("hide_automatic") == "true")
+	if err != nil {
+		s.log.Error("failed to compare nodes", zap.Error(err))
+		return c.JSON(errorStatus(err), ErrorResponse("failed to compare nodes"))
+	}
+	return c.JSON(http.StatusOK, SuccessResponse(comparison))
+}
+
+// compareEnvironments returns the cookbook versions selected by environments a and b
+func (s *Service) compareEnvironments(c echo.Context) error {
+	a, b := c.QueryParam("a"), c.QueryParam("b")
+	if a == "" || b == "" {
+		return c.JSON(http.StatusBadRequest, ErrorResponse("missing environments to compare (a and b)"))
+	}
+
+	comparison, err := s.chef.CompareEnvironmentCookbooks(c.Request().Context(), a, b)
+	if err != nil {
+		s.log.Error("failed to compare environments", zap.Error(err))
+		return c.JSON(errorStatus(err), ErrorResponse("failed to compare environments"))
+	}
+	return c.JSON(http.StatusOK, SuccessResponse(comparison))
+}
+
+// compareCookbookVersions returns the files and metadata that differ between two versions of a cookbook. The range
+// is given as <from>...<to>, e.g. /api/cookbooks/nginx/compare/1.0.0...1.2.0. Add ?format=patch to get the file
+// changes as a unified diff.
+func (s *Service) compareCookbookVersions(c echo.Context) error {
+	from, to, err := chef.ParseVersionRange(c.Param("range"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, ErrorResponse(err.Error()))
+	}
+
+	diff, err := s.chef.CompareCookbookVersions(c.Request().Context(), c.Param("name"), from, to)
+	if err != nil {
+		s.log.Error("failed to compare cookbook versions", zap.Error(err))
+		return c.JSON(errorStatus(err), ErrorResponse("failed to compare cookbook versions"))
+	}
+	if c.QueryParam("format") == "patch" {
+		return c.String(http.StatusOK, diff.Patch())
+	}
+	return c.JSON(http.StatusOK, SuccessResponse(diff))
+}
+
+// comparePolicies returns the differences between two revisions of a policy, given either as revision IDs
+// (?from=<revision>&to=<revision>) or as the policy groups they are assigned to (?from_group=staging&to_group=prod)
+func (s *Service) comparePolicies(c echo.Context) error {
+	name := c.Param("name")
+	from, to := c.QueryParam("from"), c.QueryParam("to")
+	fromGroup, toGroup := c.QueryParam("from_group"), c.QueryParam("to_group")
+
+	var comparison *chef.PolicyComparison
+	var err error
+	switch {
+	case fromGroup != "" && toGroup != "":
+		comparison, err = s.chef.ComparePolicyGroups(c.Request().Context(), name, fromGroup, toGroup)
+	case from != "" && to != "":
+		comparison, err = s.chef.ComparePolicyRevisions(c.Request().Context(), name, from, to)
+	default:
+		return c.JSON(http.StatusBadRequest, ErrorResponse("missing revisions (from and to) or policy groups (from_group and to_group) to compare"))
+	}
+	if err != nil {
+		s.log.Error("failed to compare policy revisions", zap.Error(err))
+		return c.JSON(errorStatus(err), ErrorResponse("failed to compare policy revisions"))
+	}
+	return c.JSON(http.StatusOK, SuccessResponse(comparison))
+}
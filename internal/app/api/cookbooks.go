@@ -1,6 +1,7 @@
 package api
 
 import (
+	"fmt"
 	"net/http"
 
 	"github.com/labstack/echo/v4"
@@ -8,21 +9,43 @@ import (
 )
 
 func (s *Service) getCookbooks(c echo.Context) error {
+	if isPaged(c) {
+		return s.getCookbooksPage(c)
+	}
+
 	s.log.Debug("getting all cookbooks from chef server")
 	cookbooks, err := s.chef.GetCookbooks(c.Request().Context())
 	if err != nil {
 		s.log.Error("failed to fetch cookbooks from server", zap.Error(err))
-		return c.JSON(http.StatusInternalServerError, ErrorResponse("failed to fetch cookbooks from server"))
+		return c.JSON(errorStatus(err), ErrorResponse("failed to fetch cookbooks from server"))
 	}
 	return c.JSON(http.StatusOK, cookbooks)
 }
 
+// getCookbooksPage returns a single page of cookbooks sorted by name
+func (s *Service) getCookbooksPage(c echo.Context) error {
+	params, err := parseListParams(c)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, ErrorResponse(err.Error()))
+	}
+	if params.Sort != "" && params.Sort != "name" {
+		return c.JSON(http.StatusBadRequest, ErrorResponse("cookbooks can only be sorted by name"))
+	}
+
+	cookbooks, err := s.chef.GetCookbooksPage(c.Request().Context(), params.Page, params.Descending)
+	if err != nil {
+		s.log.Error("failed to fetch cookbooks from server", zap.Error(err))
+		return c.JSON(errorStatus(err), ErrorResponse("failed to fetch cookbooks from server"))
+	}
+	return c.JSON(http.StatusOK, PagedResponse(cookbooks.Cookbooks, cookbooks.Total, params.Page))
+}
+
 func (s *Service) getCookbook(c echo.Context) error {
 	name := c.Param("name")
 	cookbook, err := s.chef.GetCookbook(c.Request().Context(), name)
 	if err != nil {
 		s.log.Error("failed to fetch cookbook from server", zap.Error(err))
-		return c.JSON(http.StatusNotFound, ErrorResponse("failed to fetch cookbook from server"))
+		return c.JSON(errorStatus(err), ErrorResponse("failed to fetch cookbook from server"))
 	}
 	return c.JSON(http.StatusOK, cookbook)
 }
@@ -33,7 +56,7 @@ func (s *Service) getCookbookVersion(c echo.Context) error {
 	cookbook, err := s.chef.GetCookbookVersion(c.Request().Context(), name, version)
 	if err != nil {
 		s.log.Error("failed to fetch cookbook from server", zap.Error(err))
-		return c.JSON(http.StatusNotFound, ErrorResponse("failed to fetch cookbook version from server"))
+		return c.JSON(errorStatus(err), ErrorResponse("failed to fetch cookbook version from server"))
 	}
 	return c.JSON(http.StatusOK, cookbook)
 }
@@ -41,17 +64,39 @@ func (s *Service) getCookbookVersion(c echo.Context) error {
 func (s *Service) getCookbookVersions(c echo.Context) error {
 	name := c.Param("name")
 
-	resp, err := s.chef.GetClient().Cookbooks.GetAvailableVersions(name, "0")
+	versions, err := s.chef.GetCookbookVersions(c.Request().Context(), name)
 	if err != nil {
-		return c.JSON(http.StatusNotFound, ErrorResponse("failed to fetch cookbook versions"))
+		return c.JSON(errorStatus(err), ErrorResponse("failed to fetch cookbook versions"))
 	}
 
-	var versions []string
-	for _, i := range resp {
-		for _, j := range i.Versions {
-			versions = append(versions, j.Version)
-		}
+	return c.JSON(http.StatusOK, versions)
+}
+
+// getCookbookDependencies returns the transitive dependency tree of a cookbook version, with each constraint resolved
+// to its newest matching version on its own. Add ?format=dot to get the tree as a Graphviz DOT graph.
+func (s *Service) getCookbookDependencies(c echo.Context) error {
+	dependencies, err := s.chef.GetCookbookDependencies(c.Request().Context(), c.Param("name"), c.Param("version"))
+	if err != nil {
+		s.log.Error("failed to resolve cookbook dependencies", zap.Error(err))
+		return c.JSON(errorStatus(err), ErrorResponse("failed to resolve cookbook dependencies"))
 	}
 
-	return c.JSON(http.StatusOK, versions)
+	if c.QueryParam("format") == "dot" {
+		c.Response().Header().Set(echo.HeaderContentDisposition,
+			fmt.Sprintf(`attachment; filename="%s-%s.dot"`, dependencies.Name, dependencies.Version))
+		c.Response().Header().Set(echo.HeaderContentType, "text/vnd.graphviz; charset=utf-8")
+		c.Response().WriteHeader(http.StatusOK)
+		return dependencies.WriteDOT(c.Response())
+	}
+	return c.JSON(http.StatusOK, SuccessResponse(dependencies))
+}
+
+// getCookbookUsage returns the cookbooks, roles, environments, policies and nodes that refer to a cookbook
+func (s *Service) getCookbookUsage(c echo.Context) error {
+	usage, err := s.chef.GetCookbookUsage(c.Request().Context(), c.Param("name"))
+	if err != nil {
+		s.log.Error("failed to find cookbook usage", zap.Error(err))
+		return c.JSON(errorStatus(err), ErrorResponse("failed to find cookbook usage"))
+	}
+	return c.JSON(http.StatusOK, SuccessResponse(usage))
 }
@@ -12,7 +12,7 @@ func (s *Service) getDatabags(c echo.Context) error {
 	databags, err := s.chef.GetDatabags(c.Request().Context())
 	if err != nil {
 		s.log.Error("failed to fetch databags from server", zap.Error(err))
-		return c.JSON(http.StatusInternalServerError, ErrorResponse("failed to fetch databags from server"))
+		return c.JSON(errorStatus(err), ErrorResponse("failed to fetch databags from server"))
 	}
 	return c.JSON(http.StatusOK, databags)
 }
@@ -22,18 +22,20 @@ func (s *Service) getDatabagItems(c echo.Context) error {
 	databag, err := s.chef.GetDatabagItems(c.Request().Context(), name)
 	if err != nil {
 		s.log.Error("failed to fetch databag from server", zap.Error(err))
-		return c.JSON(http.StatusNotFound, ErrorResponse("failed to fetch databag from server"))
+		return c.JSON(errorStatus(err), ErrorResponse("failed to fetch databag from server"))
 	}
 	return c.JSON(http.StatusOK, databag)
 }
 
+// getDatabagItemContent returns the content of a data bag item along with its encryption (null for plain items), so
+// clients can tell encrypted values apart from decrypted ones
 func (s *Service) getDatabagItemContent(c echo.Context) error {
 	name := c.Param("name")
 	item := c.Param("item")
-	content, err := s.chef.GetDatabagItemContent(c.Request().Context(), name, item)
+	content, err := s.chef.GetDatabagItem(c.Request().Context(), name, item)
 	if err != nil {
 		s.log.Error("failed to fetch databag contents from server", zap.Error(err))
-		return c.JSON(http.StatusNotFound, ErrorResponse("failed to fetch databag contents from server"))
+		return c.JSON(errorStatus(err), ErrorResponse("failed to fetch databag contents from server"))
 	}
 	return c.JSON(http.StatusOK, content)
 }
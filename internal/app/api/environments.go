@@ -12,7 +12,7 @@ func (s *Service) getEnvironments(c echo.Context) error {
 	environments, err := s.chef.GetEnvironments(c.Request().Context())
 	if err != nil {
 		s.log.Error("failed to fetch environments from server", zap.Error(err))
-		return c.JSON(http.StatusInternalServerError, ErrorResponse("failed to fetch environments from chef server"))
+		return c.JSON(errorStatus(err), ErrorResponse("failed to fetch environments from chef server"))
 	}
 
 	return c.JSON(http.StatusOK, environments)
@@ -22,11 +22,20 @@ func (s *Service) getEnvironment(c echo.Context) error {
 	name := c.Param("name")
 	environment, err := s.chef.GetEnvironment(c.Request().Context(), name)
 	if err != nil {
-		return c.JSON(http.StatusInternalServerError, ErrorResponse("failed to fetch environment from chef server"))
+		s.log.Error("failed to fetch environment from server", zap.Error(err))
+		return c.JSON(errorStatus(err), ErrorResponse("failed to fetch environment from chef server"))
 	}
-	if environment != nil {
-		return c.JSON(http.StatusOK, environment)
+
+	return c.JSON(http.StatusOK, environment)
+}
+
+// getEnvironmentCookbooks returns the cookbook constraints of an environment along with the versions they select
+func (s *Service) getEnvironmentCookbooks(c echo.Context) error {
+	cookbooks, err := s.chef.ResolveEnvironmentCookbooks(c.Request().Context(), c.Param("name"))
+	if err != nil {
+		s.log.Error("failed to resolve environment cookbooks", zap.Error(err))
+		return c.JSON(errorStatus(err), ErrorResponse("failed to resolve environment cookbooks"))
 	}
 
-	return c.JSON(http.StatusNotFound, environment)
+	return c.JSON(http.StatusOK, SuccessResponse(cookbooks))
 }
@@ -11,7 +11,7 @@ func (s *Service) getGroups(c echo.Context) error {
 	groups, err := s.chef.GetGroups(c.Request().Context())
 	if err != nil {
 		s.log.Error("failed to fetch groups from server", zap.Error(err))
-		return c.JSON(http.StatusInternalServerError, "failed to fetch groups from server")
+		return c.JSON(errorStatus(err), ErrorResponse("failed to fetch groups from server"))
 	}
 	return c.JSON(http.StatusOK, SuccessResponse(groups))
 }
@@ -21,7 +21,7 @@ func (s *Service) getGroup(c echo.Context) error {
 	group, err := s.chef.GetGroup(c.Request().Context(), name)
 	if err != nil {
 		s.log.Error("failed to fetch group from server", zap.Error(err))
-		return c.JSON(http.StatusNotFound, "failed to fetch group from server")
+		return c.JSON(errorStatus(err), ErrorResponse("failed to fetch group from server"))
 	}
 	return c.JSON(http.StatusOK, group)
 }
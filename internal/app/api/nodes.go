@@ -3,6 +3,7 @@ package api
 import (
 	"net/http"
 
+	"github.com/drewhammond/chefbrowser/internal/chef"
 	"github.com/labstack/echo/v4"
 	"go.uber.org/zap"
 )
@@ -13,15 +14,101 @@ func (s *Service) getNode(c echo.Context) error {
 	node, err := s.chef.GetNode(c.Request().Context(), name)
 	if err != nil {
 		s.log.Error("failed to fetch node from server", zap.Error(err))
+		return c.JSON(errorStatus(err), ErrorResponse("failed to fetch node from server"))
 	}
 	return c.JSON(http.StatusOK, node)
 }
 
 func (s *Service) getNodes(c echo.Context) error {
+	if fields := c.QueryParam("fields"); fields != "" {
+		return s.getNodeTable(c, fields)
+	}
+	if isPaged(c) || c.QueryParam("q") != "" {
+		return s.getNodesPage(c)
+	}
+
 	s.log.Debug("getting all nodes from chef server")
 	nodes, err := s.chef.GetNodes(c.Request().Context())
 	if err != nil {
 		s.log.Error("failed to fetch nodes", zap.Error(err))
+		return c.JSON(errorStatus(err), ErrorResponse("failed to fetch nodes"))
 	}
 	return c.JSON(http.StatusOK, nodes)
 }
+
+// getNodesPage returns a single page of the nodes matching the optional search query, which is fuzzified like in
+// the UI
+func (s *Service) getNodesPage(c echo.Context) error {
+	params, err := parseListParams(c)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, ErrorResponse(err.Error()))
+	}
+	if params.Sort != "" && params.Sort != "name" {
+		return c.JSON(http.StatusBadRequest, ErrorResponse("nodes can only be sorted by name"))
+	}
+
+	query := chef.FuzzifyQuery(chef.IndexNode, c.QueryParam("q"))
+	nodes, err := s.chef.GetNodesPage(c.Request().Context(), query, params.Page, params.Descending)
+	if err != nil {
+		s.log.Error("failed to search nodes", zap.Error(err))
+		return c.JSON(errorStatus(err), ErrorResponse("failed to search nodes"))
+	}
+	return c.JSON(http.StatusOK, PagedResponse(nodes.Nodes, nodes.Total, params.Page))
+}
+
+// getNodeTable returns the requested attribute paths (e.g. ?fields=platform,chef_packages.chef.version) for every
+// node matching the optional (fuzzified) search query
+func (s *Service) getNodeTable(c echo.Context, fields string) error {
+	params, err := parseListParams(c)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, ErrorResponse(err.Error()))
+	}
+
+	order := chef.SortNodeTable{Column: params.Sort, Descending: params.Descending}
+	query := chef.FuzzifyQuery(chef.IndexNode, c.QueryParam("q"))
+	table, err := s.chef.GetNodeTable(c.Request().Context(), query, chef.ParseColumns(fields), order, params.Page)
+	if err != nil {
+		s.log.Error("failed to search nodes", zap.Error(err))
+		return c.JSON(errorStatus(err), ErrorResponse("failed to search nodes"))
+	}
+	return c.JSON(http.StatusOK, PagedResponse(table, table.Total, params.Page))
+}
+
+// explainNodeAttribute returns the value of a node attribute (e.g. ?path=kernel.machine) at every precedence level
+// and which level wins
+func (s *Service) explainNodeAttribute(c echo.Context) error {
+	path := chef.ParseAttributePath(c.QueryParam("path"))
+	if len(path) == 0 {
+		return c.JSON(http.StatusBadRequest, ErrorResponse("missing attribute path (path)"))
+	}
+
+	node, err := s.chef.GetNode(c.Request().Context(), c.Param("name"))
+	if err != nil {
+		s.log.Error("failed to fetch node from server", zap.Error(err))
+		return c.JSON(errorStatus(err), ErrorResponse("failed to fetch node from server"))
+	}
+
+	explanation, err := s.chef.ExplainAttribute(c.Request().Context(), node, path)
+	if err != nil {
+		s.log.Error("failed to explain node attribute", zap.Error(err))
+		return c.JSON(errorStatus(err), ErrorResponse("failed to explain node attribute"))
+	}
+	return c.JSON(http.StatusOK, SuccessResponse(explanation))
+}
+
+// getNodeExpandedRunList returns the run list of a node with every role recursively expanded in the node's
+// environment
+func (s *Service) getNodeExpandedRunList(c echo.Context) error {
+	node, err := s.chef.GetNode(c.Request().Context(), c.Param("name"))
+	if err != nil {
+		s.log.Error("failed to fetch node from server", zap.Error(err))
+		return c.JSON(errorStatus(err), ErrorResponse("failed to fetch node from server"))
+	}
+
+	expanded, err := s.chef.ExpandNodeRunList(c.Request().Context(), node)
+	if err != nil {
+		s.log.Error("failed to expand node run list", zap.Error(err))
+		return c.JSON(errorStatus(err), ErrorResponse("failed to expand node run list"))
+	}
+	return c.JSON(http.StatusOK, SuccessResponse(expanded))
+}
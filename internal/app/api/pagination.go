@@ -0,0 +1,50 @@
+package api
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+
+	"github.com/drewhammond/chefbrowser/internal/chef"
+	"github.com/labstack/echo/v4"
+)
+
+var errInvalidListParams = errors.New("limit and offset must be non-negative integers")
+
+// listParams holds the pagination and sorting query parameters accepted by list endpoints:
+// limit (page size, 0 for no limit), offset (number of items to skip) and sort (the field to sort by,
+// prefixed with "-" for descending order)
+type listParams struct {
+	Page       chef.Page
+	Sort       string
+	Descending bool
+}
+
+// isPaged reports whether the request uses any of the pagination or sorting query parameters. Requests that
+// don't are answered with the full, unwrapped list for compatibility with older clients.
+func isPaged(c echo.Context) bool {
+	return c.QueryParam("limit") != "" || c.QueryParam("offset") != "" || c.QueryParam("sort") != ""
+}
+
+func parseListParams(c echo.Context) (listParams, error) {
+	var p listParams
+	var err error
+
+	if v := c.QueryParam("limit"); v != "" {
+		if p.Page.Rows, err = strconv.Atoi(v); err != nil || p.Page.Rows < 0 {
+			return p, errInvalidListParams
+		}
+	}
+	if v := c.QueryParam("offset"); v != "" {
+		if p.Page.Start, err = strconv.Atoi(v); err != nil || p.Page.Start < 0 {
+			return p, errInvalidListParams
+		}
+	}
+
+	p.Sort, p.Descending = strings.CutPrefix(c.QueryParam("sort"), "-")
+	if c.QueryParam("order") == "desc" {
+		p.Descending = true
+	}
+
+	return p, nil
+}
@@ -11,7 +11,7 @@ func (s *Service) getPolicies(c echo.Context) error {
 	policies, err := s.chef.GetPolicies(c.Request().Context())
 	if err != nil {
 		s.log.Error("failed to fetch policies from server", zap.Error(err))
-		return c.JSON(http.StatusInternalServerError, "failed to fetch policies from server")
+		return c.JSON(errorStatus(err), ErrorResponse("failed to fetch policies from server"))
 	}
 	return c.JSON(http.StatusOK, SuccessResponse(policies))
 }
@@ -21,7 +21,7 @@ func (s *Service) getPolicy(c echo.Context) error {
 	policies, err := s.chef.GetPolicy(c.Request().Context(), name)
 	if err != nil {
 		s.log.Error("failed to fetch policy from server", zap.Error(err))
-		return c.JSON(http.StatusNotFound, "failed to fetch policy from server")
+		return c.JSON(errorStatus(err), ErrorResponse("failed to fetch policy from server"))
 	}
 	return c.JSON(http.StatusOK, SuccessResponse(policies))
 }
@@ -32,7 +32,7 @@ func (s *Service) getPolicyRevision(c echo.Context) error {
 	policyRevision, err := s.chef.GetPolicyRevision(c.Request().Context(), name, revision)
 	if err != nil {
 		s.log.Error("failed to fetch policy revision from server", zap.Error(err))
-		return c.JSON(http.StatusNotFound, "failed to fetch policy revision from server")
+		return c.JSON(errorStatus(err), ErrorResponse("failed to fetch policy revision from server"))
 	}
 	return c.JSON(http.StatusOK, SuccessResponse(policyRevision))
 }
@@ -41,7 +41,7 @@ func (s *Service) getPolicyGroups(c echo.Context) error {
 	policyGroups, err := s.chef.GetPolicyGroups(c.Request().Context())
 	if err != nil {
 		s.log.Error("failed to fetch policy groups from server", zap.Error(err))
-		return c.JSON(http.StatusInternalServerError, "failed to fetch policy groups from server")
+		return c.JSON(errorStatus(err), ErrorResponse("failed to fetch policy groups from server"))
 	}
 	return c.JSON(http.StatusOK, SuccessResponse(policyGroups))
 }
@@ -51,7 +51,7 @@ func (s *Service) getPolicyGroup(c echo.Context) error {
 	policyGroup, err := s.chef.GetPolicyGroup(c.Request().Context(), name)
 	if err != nil {
 		s.log.Error("failed to fetch policy group from server", zap.Error(err))
-		return c.JSON(http.StatusNotFound, "failed to fetch policy group from server")
+		return c.JSON(errorStatus(err), ErrorResponse("failed to fetch policy group from server"))
 	}
 	return c.JSON(http.StatusOK, SuccessResponse(policyGroup))
 }
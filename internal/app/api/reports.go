@@ -0,0 +1,53 @@
+package api
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/drewhammond/chefbrowser/internal/chef"
+	"github.com/labstack/echo/v4"
+	"go.uber.org/zap"
+)
+
+// getStaleNodes returns the nodes that have not completed a chef-client run within the threshold query parameter
+// (e.g. ?threshold=48h) or the configured default. Add ?format=csv to download the report as CSV.
+func (s *Service) getStaleNodes(c echo.Context) error {
+	threshold := s.config.Reports.StaleThreshold
+	if v := c.QueryParam("threshold"); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil || d <= 0 {
+			return c.JSON(http.StatusBadRequest, ErrorResponse("threshold must be a positive duration (e.g. 24h)"))
+		}
+		threshold = d
+	}
+
+	groupBy := c.QueryParam("group_by")
+	if groupBy != "" && groupBy != chef.GroupByEnvironment && groupBy != chef.GroupByPolicyGroup {
+		return c.JSON(http.StatusBadRequest, ErrorResponse("group_by must be environment or policy_group"))
+	}
+
+	report, err := s.chef.GetStaleNodes(c.Request().Context(), threshold, groupBy)
+	if err != nil {
+		s.log.Error("failed to build stale node report", zap.Error(err))
+		return c.JSON(errorStatus(err), ErrorResponse("failed to build stale node report"))
+	}
+
+	if c.QueryParam("format") == "csv" {
+		c.Response().Header().Set(echo.HeaderContentDisposition, `attachment; filename="stale-nodes.csv"`)
+		c.Response().Header().Set(echo.HeaderContentType, "text/csv; charset=utf-8")
+		c.Response().WriteHeader(http.StatusOK)
+		return report.WriteCSV(c.Response())
+	}
+
+	return c.JSON(http.StatusOK, SuccessResponse(report))
+}
+
+// getInventory returns node counts by platform, chef client version, environment, policy group and role
+func (s *Service) getInventory(c echo.Context) error {
+	inventory, err := s.chef.GetInventory(c.Request().Context())
+	if err != nil {
+		s.log.Error("failed to build inventory report", zap.Error(err))
+		return c.JSON(errorStatus(err), ErrorResponse("failed to build inventory report"))
+	}
+	return c.JSON(http.StatusOK, SuccessResponse(inventory))
+}
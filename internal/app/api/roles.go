@@ -12,7 +12,7 @@ func (s *Service) getRoles(c echo.Context) error {
 	roles, err := s.chef.GetRoles(c.Request().Context())
 	if err != nil {
 		s.log.Error("failed to fetch roles from server", zap.Error(err))
-		return c.JSON(http.StatusInternalServerError, ErrorResponse("failed to fetch roles from server"))
+		return c.JSON(errorStatus(err), ErrorResponse("failed to fetch roles from server"))
 	}
 	return c.JSON(http.StatusOK, roles)
 }
@@ -23,7 +23,24 @@ func (s *Service) getRole(c echo.Context) error {
 	role, err := s.chef.GetRole(c.Request().Context(), name)
 	if err != nil {
 		s.log.Error("failed to fetch role from server", zap.Error(err))
-		return c.JSON(http.StatusNotFound, ErrorResponse("failed to fetch role from server"))
+		return c.JSON(errorStatus(err), ErrorResponse("failed to fetch role from server"))
 	}
 	return c.JSON(http.StatusOK, role)
 }
+
+// getRoleExpandedRunList returns the run list of a role with every nested role recursively expanded in the requested
+// environment (?environment=, defaults to _default)
+func (s *Service) getRoleExpandedRunList(c echo.Context) error {
+	name := c.Param("name")
+	if _, err := s.chef.GetRole(c.Request().Context(), name); err != nil {
+		s.log.Error("failed to fetch role from server", zap.Error(err))
+		return c.JSON(errorStatus(err), ErrorResponse("failed to fetch role from server"))
+	}
+
+	expanded, err := s.chef.ExpandRunList(c.Request().Context(), []string{"role[" + name + "]"}, c.QueryParam("environment"))
+	if err != nil {
+		s.log.Error("failed to expand role run list", zap.Error(err))
+		return c.JSON(errorStatus(err), ErrorResponse("failed to expand role run list"))
+	}
+	return c.JSON(http.StatusOK, SuccessResponse(expanded))
+}
@@ -0,0 +1,58 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"go.uber.org/zap"
+)
+
+func (s *Service) search(c echo.Context) error {
+	query := c.QueryParam("q")
+	if query == "" {
+		return c.JSON(http.StatusBadRequest, ErrorResponse("missing search query (q)"))
+	}
+
+	results, err := s.chef.SearchAll(c.Request().Context(), query)
+	if err != nil {
+		s.log.Error("failed to search chef server", zap.Error(err))
+		return c.JSON(errorStatus(err), ErrorResponse("failed to search chef server"))
+	}
+	return c.JSON(http.StatusOK, SuccessResponse(results))
+}
+
+func (s *Service) searchIndex(c echo.Context) error {
+	index := c.Param("index")
+	query := c.QueryParam("q")
+	if query == "" {
+		return c.JSON(http.StatusBadRequest, ErrorResponse("missing search query (q)"))
+	}
+
+	results, err := s.chef.Search(c.Request().Context(), index, query)
+	if err != nil {
+		s.log.Error("failed to search chef server", zap.String("index", index), zap.Error(err))
+		return c.JSON(errorStatus(err), ErrorResponse("failed to search chef server"))
+	}
+	return c.JSON(http.StatusOK, SuccessResponse(results))
+}
+
+// searchOrganizations searches the nodes of every organization. Organizations that fail or time out are listed in
+// the response; the request only fails if no organization could be searched.
+func (s *Service) searchOrganizations(c echo.Context) error {
+	query := c.QueryParam("q")
+	if query == "" {
+		return c.JSON(http.StatusBadRequest, ErrorResponse("missing search query (q)"))
+	}
+
+	results := s.orgs.SearchNodes(c.Request().Context(), query, s.config.Chef.FederatedSearchTimeout)
+	for _, org := range results.Organizations {
+		if org.Error != "" {
+			s.log.Warn("failed to search organization", zap.String("organization", org.Organization),
+				zap.String("error", org.Error))
+		}
+	}
+	if results.Failed() {
+		return c.JSON(http.StatusBadGateway, ErrorResponse("failed to search every organization"))
+	}
+	return c.JSON(http.StatusOK, SuccessResponse(results))
+}
@@ -0,0 +1,27 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"go.uber.org/zap"
+)
+
+func (s *Service) getUsers(c echo.Context) error {
+	users, err := s.chef.GetUsers(c.Request().Context())
+	if err != nil {
+		s.log.Error("failed to fetch users from server", zap.Error(err))
+		return c.JSON(errorStatus(err), ErrorResponse("failed to fetch users from server"))
+	}
+	return c.JSON(http.StatusOK, SuccessResponse(users))
+}
+
+// getUser returns a single member of the organization along with the fingerprint of its public key
+func (s *Service) getUser(c echo.Context) error {
+	user, err := s.chef.GetUser(c.Request().Context(), c.Param("name"))
+	if err != nil {
+		s.log.Error("failed to fetch user from server", zap.Error(err))
+		return c.JSON(errorStatus(err), ErrorResponse("failed to fetch user from server"))
+	}
+	return c.JSON(http.StatusOK, SuccessResponse(user))
+}
@@ -10,6 +10,7 @@ import (
 	"github.com/drewhammond/chefbrowser/config"
 	"github.com/drewhammond/chefbrowser/internal/app/api"
 	"github.com/drewhammond/chefbrowser/internal/app/ui"
+	"github.com/drewhammond/chefbrowser/internal/auth"
 	"github.com/drewhammond/chefbrowser/internal/chef"
 	"github.com/drewhammond/chefbrowser/internal/common/logging"
 	"github.com/drewhammond/chefbrowser/internal/common/version"
@@ -20,7 +21,7 @@ import (
 
 type AppService struct {
 	Log        *logging.Logger
-	Chef       *chef.Service
+	Orgs       *chef.Organizations
 	APIService *api.Service
 	UIService  *ui.Service
 }
@@ -63,7 +64,7 @@ func New(cfg *config.Config) {
 			LogStatus:       true,
 			LogResponseSize: true,
 			LogValuesFunc: func(c echo.Context, v middleware.RequestLoggerValues) error {
-				logger.Info("request",
+				fields := []zap.Field{
 					zap.String("remote_ip", v.RemoteIP),
 					zap.String("host", v.Host),
 					zap.String("method", v.Method),
@@ -72,14 +73,19 @@ func New(cfg *config.Config) {
 					zap.Int64("latency_ms", v.Latency.Milliseconds()),
 					zap.Int64("response_bytes", v.ResponseSize),
 					zap.String("user_agent", v.UserAgent),
-				)
+				}
+				if id := auth.IdentityFromContext(c); id != nil {
+					fields = append(fields, zap.String("user", id.Username))
+				}
+				logger.Info("request", fields...)
 				return nil
 			},
 		}
 		if !cfg.Logging.LogHealthChecks {
 			logger.Debug("log_health_checks = false; requests to health check endpoint will not be logged")
 			logCfg.Skipper = func(c echo.Context) bool {
-				return c.Path() == cfg.Server.BasePath+"/api/health"
+				// every organization has its own health check endpoint, see api.RegisterRoutes
+				return strings.HasSuffix(c.Path(), "/api/health")
 			}
 		}
 		engine.Use(middleware.RequestLoggerWithConfig(logCfg))
@@ -89,29 +95,32 @@ func New(cfg *config.Config) {
 		engine.Use(middleware.Gzip())
 	}
 
-	if cfg.Server.TrustedProxies != "" {
+	trustedProxies := parseTrustedProxies(cfg.Server.TrustedProxies, logger)
+	if len(trustedProxies) > 0 {
 		var opts []echo.TrustOption
 		opts = append(opts, echo.TrustPrivateNet(false))
-		for _, x := range strings.Split(cfg.Server.TrustedProxies, ",") {
-			_, j, err := net.ParseCIDR(x)
-			if err != nil {
-				logger.Error(fmt.Sprintf("invalid proxy network specified: %s", x))
-				continue
-			}
-			opts = append(opts, echo.TrustIPRange(j))
+		for _, network := range trustedProxies {
+			opts = append(opts, echo.TrustIPRange(network))
 		}
 		engine.IPExtractor = echo.ExtractIPFromXFFHeader(opts...)
 	} else {
 		engine.IPExtractor = echo.ExtractIPDirect()
 	}
 
-	chefService := chef.New(cfg, logger)
+	if cfg.Auth.Enabled {
+		auth.New(cfg, engine, logger, trustedProxies).RegisterRoutes()
+	} else {
+		logger.Warn("authentication is disabled; anyone who can reach chefbrowser can browse the chef server")
+	}
+	engine.Use(viewerMiddleware)
+
+	orgs := chef.NewOrganizations(cfg, logger)
 
 	app := AppService{
 		Log:        logger,
-		Chef:       chefService,
-		APIService: api.New(cfg, engine, chefService, logger),
-		UIService:  ui.New(cfg, engine, chefService, logger),
+		Orgs:       orgs,
+		APIService: api.New(cfg, engine, orgs, logger),
+		UIService:  ui.New(cfg, engine, orgs, logger),
 	}
 	app.APIService.RegisterRoutes()
 	app.UIService.RegisterRoutes()
@@ -123,6 +132,34 @@ func New(cfg *config.Config) {
 	}
 }
 
+// viewerMiddleware makes the chef services apply the authorization policy for the groups of the authenticated user.
+// Without authentication every request is made by an anonymous user in no groups.
+func viewerMiddleware(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		if id := auth.IdentityFromContext(c); id != nil {
+			c.SetRequest(c.Request().WithContext(chef.WithViewer(c.Request().Context(), id.Groups)))
+		}
+		return next(c)
+	}
+}
+
+// parseTrustedProxies parses the comma-separated list of trusted proxy networks, skipping invalid networks
+func parseTrustedProxies(list string, logger *logging.Logger) []*net.IPNet {
+	var networks []*net.IPNet
+	for _, x := range strings.Split(list, ",") {
+		if x = strings.TrimSpace(x); x == "" {
+			continue
+		}
+		_, network, err := net.ParseCIDR(x)
+		if err != nil {
+			logger.Error(fmt.Sprintf("invalid proxy network specified: %s", x))
+			continue
+		}
+		networks = append(networks, network)
+	}
+	return networks
+}
+
 // normalizeBasePath cleans and strips trailing slashes from the configured base_path
 func normalizeBasePath(p string) string {
 	p = path.Clean(p)
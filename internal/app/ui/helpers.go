@@ -0,0 +1,129 @@
+package ui
+
+import (
+	"encoding/json"
+	"fmt"
+	"maps"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/drewhammond/chefbrowser/internal/chef"
+	"github.com/labstack/echo/v4"
+)
+
+// isRedacted returns true if an attribute value was hidden from the user by the redaction policy
+func isRedacted(v interface{}) bool {
+	s, ok := v.(string)
+	return ok && s == chef.RedactedValue
+}
+
+// formatValue renders an attribute value for display. Numbers decoded from JSON are always float64, which the
+// template engine would otherwise print in scientific notation (e.g. ohai_time).
+func formatValue(v interface{}) string {
+	switch t := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return t
+	case float64:
+		return strconv.FormatFloat(t, 'f', -1, 64)
+	case map[string]interface{}, []interface{}:
+		b, err := json.Marshal(t)
+		if err != nil {
+			return fmt.Sprint(t)
+		}
+		return string(b)
+	default:
+		return fmt.Sprint(t)
+	}
+}
+
+// percent returns n as a percentage of total, formatted for display
+func percent(n int, total int) string {
+	if total == 0 {
+		return "0"
+	}
+	return strconv.FormatFloat(float64(n)*100/float64(total), 'f', 1, 64)
+}
+
+// shortDuration formats d without redundant zero units, e.g. 24h instead of 24h0m0s
+func shortDuration(d time.Duration) string {
+	s := d.String()
+	if strings.HasSuffix(s, "m0s") {
+		s = strings.TrimSuffix(s, "0s")
+	}
+	if strings.HasSuffix(s, "h0m") {
+		s = strings.TrimSuffix(s, "0m")
+	}
+	return s
+}
+
+// pagination holds everything needed to render the page links of a paginated list
+type pagination struct {
+	Page  int
+	Pages int
+	Prev  string
+	Next  string
+	Links []pageLink
+}
+
+// pageLink is a link to a single page. A zero Number marks a gap between non-adjacent pages.
+type pageLink struct {
+	Number int
+	URL    string
+	Active bool
+}
+
+// currentPage returns the 1-based page number requested with the "page" query parameter and the window of
+// objects it selects
+func (s *Service) currentPage(c echo.Context) (int, chef.Page) {
+	page, err := strconv.Atoi(c.QueryParam("page"))
+	if err != nil || page < 1 {
+		page = 1
+	}
+
+	size := max(s.config.UI.PageSize, 0)
+	if size == 0 {
+		return 1, chef.Page{}
+	}
+
+	return page, chef.Page{Start: (page - 1) * size, Rows: size}
+}
+
+// newPagination returns the page links for a list of total objects, or nil if everything fits on a single page.
+// Links keep every other query parameter (e.g. the search query and sort order) of the current request.
+func newPagination(c echo.Context, page int, p chef.Page, total int) *pagination {
+	if p.Rows <= 0 || total <= p.Rows {
+		return nil
+	}
+
+	// echo caches the query of the request, so change a copy of it
+	q := maps.Clone(c.QueryParams())
+	pageURL := func(n int) string {
+		q.Set("page", strconv.Itoa(n))
+		return "?" + q.Encode()
+	}
+
+	pg := &pagination{Page: page, Pages: (total + p.Rows - 1) / p.Rows}
+	if page > 1 {
+		pg.Prev = pageURL(page - 1)
+	}
+	if page < pg.Pages {
+		pg.Next = pageURL(page + 1)
+	}
+
+	// always link the first and last pages along with the pages surrounding the current one
+	const surrounding = 2
+	for n := 1; n <= pg.Pages; n++ {
+		if n != 1 && n != pg.Pages && (n < page-surrounding || n > page+surrounding) {
+			if pg.Links[len(pg.Links)-1].Number != 0 {
+				pg.Links = append(pg.Links, pageLink{})
+			}
+			continue
+		}
+		pg.Links = append(pg.Links, pageLink{Number: n, URL: pageURL(n), Active: n == page})
+	}
+
+	return pg
+}
@@ -1,16 +1,20 @@
 package ui
 
 import (
-	"crypto/tls"
 	"errors"
 	"fmt"
 	"html/template"
+	"io"
 	"net/http"
+	"net/url"
 	"path/filepath"
+	"slices"
 	"sort"
 	"strings"
+	"time"
 
 	"github.com/drewhammond/chefbrowser/config"
+	"github.com/drewhammond/chefbrowser/internal/auth"
 	"github.com/drewhammond/chefbrowser/internal/chef"
 	"github.com/drewhammond/chefbrowser/internal/common/logging"
 	"github.com/drewhammond/chefbrowser/internal/common/version"
@@ -33,9 +37,12 @@ func embeddedFH(config goview.Config, tmpl string) (string, error) {
 }
 
 type Service struct {
-	log         *logging.Logger
-	config      *config.Config
-	chef        *chef.Service
+	log    *logging.Logger
+	config *config.Config
+	chef   *chef.Service
+	orgs   *chef.Organizations
+	// org is the organization in the route prefix, or empty for the unprefixed routes of the default organization
+	org         string
 	engine      *echo.Echo
 	customLinks *CustomLinksCollection
 }
@@ -53,10 +60,11 @@ type CustomLinksCollection struct {
 	DataBags     []CustomLink // Unused, but maybe in the future
 }
 
-func New(config *config.Config, engine *echo.Echo, chef *chef.Service, logger *logging.Logger) *Service {
+func New(config *config.Config, engine *echo.Echo, orgs *chef.Organizations, logger *logging.Logger) *Service {
 	s := Service{
-		config: config,
-		chef:   chef,
+		config: config.ForOrganization(orgs.Default().Organization()),
+		chef:   orgs.Default(),
+		orgs:   orgs,
 		log:    logger,
 		engine: engine,
 	}
@@ -64,25 +72,14 @@ func New(config *config.Config, engine *echo.Echo, chef *chef.Service, logger *l
 	return &s
 }
 
+// RegisterRoutes registers the UI of the default organization under /ui and the UI of every organization under
+// /orgs/<name>/ui. Each organization renders its templates with its own view engine so that links stay within the
+// organization.
 func (s *Service) RegisterRoutes() {
 	s.log.Info("registering UI routes")
 
-	templateRoot := "templates"
-	disableCache := false
 	if s.config.App.AppMode == "development" {
 		s.log.Warn("development mode enabled! view cache is disabled and templates are not loaded from embed.FS")
-		templateRoot = "ui/templates"
-		disableCache = true
-	}
-
-	cfg := goview.Config{
-		Root:         templateRoot,
-		Extension:    ".html",
-		Master:       "layouts/master",
-		Partials:     []string{},
-		Funcs:        make(template.FuncMap),
-		DisableCache: disableCache,
-		Delims:       goview.Delims{Left: "{{", Right: "}}"},
 	}
 
 	vCfg := ViteConfig{
@@ -105,20 +102,9 @@ func (s *Service) RegisterRoutes() {
 		s.log.Error("failed to validate custom links configuration", zap.Error(err))
 	}
 
-	viteTags := vite.HTMLTags
-	cfg.Funcs["makeRunListURL"] = s.makeRunListURL
-	cfg.Funcs["base_path"] = func() string { return basePath }
-	cfg.Funcs["app_version"] = func() string { return version.Get().Version }
-	cfg.Funcs["vite_assets"] = func() template.HTML {
-		return template.HTML(viteTags)
-	}
-
-	ev := echoview.New(cfg)
-	if s.config.App.AppMode == "production" {
-		ev.ViewEngine.SetFileHandler(embeddedFH)
-	}
-
-	s.engine.Renderer = ev
+	renderer := &orgRenderer{engines: map[string]*echoview.ViewEngine{}}
+	renderer.engines[""] = s.newViewEngine(vite.HTMLTags)
+	s.engine.Renderer = renderer
 
 	s.engine.GET(urlWithBasePath(""), func(c echo.Context) error {
 		return c.Redirect(http.StatusFound, urlWithBasePath("/ui/nodes"))
@@ -138,42 +124,125 @@ func (s *Service) RegisterRoutes() {
 	})
 
 	router := s.engine.Group(vCfg.Base)
-	{
-		router.GET("/", func(c echo.Context) error {
-			return c.Redirect(http.StatusFound, urlWithBasePath("/ui/nodes"))
+	router.GET("/assets/*", ViteHandler(vCfg.Base), CacheControlMiddleware)
+	router.GET("/favicons/*", ViteHandler(vCfg.Base), CacheControlMiddleware)
+	router.GET("/federated/nodes", s.searchOrganizations)
+	s.registerRoutes(router)
+
+	for _, name := range s.orgs.Names() {
+		org := *s
+		org.org = name
+		org.chef, _ = s.orgs.Get(name)
+		org.config = s.config.ForOrganization(name)
+		renderer.engines[name] = org.newViewEngine(vite.HTMLTags)
+
+		s.engine.GET(urlWithBasePath(orgPath(name)), func(c echo.Context) error {
+			return c.Redirect(http.StatusFound, org.urlWithOrgPath("/ui/nodes"))
 		})
-		router.GET("/nodes", s.getNodes)
-		router.GET("/nodes/:name", s.getNode)
+		org.registerRoutes(s.engine.Group(urlWithBasePath(orgPath(name) + "/ui")))
+	}
+}
+
+func (s *Service) registerRoutes(router *echo.Group) {
+	router.GET("", func(c echo.Context) error {
+		return c.Redirect(http.StatusFound, s.urlWithOrgPath("/ui/nodes"))
+	})
+	router.GET("/", func(c echo.Context) error {
+		return c.Redirect(http.StatusFound, s.urlWithOrgPath("/ui/nodes"))
+	})
+	router.GET("/nodes", s.getNodes)
+	router.GET("/nodes/:name", s.getNode)
+	router.GET("/nodes/:name/explain", s.explainNodeAttribute)
 
-		router.GET("/environments", s.getEnvironments)
-		router.GET("/environments/:name", s.getEnvironment)
+	router.GET("/environments", s.getEnvironments)
+	router.GET("/environments/:name", s.getEnvironment)
 
-		router.GET("/roles", s.getRoles)
-		router.GET("/roles/:name", s.getRole)
+	router.GET("/roles", s.getRoles)
+	router.GET("/roles/:name", s.getRole)
 
-		router.GET("/databags", s.getDatabags)
-		router.GET("/databags/:name", s.getDatabagItems)
-		router.GET("/databags/:name/:item", s.getDatabagItemContent)
+	router.GET("/databags", s.getDatabags)
+	router.GET("/databags/:name", s.getDatabagItems)
+	router.GET("/databags/:name/:item", s.getDatabagItemContent)
+
+	router.GET("/cookbooks", s.getCookbooks)
+	router.GET("/cookbooks/:name", s.getCookbook)
+	router.GET("/cookbooks/:name/:version", s.getCookbookVersion)
+	router.GET("/cookbooks/:name/:version/files", s.getCookbookFiles)
+	router.GET("/cookbooks/:name/:version/file/*", s.getCookbookFile)
+	router.GET("/cookbooks/:name/:version/recipes", s.getCookbookRecipes)
+	router.GET("/cookbooks/:name/:version/dependencies", s.getCookbookDependencies)
+	router.GET("/cookbooks/:name/:version/used-by", s.getCookbookUsage)
+	router.GET("/cookbooks/:name/compare", s.compareCookbookVersions)
+	router.GET("/cookbooks/:name/compare/:range", s.compareCookbookVersions)
+
+	router.GET("/groups", s.getGroups)
+	router.GET("/groups/:name", s.getGroup)
+
+	router.GET("/clients", s.getClients)
+	router.GET("/clients/:name", s.getClient)
+
+	router.GET("/users", s.getUsers)
+	router.GET("/users/:name", s.getUser)
+
+	router.GET("/policies", s.getPolicies)
+	router.GET("/policies/:name", s.getPolicy)
+	router.GET("/policies/:name/compare", s.comparePolicies)
+	router.GET("/policies/:name/:revision", s.getPolicyRevision)
+	router.GET("/policy-groups", s.getPolicyGroups)
+	router.GET("/policy-groups/:name", s.getPolicyGroup)
+
+	router.GET("/search", s.search)
+
+	router.GET("/compare/nodes", s.compareNodes)
+	router.GET("/compare/environments", s.compareEnvironments)
+
+	router.GET("/reports/stale", s.getStaleNodes)
+	router.GET("/reports/inventory", s.getInventory)
+	router.GET("/reports/acl", s.getACLReport)
+
+}
 
-		router.GET("/cookbooks", s.getCookbooks)
-		router.GET("/cookbooks/:name", s.getCookbook)
-		router.GET("/cookbooks/:name/:version", s.getCookbookVersion)
-		router.GET("/cookbooks/:name/:version/files", s.getCookbookFiles)
-		router.GET("/cookbooks/:name/:version/file/*", s.getCookbookFile)
-		router.GET("/cookbooks/:name/:version/recipes", s.getCookbookRecipes)
+// newViewEngine returns the view engine used to render the templates of the organization in the route prefix
+func (s *Service) newViewEngine(viteTags string) *echoview.ViewEngine {
+	templateRoot := "templates"
+	disableCache := false
+	if s.config.App.AppMode == "development" {
+		templateRoot = "ui/templates"
+		disableCache = true
+	}
 
-		router.GET("/groups", s.getGroups)
-		router.GET("/groups/:name", s.getGroup)
+	cfg := goview.Config{
+		Root:         templateRoot,
+		Extension:    ".html",
+		Master:       "layouts/master",
+		Partials:     []string{},
+		Funcs:        make(template.FuncMap),
+		DisableCache: disableCache,
+		Delims:       goview.Delims{Left: "{{", Right: "}}"},
+	}
 
-		router.GET("/policies", s.getPolicies)
-		router.GET("/policies/:name", s.getPolicy)
-		router.GET("/policies/:name/:revision", s.getPolicyRevision)
-		router.GET("/policy-groups", s.getPolicyGroups)
-		router.GET("/policy-groups/:name", s.getPolicyGroup)
+	cfg.Funcs["makeRunListURL"] = s.makeRunListURL
+	cfg.Funcs["format_value"] = formatValue
+	cfg.Funcs["redacted"] = isRedacted
+	cfg.Funcs["redacted_value"] = func() string { return chef.RedactedValue }
+	cfg.Funcs["percent"] = percent
+	cfg.Funcs["base_path"] = func() string { return s.urlWithOrgPath("") }
+	// assets are shared by every organization and only served under the unprefixed /ui routes
+	cfg.Funcs["assets_path"] = func() string { return urlWithBasePath("/ui") }
+	cfg.Funcs["organization"] = s.chef.Organization
+	cfg.Funcs["organizations"] = s.orgs.Names
+	cfg.Funcs["organization_path"] = func(name string) string { return urlWithBasePath(orgPath(name)) }
+	cfg.Funcs["root_path"] = func() string { return urlWithBasePath("") }
+	cfg.Funcs["app_version"] = func() string { return version.Get().Version }
+	cfg.Funcs["vite_assets"] = func() template.HTML {
+		return template.HTML(viteTags)
+	}
 
-		router.GET("/assets/*", ViteHandler(vCfg.Base), CacheControlMiddleware)
-		router.GET("/favicons/*", ViteHandler(vCfg.Base), CacheControlMiddleware)
+	ev := echoview.New(cfg)
+	if s.config.App.AppMode == "production" {
+		ev.ViewEngine.SetFileHandler(embeddedFH)
 	}
+	return ev
 }
 
 // BuildCustomLinks returns a map of custom links to be displayed in the UI
@@ -221,97 +290,261 @@ func (s *Service) getNode(c echo.Context) error {
 	name := c.Param("name")
 	node, err := s.chef.GetNode(c.Request().Context(), name)
 	if err != nil {
-		return c.Render(http.StatusNotFound, "errors/404", echo.Map{
-			"message": "Node not found",
-		})
+		return s.renderError(c, err, "Node not found")
 	}
 
+	// a broken run list shouldn't prevent the rest of the node from being shown
+	expanded, err := s.chef.ExpandNodeRunList(c.Request().Context(), node)
+	if err != nil {
+		s.log.Warn("failed to expand node run list", zap.Error(err))
+	}
+
+	acl, aclError := s.objectACL(c, chef.ACLTypeNode, node.Name)
+
 	return c.Render(http.StatusOK, "node", echo.Map{
-		"active_nav":   "nodes",
-		"custom_links": s.customLinks.Nodes,
-		"node":         node,
-		"title":        node.Name,
+		"acl":               acl,
+		"acl_error":         aclError,
+		"active_nav":        "nodes",
+		"custom_links":      s.customLinks.Nodes,
+		"expanded_run_list": expanded,
+		"node":              node,
+		"title":             node.Name,
 	})
 }
 
-func (s *Service) makeRunListURL(f string) string {
-	if strings.HasPrefix(f, "recipe") {
-		var cookbook, recipe string
-		r := strings.TrimPrefix(f, "recipe[")
-		r = strings.TrimSuffix(r, "]")
-		split := strings.SplitN(r, "::", 2)
-		cookbook = split[0]
-		if len(split) == 2 {
-			recipe = split[1]
-		} else {
-			recipe = "default"
+// explainNodeAttribute renders the value of a node attribute at every precedence level and which level wins
+func (s *Service) explainNodeAttribute(c echo.Context) error {
+	node, err := s.chef.GetNode(c.Request().Context(), c.Param("name"))
+	if err != nil {
+		return s.renderError(c, err, "Node not found")
+	}
+
+	path := chef.ParseAttributePath(c.QueryParam("path"))
+	var explanation *chef.AttributeExplanation
+	if len(path) > 0 {
+		explanation, err = s.chef.ExplainAttribute(c.Request().Context(), node, path)
+		if err != nil {
+			s.log.Error("failed to explain node attribute", zap.Error(err))
+			return s.renderError(c, err, "failed to explain node attribute")
+		}
+	}
+
+	return c.Render(http.StatusOK, "node_explain", echo.Map{
+		"active_nav":  "nodes",
+		"node":        node,
+		"path":        strings.Join(path, "."),
+		"explanation": explanation,
+		"title":       node.Name,
+	})
+}
+
+// compareNodes renders the differences between nodes a and b, or an empty comparison form if either is missing
+func (s *Service) compareNodes(c echo.Context) error {
+	a, b := c.QueryParam("a"), c.QueryParam("b")
+	hideAutomatic := c.QueryParam("hide_automatic") == "true"
+
+	var comparison *chef.NodeComparison
+	if a != "" && b != "" {
+		var err error
+		comparison, err = s.chef.CompareNodes(c.Request().Context(), a, b, hideAutomatic)
+		if err != nil {
+			s.log.Error("failed to compare nodes", zap.Error(err))
+			return s.renderError(c, err, "Node not found")
 		}
-		return fmt.Sprintf("cookbooks/%s/_latest/file/recipes/%s.rb", cookbook, recipe)
 	}
-	if strings.HasPrefix(f, "role") {
-		r := strings.TrimPrefix(f, "role[")
-		r = strings.TrimSuffix(r, "]")
-		return fmt.Sprintf("roles/%s", r)
+
+	return c.Render(http.StatusOK, "compare_nodes", echo.Map{
+		"a":              a,
+		"b":              b,
+		"hide_automatic": hideAutomatic,
+		"comparison":     comparison,
+		"active_nav":     "nodes",
+		"title":          "Compare Nodes",
+	})
+}
+
+// makeRunListURL returns the UI path of a run list item: the role, or the recipe file of the cookbook version the
+// item pins (the latest one otherwise)
+func (s *Service) makeRunListURL(f string) string {
+	item := chef.ParseRunListItem(f)
+	if item.Name == "" {
+		return ""
+	}
+	if item.Type == chef.RunListRole {
+		return fmt.Sprintf("roles/%s", item.Name)
 	}
 
-	return ""
+	version := item.Version
+	if version == "" {
+		version = "_latest"
+	}
+	return fmt.Sprintf("cookbooks/%s/%s/file/recipes/%s.rb", item.Name, version, item.Recipe)
 }
 
 func (s *Service) getNodes(c echo.Context) error {
 	query := c.QueryParam("q")
-	var nodes *chef.NodeList
-	var err error
-	if query != "" {
-		if !strings.Contains(query, ":") {
-			query = fuzzifySearchStr(query)
-		}
-		nodes, err = s.chef.SearchNodes(c.Request().Context(), query)
-	} else {
-		nodes, err = s.chef.GetNodes(c.Request().Context())
+	if columns := chef.ParseColumns(s.config.NodeList.Columns...); len(columns) > 0 {
+		return s.getNodeTable(c, query, columns)
 	}
+
+	page, p := s.currentPage(c)
+	nodes, err := s.chef.GetNodesPage(c.Request().Context(), chef.FuzzifyQuery(chef.IndexNode, query), p, c.QueryParam("order") == "desc")
 	if err != nil {
 		s.log.Error("failed to fetch nodes", zap.Error(err))
-		return c.Render(http.StatusInternalServerError, "errors/500", echo.Map{
-			"message": "failed to fetch nodes",
-		})
-
+		return s.renderError(c, err, "failed to fetch nodes")
 	}
+
 	return c.Render(http.StatusOK, "nodes", echo.Map{
 		"nodes":          nodes.Nodes,
+		"total":          nodes.Total,
+		"pagination":     newPagination(c, page, p, nodes.Total),
+		"active_nav":     "nodes",
+		"search_enabled": true,
+		"title":          "All Nodes",
+	})
+}
+
+// getNodeTable renders the node list with the attribute columns configured in [node_list]
+func (s *Service) getNodeTable(c echo.Context, query string, columns []string) error {
+	order := chef.SortNodeTable{
+		Column:     c.QueryParam("sort"),
+		Descending: c.QueryParam("order") == "desc",
+	}
+	page, p := s.currentPage(c)
+	table, err := s.chef.GetNodeTable(c.Request().Context(), chef.FuzzifyQuery(chef.IndexNode, query), columns, order, p)
+	if err != nil {
+		s.log.Error("failed to fetch nodes", zap.Error(err))
+		return s.renderError(c, err, "failed to fetch nodes")
+	}
+
+	nodes := make([]string, 0, len(table.Nodes))
+	for _, n := range table.Nodes {
+		nodes = append(nodes, n.Name)
+	}
+
+	return c.Render(http.StatusOK, "nodes", echo.Map{
+		"nodes":          nodes,
+		"total":          table.Total,
+		"table":          table,
+		"pagination":     newPagination(c, page, p, table.Total),
+		"query":          query,
+		"sort":           order.Column,
+		"order":          c.QueryParam("order"),
 		"active_nav":     "nodes",
 		"search_enabled": true,
 		"title":          "All Nodes",
 	})
 }
 
-// fuzzifySearchStr mimics the fuzzy search functionality
-// provided by chef https://github.com/chef/chef/blob/main/lib/chef/search/query.rb#L109
-func fuzzifySearchStr(s string) string {
-	format := []string{
-		"tags:*%v*",
-		"roles:*%v*",
-		"fqdn:*%v*",
-		"addresses:*%v*",
-		"policy_name:*%v*",
-		"policy_group:*%v*",
+// getStaleNodes renders the nodes that have not completed a chef-client run within the threshold query parameter
+// (or the configured default). Add ?format=csv to download the report as CSV.
+func (s *Service) getStaleNodes(c echo.Context) error {
+	threshold := s.config.Reports.StaleThreshold
+	if d, err := time.ParseDuration(c.QueryParam("threshold")); err == nil && d > 0 {
+		threshold = d
+	}
+
+	groupBy := c.QueryParam("group_by")
+	if groupBy != chef.GroupByPolicyGroup {
+		groupBy = chef.GroupByEnvironment
+	}
+
+	report, err := s.chef.GetStaleNodes(c.Request().Context(), threshold, groupBy)
+	if err != nil {
+		s.log.Error("failed to build stale node report", zap.Error(err))
+		return s.renderError(c, err, "failed to build stale node report")
+	}
+
+	if c.QueryParam("format") == "csv" {
+		c.Response().Header().Set(echo.HeaderContentDisposition, `attachment; filename="stale-nodes.csv"`)
+		c.Response().Header().Set(echo.HeaderContentType, "text/csv; charset=utf-8")
+		c.Response().WriteHeader(http.StatusOK)
+		return report.WriteCSV(c.Response())
+	}
+
+	return c.Render(http.StatusOK, "stale_nodes", echo.Map{
+		"report":     report,
+		"threshold":  shortDuration(threshold),
+		"group_by":   groupBy,
+		"active_nav": "reports",
+		"title":      "Stale Nodes",
+	})
+}
+
+func (s *Service) getInventory(c echo.Context) error {
+	inventory, err := s.chef.GetInventory(c.Request().Context())
+	if err != nil {
+		s.log.Error("failed to build inventory report", zap.Error(err))
+		return s.renderError(c, err, "failed to build inventory report")
+	}
+
+	return c.Render(http.StatusOK, "inventory", echo.Map{
+		"inventory":  inventory,
+		"active_nav": "reports",
+		"title":      "Fleet Inventory",
+	})
+}
+
+func (s *Service) search(c echo.Context) error {
+	query := c.QueryParam("q")
+	index := c.QueryParam("index")
+
+	var results []chef.SearchResult
+	var err error
+	switch {
+	case query == "":
+		// render an empty search page
+	case index != "":
+		var res *chef.SearchResult
+		res, err = s.chef.Search(c.Request().Context(), index, query)
+		if err == nil && len(res.Items) > 0 {
+			results = append(results, *res)
+		}
+	default:
+		results, err = s.chef.SearchAll(c.Request().Context(), query)
 	}
-	var b strings.Builder
-	for i, f := range format {
-		if i > 0 {
-			b.WriteString(" OR ")
+	if err != nil {
+		s.log.Warn("failed to search chef server", zap.Error(err))
+		return s.renderError(c, err, "Search index not found")
+	}
+
+	return c.Render(http.StatusOK, "search", echo.Map{
+		"query":      query,
+		"index":      index,
+		"results":    results,
+		"active_nav": "search",
+		"title":      "Search",
+	})
+}
+
+// searchOrganizations searches the nodes of every organization
+func (s *Service) searchOrganizations(c echo.Context) error {
+	query := c.QueryParam("q")
+
+	var results *chef.FederatedNodeList
+	if query != "" {
+		results = s.orgs.SearchNodes(c.Request().Context(), query, s.config.Chef.FederatedSearchTimeout)
+		for _, org := range results.Organizations {
+			if org.Error != "" {
+				s.log.Warn("failed to search organization", zap.String("organization", org.Organization),
+					zap.String("error", org.Error))
+			}
 		}
-		b.WriteString(fmt.Sprintf(f, s))
 	}
-	return b.String()
+
+	return c.Render(http.StatusOK, "federated_search", echo.Map{
+		"query":      query,
+		"results":    results,
+		"active_nav": "search",
+		"title":      "Search All Organizations",
+	})
 }
 
 func (s *Service) getRoles(c echo.Context) error {
 	roles, err := s.chef.GetRoles(c.Request().Context())
 	if err != nil {
 		s.log.Error("failed to fetch roles", zap.Error(err))
-		return c.Render(http.StatusInternalServerError, "errors/500", echo.Map{
-			"message": "failed to fetch roles from server",
-		})
+		return s.renderError(c, err, "failed to fetch roles from server")
 
 	}
 	return c.Render(http.StatusOK, "roles", echo.Map{
@@ -325,16 +558,32 @@ func (s *Service) getRole(c echo.Context) error {
 	name := c.Param("name")
 	role, err := s.chef.GetRole(c.Request().Context(), name)
 	if err != nil {
-		if errors.Is(err, chef.ErrRoleNotFound) {
-			return c.Render(http.StatusNotFound, "errors/404", echo.Map{
-				"message": "Role not found",
-			})
+		return s.renderError(c, err, "Role not found")
+	}
+
+	environments := []string{"_default"}
+	for env := range role.EnvRunList {
+		if env != "_default" {
+			environments = append(environments, env)
 		}
 	}
+	sort.Strings(environments[1:])
+
+	expanded, err := s.chef.ExpandRunList(c.Request().Context(), []string{"role[" + name + "]"}, c.QueryParam("environment"))
+	if err != nil {
+		s.log.Warn("failed to expand role run list", zap.Error(err))
+	}
+
+	acl, aclError := s.objectACL(c, chef.ACLTypeRole, name)
+
 	return c.Render(http.StatusOK, "role", echo.Map{
-		"role":       role,
-		"active_nav": "roles",
-		"title":      role.Name,
+		"role":              role,
+		"acl":               acl,
+		"acl_error":         aclError,
+		"environments":      environments,
+		"expanded_run_list": expanded,
+		"active_nav":        "roles",
+		"title":             role.Name,
 	})
 }
 
@@ -343,6 +592,7 @@ func (s *Service) getCookbook(c echo.Context) error {
 	cookbook, err := s.chef.GetCookbook(c.Request().Context(), name)
 	if err != nil {
 		s.log.Warn("failed to fetch cookbook", zap.Error(err))
+		return s.renderError(c, err, "Cookbook not found!")
 	}
 	return c.Render(http.StatusOK, "cookbook", echo.Map{
 		"cookbook":   cookbook,
@@ -357,23 +607,13 @@ func (s *Service) getCookbookVersion(c echo.Context) error {
 	version := c.Param("version")
 	cookbook, err := s.chef.GetCookbookVersion(c.Request().Context(), name, version)
 	if err != nil {
-		if errors.Is(err, chef.ErrCookbookVersionNotFound) {
-			return c.Render(http.StatusNotFound, "errors/404", echo.Map{
-				"message": "Cookbook version not found!",
-			})
-		}
-		return c.Render(http.StatusInternalServerError, "errors/500", echo.Map{
-			"message": "Unknown error occurred",
-		})
+		return s.renderError(c, err, "Cookbook version not found!")
 	}
 
 	metadata := cookbook.Metadata
 
 	// TODO: should we load this on the client side to speed up the initial load?
-	customTransport := http.DefaultTransport.(*http.Transport).Clone()
-	customTransport.TLSClientConfig = &tls.Config{InsecureSkipVerify: !s.config.Chef.SSLVerify}
-	client := &http.Client{Transport: customTransport}
-	readme, err := cookbook.GetReadme(c.Request().Context(), client)
+	readme, err := s.chef.GetCookbookReadme(c.Request().Context(), cookbook)
 	if err != nil {
 		s.log.Warn("failed to fetch cookbook", zap.Error(err))
 	}
@@ -392,9 +632,7 @@ func (s *Service) getCookbookFiles(c echo.Context) error {
 	version := c.Param("version")
 	cookbook, err := s.chef.GetCookbookVersion(c.Request().Context(), name, version)
 	if err != nil {
-		return c.Render(http.StatusNotFound, "errors/404", echo.Map{
-			"message": "Cookbook version not found!",
-		})
+		return s.renderError(c, err, "Cookbook version not found!")
 	}
 	return c.Render(http.StatusOK, "cookbook_file_list", echo.Map{
 		"cookbook":   cookbook,
@@ -410,9 +648,7 @@ func (s *Service) getCookbookRecipes(c echo.Context) error {
 	version := c.Param("version")
 	cookbook, err := s.chef.GetCookbookVersion(c.Request().Context(), name, version)
 	if err != nil {
-		return c.Render(http.StatusNotFound, "errors/404", echo.Map{
-			"message": "Cookbook version not found!",
-		})
+		return s.renderError(c, err, "Cookbook version not found!")
 	}
 	return c.Render(http.StatusOK, "cookbook_recipes", echo.Map{
 		"cookbook":   cookbook,
@@ -423,26 +659,61 @@ func (s *Service) getCookbookRecipes(c echo.Context) error {
 	})
 }
 
+func (s *Service) getCookbookDependencies(c echo.Context) error {
+	name := c.Param("name")
+	version := c.Param("version")
+	cookbook, err := s.chef.GetCookbookVersion(c.Request().Context(), name, version)
+	if err != nil {
+		return s.renderError(c, err, "Cookbook version not found!")
+	}
+	dependencies, err := s.chef.GetCookbookDependencies(c.Request().Context(), name, cookbook.Metadata.Version)
+	if err != nil {
+		s.log.Warn("failed to resolve cookbook dependencies", zap.Error(err))
+		return s.renderError(c, err, "Cookbook version not found!")
+	}
+	return c.Render(http.StatusOK, "cookbook_dependencies", echo.Map{
+		"cookbook":     cookbook,
+		"active_tab":   "dependencies",
+		"active_nav":   "cookbooks",
+		"dependencies": dependencies,
+		"title":        cookbook.Name,
+	})
+}
+
+func (s *Service) getCookbookUsage(c echo.Context) error {
+	name := c.Param("name")
+	version := c.Param("version")
+	cookbook, err := s.chef.GetCookbookVersion(c.Request().Context(), name, version)
+	if err != nil {
+		return s.renderError(c, err, "Cookbook version not found!")
+	}
+	usage, err := s.chef.GetCookbookUsage(c.Request().Context(), name)
+	if err != nil {
+		s.log.Warn("failed to find cookbook usage", zap.Error(err))
+		return s.renderError(c, err, "failed to find cookbook usage")
+	}
+	return c.Render(http.StatusOK, "cookbook_used_by", echo.Map{
+		"cookbook":   cookbook,
+		"active_tab": "used_by",
+		"active_nav": "cookbooks",
+		"usage":      usage,
+		"title":      cookbook.Name,
+	})
+}
+
 func (s *Service) getCookbookFile(c echo.Context) error {
 	name := c.Param("name")
 	version := c.Param("version")
 	path := c.Param("*")
 	cookbook, err := s.chef.GetCookbookVersion(c.Request().Context(), name, version)
 	if err != nil {
-		return c.Render(http.StatusNotFound, "errors/404", echo.Map{
-			"message": "Cookbook version not found!",
-		})
+		return s.renderError(c, err, "Cookbook version not found!")
 	}
 
-	customTransport := http.DefaultTransport.(*http.Transport).Clone()
-	customTransport.TLSClientConfig = &tls.Config{InsecureSkipVerify: !s.config.Chef.SSLVerify}
-	client := &http.Client{Transport: customTransport}
-	file, err := cookbook.GetFile(c.Request().Context(), client, path)
+	file, err := s.chef.GetCookbookFile(c.Request().Context(), cookbook, path)
 	if err != nil {
 		s.log.Warn("failed to fetch cookbook", zap.Error(err))
-		return c.Render(http.StatusNotFound, "errors/404", echo.Map{
-			"message": "Cookbook file not found!",
-		})
+		return s.renderError(c, err, "Cookbook file not found!")
 	}
 
 	return c.Render(http.StatusOK, "cookbook_file", echo.Map{
@@ -455,17 +726,60 @@ func (s *Service) getCookbookFile(c echo.Context) error {
 	})
 }
 
+// compareCookbookVersions shows the differences between two cookbook versions. Without a version range a form to pick
+// the versions is shown, which redirects to /ui/cookbooks/:name/compare/<from>...<to> once both are selected.
+func (s *Service) compareCookbookVersions(c echo.Context) error {
+	name := c.Param("name")
+	from, to := c.QueryParam("from"), c.QueryParam("to")
+
+	if r := c.Param("range"); r != "" {
+		var err error
+		from, to, err = chef.ParseVersionRange(r)
+		if err != nil {
+			return c.Redirect(http.StatusFound, s.urlWithOrgPath("/ui/cookbooks/"+url.PathEscape(name)+"/compare"))
+		}
+	} else if from != "" && to != "" {
+		return c.Redirect(http.StatusFound, s.urlWithOrgPath("/ui/cookbooks/"+url.PathEscape(name)+"/compare/"+
+			url.PathEscape(from)+"..."+url.PathEscape(to)))
+	}
+
+	versions, err := s.chef.GetCookbookVersions(c.Request().Context(), name)
+	if err != nil {
+		return s.renderError(c, err, "Cookbook not found!")
+	}
+
+	var diff *chef.CookbookDiff
+	if from != "" && to != "" {
+		diff, err = s.chef.CompareCookbookVersions(c.Request().Context(), name, from, to)
+		if err != nil {
+			s.log.Error("failed to compare cookbook versions", zap.Error(err))
+			return s.renderError(c, err, "Cookbook version not found!")
+		}
+	}
+
+	return c.Render(http.StatusOK, "compare_cookbook", echo.Map{
+		"name":       name,
+		"versions":   versions,
+		"from":       from,
+		"to":         to,
+		"diff":       diff,
+		"active_nav": "cookbooks",
+		"title":      "Compare " + name,
+	})
+}
+
 func (s *Service) getCookbooks(c echo.Context) error {
-	cookbooks, err := s.chef.GetCookbooks(c.Request().Context())
+	page, p := s.currentPage(c)
+	cookbooks, err := s.chef.GetCookbooksPage(c.Request().Context(), p, c.QueryParam("order") == "desc")
 	if err != nil {
 		s.log.Warn("failed to fetch cookbooks", zap.Error(err))
-		return c.Render(http.StatusInternalServerError, "errors/500", echo.Map{
-			"message": "failed to fetch cookbooks from server",
-		})
+		return s.renderError(c, err, "failed to fetch cookbooks from server")
 	}
 
 	return c.Render(http.StatusOK, "cookbooks", echo.Map{
 		"cookbooks":  cookbooks.Cookbooks,
+		"total":      cookbooks.Total,
+		"pagination": newPagination(c, page, p, cookbooks.Total),
 		"active_nav": "cookbooks",
 		"title":      "All Cookbooks",
 	})
@@ -475,9 +789,7 @@ func (s *Service) getEnvironments(c echo.Context) error {
 	environments, err := s.chef.GetEnvironments(c.Request().Context())
 	if err != nil {
 		s.log.Warn("failed to fetch environments", zap.Error(err))
-		return c.Render(http.StatusInternalServerError, "errors/500", echo.Map{
-			"message": "failed to fetch environments from server",
-		})
+		return s.renderError(c, err, "failed to fetch environments from server")
 	}
 	return c.Render(http.StatusOK, "environments", echo.Map{
 		"environments": environments,
@@ -491,26 +803,53 @@ func (s *Service) getEnvironment(c echo.Context) error {
 	environment, err := s.chef.GetEnvironment(c.Request().Context(), name)
 	if err != nil {
 		s.log.Warn("failed to fetch environment", zap.Error(err))
-		if errors.Is(err, chef.ErrEnvironmentNotFound) {
-			return c.Render(http.StatusNotFound, "errors/404", echo.Map{
-				"message": "Environment not found",
-			})
-		}
+		return s.renderError(c, err, "Environment not found")
 	}
+
+	cookbooks, err := s.chef.ResolveEnvironmentCookbooks(c.Request().Context(), name)
+	if err != nil {
+		s.log.Warn("failed to resolve environment cookbooks", zap.Error(err))
+	}
+
+	acl, aclError := s.objectACL(c, chef.ACLTypeEnvironment, name)
+
 	return c.Render(http.StatusOK, "environment", echo.Map{
 		"environment": environment,
+		"cookbooks":   cookbooks,
+		"acl":         acl,
+		"acl_error":   aclError,
 		"active_nav":  "environments",
 		"title":       environment.Name,
 	})
 }
 
+func (s *Service) compareEnvironments(c echo.Context) error {
+	a, b := c.QueryParam("a"), c.QueryParam("b")
+
+	var comparison *chef.EnvironmentComparison
+	if a != "" && b != "" {
+		var err error
+		comparison, err = s.chef.CompareEnvironmentCookbooks(c.Request().Context(), a, b)
+		if err != nil {
+			s.log.Error("failed to compare environments", zap.Error(err))
+			return s.renderError(c, err, "Environment not found")
+		}
+	}
+
+	return c.Render(http.StatusOK, "compare_environments", echo.Map{
+		"a":          a,
+		"b":          b,
+		"comparison": comparison,
+		"active_nav": "environments",
+		"title":      "Compare Environments",
+	})
+}
+
 func (s *Service) getDatabags(c echo.Context) error {
 	databags, err := s.chef.GetDatabags(c.Request().Context())
 	if err != nil {
 		s.log.Warn("failed to fetch databags", zap.Error(err))
-		return c.Render(http.StatusInternalServerError, "errors/500", echo.Map{
-			"message": "failed to fetch databags from server",
-		})
+		return s.renderError(c, err, "failed to fetch databags from server")
 	}
 	return c.Render(http.StatusOK, "databags", echo.Map{
 		"databags":   databags,
@@ -523,17 +862,16 @@ func (s *Service) getDatabagItems(c echo.Context) error {
 	name := c.Param("name")
 	items, err := s.chef.GetDatabagItems(c.Request().Context(), name)
 	if err != nil {
-		if errors.Is(err, chef.ErrDatabagNotFound) {
-			s.log.Warn("failed to fetch databag items", zap.Error(err))
-
-			return c.Render(http.StatusNotFound, "errors/404", echo.Map{
-				"message": "Databag not found",
-			})
-		}
+		s.log.Warn("failed to fetch databag items", zap.Error(err))
+		return s.renderError(c, err, "Databag not found")
 	}
+	acl, aclError := s.objectACL(c, chef.ACLTypeDataBag, name)
+
 	return c.Render(http.StatusOK, "databag_items", echo.Map{
 		"databag":    name,
 		"items":      items,
+		"acl":        acl,
+		"acl_error":  aclError,
 		"active_nav": "databags",
 		"title":      fmt.Sprintf("Data Bag %s - All Items", name),
 	})
@@ -542,20 +880,17 @@ func (s *Service) getDatabagItems(c echo.Context) error {
 func (s *Service) getDatabagItemContent(c echo.Context) error {
 	databag := c.Param("name")
 	item := c.Param("item")
-	content, err := s.chef.GetDatabagItemContent(c.Request().Context(), databag, item)
+	content, err := s.chef.GetDatabagItem(c.Request().Context(), databag, item)
 	if err != nil {
-		if errors.Is(err, chef.ErrDatabagItemNotFound) {
-			s.log.Warn("failed to fetch databag item content", zap.Error(err))
-			return c.Render(http.StatusNotFound, "errors/404", echo.Map{
-				"message": "Databag item not found",
-			})
-		}
+		s.log.Warn("failed to fetch databag item content", zap.Error(err))
+		return s.renderError(c, err, "Databag item not found")
 	}
 	return c.Render(http.StatusOK, "databag_item_content", echo.Map{
 		"active_nav": "databags",
 		"databag":    databag,
 		"item":       item,
-		"content":    content,
+		"content":    content.Content,
+		"encryption": content.Encryption,
 		"title":      fmt.Sprintf("Data Bag %s - %s", databag, item),
 	})
 }
@@ -564,13 +899,11 @@ func (s *Service) getGroups(c echo.Context) error {
 	groups, err := s.chef.GetGroups(c.Request().Context())
 	if err != nil {
 		s.log.Warn("failed to fetch groups", zap.Error(err))
-		return c.Render(http.StatusInternalServerError, "errors/500", echo.Map{
-			"message": "failed to fetch groups from server",
-		})
+		return s.renderError(c, err, "failed to fetch groups from server")
 	}
 	return c.Render(http.StatusOK, "groups", echo.Map{
-		"content":    groups,
-		"active_nav": "groups",
+		"groups":     groups,
+		"active_nav": "access",
 		"title":      "All Groups",
 	})
 }
@@ -580,24 +913,82 @@ func (s *Service) getGroup(c echo.Context) error {
 	group, err := s.chef.GetGroup(c.Request().Context(), name)
 	if err != nil {
 		s.log.Warn("failed to fetch group", zap.Error(err))
-		return c.Render(http.StatusNotFound, "errors/404", echo.Map{
-			"message": "failed to fetch group from server",
-		})
+		return s.renderError(c, err, "Group not found")
 	}
+	acl, aclError := s.objectACL(c, chef.ACLTypeGroup, name)
+
 	return c.Render(http.StatusOK, "group", echo.Map{
-		"content":    group,
-		"active_nav": "groups",
+		"group":      group,
+		"acl":        acl,
+		"acl_error":  aclError,
+		"active_nav": "access",
 		"title":      fmt.Sprintf("Groups - %s", name),
 	})
 }
 
+func (s *Service) getClients(c echo.Context) error {
+	clients, err := s.chef.GetAPIClients(c.Request().Context())
+	if err != nil {
+		s.log.Warn("failed to fetch clients", zap.Error(err))
+		return s.renderError(c, err, "failed to fetch clients from server")
+	}
+	return c.Render(http.StatusOK, "clients", echo.Map{
+		"clients":    clients.Clients,
+		"active_nav": "access",
+		"title":      "All Clients",
+	})
+}
+
+func (s *Service) getClient(c echo.Context) error {
+	client, err := s.chef.GetAPIClient(c.Request().Context(), c.Param("name"))
+	if err != nil {
+		s.log.Warn("failed to fetch client", zap.Error(err))
+		return s.renderError(c, err, "Client not found")
+	}
+	acl, aclError := s.objectACL(c, chef.ACLTypeClient, client.Name)
+
+	return c.Render(http.StatusOK, "client", echo.Map{
+		"client":     client,
+		"acl":        acl,
+		"acl_error":  aclError,
+		"keys":       client.Keys,
+		"active_nav": "access",
+		"title":      client.Name,
+	})
+}
+
+func (s *Service) getUsers(c echo.Context) error {
+	users, err := s.chef.GetUsers(c.Request().Context())
+	if err != nil {
+		s.log.Warn("failed to fetch users", zap.Error(err))
+		return s.renderError(c, err, "failed to fetch users from server")
+	}
+	return c.Render(http.StatusOK, "users", echo.Map{
+		"users":      users.Users,
+		"active_nav": "access",
+		"title":      "All Users",
+	})
+}
+
+func (s *Service) getUser(c echo.Context) error {
+	user, err := s.chef.GetUser(c.Request().Context(), c.Param("name"))
+	if err != nil {
+		s.log.Warn("failed to fetch user", zap.Error(err))
+		return s.renderError(c, err, "User not found")
+	}
+	return c.Render(http.StatusOK, "user", echo.Map{
+		"user":       user,
+		"keys":       user.Keys,
+		"active_nav": "access",
+		"title":      user.Name,
+	})
+}
+
 func (s *Service) getPolicies(c echo.Context) error {
 	policies, err := s.chef.GetPolicies(c.Request().Context())
 	if err != nil {
 		s.log.Warn("failed to fetch policies", zap.Error(err))
-		return c.Render(http.StatusInternalServerError, "errors/500", echo.Map{
-			"message": "failed to fetch policies from server",
-		})
+		return s.renderError(c, err, "failed to fetch policies from server")
 	}
 	return c.Render(http.StatusOK, "policies", echo.Map{
 		"content":    policies,
@@ -611,9 +1002,7 @@ func (s *Service) getPolicy(c echo.Context) error {
 	policy, err := s.chef.GetPolicy(c.Request().Context(), name)
 	if err != nil {
 		s.log.Warn("failed to fetch policy", zap.Error(err))
-		return c.Render(http.StatusNotFound, "errors/404", echo.Map{
-			"message": "failed to fetch policy from server",
-		})
+		return s.renderError(c, err, "Policy not found")
 	}
 	return c.Render(http.StatusOK, "policy", echo.Map{
 		"name":       name,
@@ -629,9 +1018,7 @@ func (s *Service) getPolicyRevision(c echo.Context) error {
 	policy, err := s.chef.GetPolicyRevision(c.Request().Context(), name, revision)
 	if err != nil {
 		s.log.Warn("failed to fetch policy", zap.Error(err))
-		return c.Render(http.StatusNotFound, "errors/404", echo.Map{
-			"message": "failed to fetch policy from server",
-		})
+		return s.renderError(c, err, "Policy revision not found")
 	}
 	return c.Render(http.StatusOK, "policy-revision", echo.Map{
 		"active_nav": "policies",
@@ -642,13 +1029,67 @@ func (s *Service) getPolicyRevision(c echo.Context) error {
 	})
 }
 
+// comparePolicies shows the differences between two revisions of a policy, selected either by revision ID or by the
+// policy groups they are assigned to
+func (s *Service) comparePolicies(c echo.Context) error {
+	name := c.Param("name")
+	from, to := c.QueryParam("from"), c.QueryParam("to")
+	fromGroup, toGroup := c.QueryParam("from_group"), c.QueryParam("to_group")
+
+	policy, err := s.chef.GetPolicy(c.Request().Context(), name)
+	if err != nil {
+		s.log.Warn("failed to fetch policy", zap.Error(err))
+		return s.renderError(c, err, "Policy not found")
+	}
+	policyGroups, err := s.chef.GetPolicyGroups(c.Request().Context())
+	if err != nil {
+		s.log.Warn("failed to fetch policy groups", zap.Error(err))
+		return s.renderError(c, err, "failed to fetch policy groups from server")
+	}
+
+	var revisions, groups []string
+	for revision := range policy["revisions"] {
+		revisions = append(revisions, revision)
+	}
+	for group, pg := range policyGroups {
+		if _, ok := pg.Policies[name]; ok {
+			groups = append(groups, group)
+		}
+	}
+	sort.Strings(revisions)
+	sort.Strings(groups)
+
+	var comparison *chef.PolicyComparison
+	switch {
+	case fromGroup != "" && toGroup != "":
+		comparison, err = s.chef.ComparePolicyGroups(c.Request().Context(), name, fromGroup, toGroup)
+	case from != "" && to != "":
+		comparison, err = s.chef.ComparePolicyRevisions(c.Request().Context(), name, from, to)
+	}
+	if err != nil {
+		s.log.Warn("failed to compare policy revisions", zap.Error(err))
+		return s.renderError(c, err, "Policy revision not found")
+	}
+
+	return c.Render(http.StatusOK, "compare_policies", echo.Map{
+		"name":       name,
+		"revisions":  revisions,
+		"groups":     groups,
+		"from":       from,
+		"to":         to,
+		"from_group": fromGroup,
+		"to_group":   toGroup,
+		"comparison": comparison,
+		"active_nav": "policies",
+		"title":      fmt.Sprintf("Policy > %s > Compare", name),
+	})
+}
+
 func (s *Service) getPolicyGroups(c echo.Context) error {
 	policyGroups, err := s.chef.GetPolicyGroups(c.Request().Context())
 	if err != nil {
 		s.log.Warn("failed to fetch policy groups", zap.Error(err))
-		return c.Render(http.StatusNotFound, "errors/404", echo.Map{
-			"message": "failed to fetch policy groups from server",
-		})
+		return s.renderError(c, err, "failed to fetch policy groups from server")
 	}
 	return c.Render(http.StatusOK, "policy-groups", echo.Map{
 		"content":    policyGroups,
@@ -662,9 +1103,7 @@ func (s *Service) getPolicyGroup(c echo.Context) error {
 	policyGroup, err := s.chef.GetPolicyGroup(c.Request().Context(), name)
 	if err != nil {
 		s.log.Warn("failed to fetch policy group", zap.Error(err))
-		return c.Render(http.StatusNotFound, "errors/404", echo.Map{
-			"message": "failed to fetch policy group from server",
-		})
+		return s.renderError(c, err, "Policy group not found")
 	}
 	return c.Render(http.StatusOK, "policy-group", echo.Map{
 		"active_nav": "policies",
@@ -674,6 +1113,121 @@ func (s *Service) getPolicyGroup(c echo.Context) error {
 	})
 }
 
+// objectACL fetches the ACL shown on object detail pages. The ACL of an object may not be readable even when the
+// object is, so failures are described in the ACL panel instead of failing the page.
+func (s *Service) objectACL(c echo.Context, objectType string, name string) (chef.ACL, string) {
+	acl, err := s.chef.GetACL(c.Request().Context(), objectType, name)
+	if err != nil {
+		s.log.Warn("failed to fetch acl", zap.String("type", objectType), zap.String("name", name), zap.Error(err))
+		if errors.Is(err, chef.ErrForbidden) {
+			return nil, "The chef server denied access to this ACL"
+		}
+		return nil, "Failed to fetch the ACL from the chef server"
+	}
+	return acl, ""
+}
+
+// getACLReport renders the objects of a type whose ACL differs from the default ACL of their container
+func (s *Service) getACLReport(c echo.Context) error {
+	objectType := c.QueryParam("type")
+	if !slices.Contains(chef.ACLTypes, objectType) {
+		objectType = chef.ACLTypeRole
+	}
+	includeActors := c.QueryParam("include_actors") == "true"
+
+	report, err := s.chef.GetACLReport(c.Request().Context(), objectType, includeActors)
+	if err != nil {
+		s.log.Error("failed to build acl report", zap.Error(err))
+		return s.renderError(c, err, "failed to build acl report")
+	}
+
+	return c.Render(http.StatusOK, "acl_report", echo.Map{
+		"report":         report,
+		"types":          chef.ACLTypes,
+		"include_actors": includeActors,
+		"active_nav":     "reports",
+		"title":          "ACL Differences",
+	})
+}
+
+// renderError renders the error page matching the kind of error returned by the chef service.
+// message describes the failure and is displayed on the not found page.
+func (s *Service) renderError(c echo.Context, err error, message string) error {
+	status := chef.StatusCode(err, http.StatusInternalServerError)
+	switch status {
+	case http.StatusNotFound:
+		return c.Render(status, "errors/404", echo.Map{
+			"message": message,
+		})
+	case http.StatusForbidden:
+		if errors.Is(err, chef.ErrDenied) {
+			return c.Render(status, "errors/403", echo.Map{
+				"message": "You are not allowed to view this object",
+			})
+		}
+		return c.Render(status, "errors/403", echo.Map{
+			"message": "The chef server denied access to this object",
+		})
+	case http.StatusBadGateway:
+		return c.Render(status, "errors/502", echo.Map{
+			"message": "The chef server is unavailable or rejected our credentials",
+		})
+	case http.StatusGatewayTimeout:
+		return c.Render(status, "errors/504", echo.Map{
+			"message": "Timed out waiting for the chef server to respond",
+		})
+	}
+
+	return c.Render(status, "errors/500", echo.Map{
+		"message": message,
+	})
+}
+
 func urlWithBasePath(path string) string {
 	return basePath + path
 }
+
+// orgPath returns the route prefix of an organization
+func orgPath(org string) string {
+	return "/orgs/" + org
+}
+
+// urlWithOrgPath prefixes a path with the base path and, unless the service handles the unprefixed routes of the
+// default organization, the route prefix of its organization
+func (s *Service) urlWithOrgPath(path string) string {
+	if s.org == "" {
+		return urlWithBasePath(path)
+	}
+	return urlWithBasePath(orgPath(s.org) + path)
+}
+
+// routeOrganization returns the organization in the route prefix of a request, or an empty string for unprefixed
+// routes
+func routeOrganization(c echo.Context) string {
+	path, ok := strings.CutPrefix(c.Path(), urlWithBasePath(orgPath("")))
+	if !ok {
+		return ""
+	}
+	org, _, _ := strings.Cut(path, "/")
+	return org
+}
+
+// orgRenderer renders templates with the view engine of the organization in the route prefix
+type orgRenderer struct {
+	engines map[string]*echoview.ViewEngine
+}
+
+func (r *orgRenderer) Render(w io.Writer, name string, data interface{}, c echo.Context) error {
+	// every page shows who is logged in, so the navigation bar gets the user without every handler passing it
+	if m, ok := data.(echo.Map); ok {
+		if id := auth.IdentityFromContext(c); id != nil {
+			m["current_user"] = id
+		}
+	}
+
+	engine, ok := r.engines[routeOrganization(c)]
+	if !ok {
+		engine = r.engines[""]
+	}
+	return engine.Render(w, name, data, c)
+}
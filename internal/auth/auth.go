@@ -0,0 +1,157 @@
+package auth
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/coreos/go-oidc/v3/oidc"
+	"github.com/drewhammond/chefbrowser/config"
+	"github.com/drewhammond/chefbrowser/internal/common/logging"
+	"github.com/labstack/echo/v4"
+	"go.uber.org/zap"
+	"golang.org/x/oauth2"
+)
+
+// identityKey holds the identity of the current user in the echo context
+const identityKey = "identity"
+
+// Identity is the authenticated user of a request
+type Identity struct {
+	Username string   `json:"username"`
+	Name     string   `json:"name,omitempty"`
+	Email    string   `json:"email,omitempty"`
+	Groups   []string `json:"groups,omitempty"`
+}
+
+// IdentityFromContext returns the authenticated user of a request, or nil if authentication is disabled
+func IdentityFromContext(c echo.Context) *Identity {
+	id, _ := c.Get(identityKey).(*Identity)
+	return id
+}
+
+// Supported authentication providers
+const (
+	// ProviderOIDC logs users in with OpenID Connect and keeps them logged in with a signed session cookie
+	ProviderOIDC = "oidc"
+	// ProviderProxy trusts the identity a reverse proxy (such as oauth2-proxy) sends in request headers
+	ProviderProxy = "proxy"
+)
+
+// Service authenticates every request with the configured provider
+type Service struct {
+	log    *logging.Logger
+	config *config.Config
+	engine *echo.Echo
+	// trustedProxies are the networks allowed to send identity headers
+	trustedProxies []*net.IPNet
+
+	provider *oidc.Provider
+	verifier *oidc.IDTokenVerifier
+	oauth    *oauth2.Config
+	sessions *cookieSigner
+	secure   bool
+	// endSessionURL is the logout endpoint of the identity provider, if it has one
+	endSessionURL string
+}
+
+// New sets up the configured provider. Authentication is required for every route except the health checks, so a
+// misconfigured provider is fatal.
+func New(config *config.Config, engine *echo.Echo, logger *logging.Logger, trustedProxies []*net.IPNet) *Service {
+	s := &Service{
+		log:            logger,
+		config:         config,
+		engine:         engine,
+		trustedProxies: trustedProxies,
+	}
+
+	switch config.Auth.Provider {
+	case ProviderOIDC:
+		s.setupOIDC()
+	case ProviderProxy:
+		if len(trustedProxies) == 0 {
+			logger.Fatal("auth provider proxy requires server.trusted_proxies to be set")
+		}
+		logger.Info("reverse proxy authentication is enabled", zap.String("user_header", config.Auth.UserHeader))
+	default:
+		logger.Fatal(fmt.Sprintf("unknown auth provider %q; use %s or %s", config.Auth.Provider, ProviderOIDC,
+			ProviderProxy))
+	}
+	return s
+}
+
+func (s *Service) RegisterRoutes() {
+	s.log.Info("registering auth routes")
+
+	router := s.engine.Group(urlWithBasePath(s.config, "/auth"))
+	if s.config.Auth.Provider == ProviderOIDC {
+		router.GET("/login", s.login)
+		router.GET("/callback", s.callback)
+	}
+	// logging out changes state, so it must not be triggered by links or images on other sites
+	router.POST("/logout", s.logout)
+
+	s.engine.Use(s.Middleware)
+}
+
+// Middleware requires an authenticated user for every route except the health checks, the auth routes and static
+// assets. Unauthenticated UI requests are redirected to the identity provider while API requests are rejected.
+func (s *Service) Middleware(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		if s.public(c.Path()) {
+			return next(c)
+		}
+
+		id, err := s.identify(c)
+		if err != nil {
+			if s.isAPI(c.Path()) {
+				return c.JSON(http.StatusUnauthorized, echo.Map{"success": false, "message": "authentication required"})
+			}
+			if s.config.Auth.Provider == ProviderProxy {
+				// there is no login page to send the user to; the proxy should have authenticated them
+				return c.Render(http.StatusUnauthorized, "errors/401", echo.Map{"message": "Authentication required"})
+			}
+			return c.Redirect(http.StatusFound, urlWithBasePath(s.config, "/auth/login")+"?next="+
+				url.QueryEscape(c.Request().URL.RequestURI()))
+		}
+
+		c.Set(identityKey, id)
+		return next(c)
+	}
+}
+
+// identify returns the user of a request according to the configured provider
+func (s *Service) identify(c echo.Context) (*Identity, error) {
+	if s.config.Auth.Provider == ProviderProxy {
+		return s.identityFromHeaders(c)
+	}
+	return s.readSession(c)
+}
+
+// public returns true for the routes that don't require authentication
+func (s *Service) public(route string) bool {
+	if route == "/robots.txt" {
+		return true
+	}
+	path, ok := strings.CutPrefix(route, s.config.Server.BasePath)
+	return ok && (strings.HasSuffix(path, "/api/health") ||
+		strings.HasPrefix(path, "/auth/") ||
+		strings.HasPrefix(path, "/ui/assets/") ||
+		strings.HasPrefix(path, "/ui/favicons/"))
+}
+
+// isAPI returns true for the routes of the API of any organization
+func (s *Service) isAPI(route string) bool {
+	path := strings.TrimPrefix(route, s.config.Server.BasePath)
+	if org, ok := strings.CutPrefix(path, "/orgs/"); ok {
+		_, path, _ = strings.Cut(org, "/")
+		path = "/" + path
+	}
+	return path == "/api" || strings.HasPrefix(path, "/api/")
+}
+
+func urlWithBasePath(config *config.Config, path string) string {
+	return config.Server.BasePath + path
+}
@@ -0,0 +1,327 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/drewhammond/chefbrowser/config"
+	"github.com/drewhammond/chefbrowser/internal/common/logging"
+	"github.com/labstack/echo/v4"
+	"github.com/spf13/viper"
+	"go.uber.org/zap"
+)
+
+// mockIdP is a minimal OpenID Connect provider that logs in every user as the configured claims
+type mockIdP struct {
+	*httptest.Server
+	key       *rsa.PrivateKey
+	claims    map[string]interface{}
+	challenge string
+	nonce     string
+}
+
+func newMockIdP(t *testing.T, claims map[string]interface{}) *mockIdP {
+	t.Helper()
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	idp := &mockIdP{key: key, claims: claims}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, map[string]interface{}{
+			"issuer":                                idp.URL,
+			"authorization_endpoint":                idp.URL + "/authorize",
+			"token_endpoint":                        idp.URL + "/token",
+			"jwks_uri":                              idp.URL + "/keys",
+			"end_session_endpoint":                  idp.URL + "/logout",
+			"id_token_signing_alg_values_supported": []string{"RS256"},
+		})
+	})
+	mux.HandleFunc("/keys", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, map[string]interface{}{"keys": []map[string]string{{
+			"kty": "RSA", "alg": "RS256", "use": "sig", "kid": "test",
+			"n": base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+			"e": base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+		}}})
+	})
+	mux.HandleFunc("/authorize", func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		idp.challenge = q.Get("code_challenge")
+		idp.nonce = q.Get("nonce")
+		http.Redirect(w, r, q.Get("redirect_uri")+"?code=letmein&state="+url.QueryEscape(q.Get("state")), http.StatusFound)
+	})
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		sum := sha256.Sum256([]byte(r.FormValue("code_verifier")))
+		if r.FormValue("code") != "letmein" || base64.RawURLEncoding.EncodeToString(sum[:]) != idp.challenge {
+			w.WriteHeader(http.StatusBadRequest)
+			writeJSON(w, map[string]string{"error": "invalid_grant"})
+			return
+		}
+		writeJSON(w, map[string]interface{}{
+			"access_token": "access",
+			"token_type":   "Bearer",
+			"expires_in":   3600,
+			"id_token":     idp.idToken(t),
+		})
+	})
+	idp.Server = httptest.NewServer(mux)
+	return idp
+}
+
+// idToken returns an RS256 signed ID token holding the configured claims
+func (idp *mockIdP) idToken(t *testing.T) string {
+	claims := map[string]interface{}{
+		"iss":   idp.URL,
+		"aud":   "chefbrowser",
+		"sub":   "1234",
+		"nonce": idp.nonce,
+		"iat":   time.Now().Unix(),
+		"exp":   time.Now().Add(time.Hour).Unix(),
+	}
+	for k, v := range idp.claims {
+		claims[k] = v
+	}
+
+	header, _ := json.Marshal(map[string]string{"alg": "RS256", "kid": "test", "typ": "JWT"})
+	payload, _ := json.Marshal(claims)
+	signed := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(payload)
+	sum := sha256.Sum256([]byte(signed))
+	sig, err := rsa.SignPKCS1v15(rand.Reader, idp.key, crypto.SHA256, sum[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	return signed + "." + base64.RawURLEncoding.EncodeToString(sig)
+}
+
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+// newTestEngine returns an engine with authentication in front of a UI, API and health check route
+func newTestEngine(t *testing.T, idp *mockIdP, cfg *config.Config) *echo.Echo {
+	t.Helper()
+
+	cfg.Auth.Enabled = true
+	cfg.Auth.Provider = ProviderOIDC
+	cfg.Auth.IssuerURL = idp.URL
+	cfg.Auth.ClientID = "chefbrowser"
+	cfg.Auth.RedirectURL = "http://chefbrowser.test/auth/callback"
+	cfg.Auth.SessionSecret = "secret"
+	cfg.Auth.SessionTTL = time.Hour
+	cfg.Auth.UsernameClaim = "preferred_username"
+	cfg.Auth.GroupsClaim = "groups"
+
+	engine := echo.New()
+	New(cfg, engine, &logging.Logger{Logger: zap.NewNop()}, nil).RegisterRoutes()
+	registerTestRoutes(engine)
+	return engine
+}
+
+// registerTestRoutes adds a UI, API and health check route that respond with the identity of the user
+func registerTestRoutes(engine *echo.Echo) {
+	whoami := func(c echo.Context) error {
+		return c.JSON(http.StatusOK, IdentityFromContext(c))
+	}
+	engine.GET("/ui/nodes", whoami)
+	engine.GET("/api/nodes", whoami)
+	engine.GET("/orgs/prod/api/nodes", whoami)
+	engine.GET("/api/health", func(c echo.Context) error { return c.String(http.StatusOK, "ready") })
+}
+
+// request performs a request against the engine, sending and storing cookies in jar
+func request(engine *echo.Echo, target string, jar map[string]*http.Cookie) *httptest.ResponseRecorder {
+	return send(engine, http.MethodGet, target, jar)
+}
+
+func send(engine *echo.Echo, method string, target string, jar map[string]*http.Cookie) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, target, nil)
+	for _, cookie := range jar {
+		req.AddCookie(cookie)
+	}
+	rec := httptest.NewRecorder()
+	engine.ServeHTTP(rec, req)
+	for _, cookie := range rec.Result().Cookies() {
+		if cookie.MaxAge < 0 {
+			delete(jar, cookie.Name)
+		} else {
+			jar[cookie.Name] = cookie
+		}
+	}
+	return rec
+}
+
+// followIdP follows a redirect to the identity provider and returns where it redirects back to
+func followIdP(t *testing.T, location string) string {
+	t.Helper()
+
+	client := &http.Client{CheckRedirect: func(req *http.Request, via []*http.Request) error {
+		return http.ErrUseLastResponse
+	}}
+	res, err := client.Get(location)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = res.Body.Close()
+	callback, _ := url.Parse(res.Header.Get("Location"))
+	return callback.RequestURI()
+}
+
+func TestLogin(t *testing.T) {
+	idp := newMockIdP(t, map[string]interface{}{
+		"preferred_username": "jdoe",
+		"email":              "jdoe@example.com",
+		"groups":             []string{"sre"},
+	})
+	defer idp.Close()
+
+	engine := newTestEngine(t, idp, &config.Config{})
+	jar := map[string]*http.Cookie{}
+
+	if rec := request(engine, "/api/health", jar); rec.Code != http.StatusOK {
+		t.Errorf("expected the health check to be public, actual status: %d", rec.Code)
+	}
+	for _, path := range []string{"/api/nodes", "/orgs/prod/api/nodes"} {
+		if rec := request(engine, path, jar); rec.Code != http.StatusUnauthorized {
+			t.Errorf("expected %s to require authentication, actual status: %d", path, rec.Code)
+		}
+	}
+
+	rec := request(engine, "/ui/nodes?q=web", jar)
+	if rec.Code != http.StatusFound || rec.Header().Get("Location") != "/auth/login?next=%2Fui%2Fnodes%3Fq%3Dweb" {
+		t.Fatalf("expected a redirect to the login page, actual: %d %s", rec.Code, rec.Header().Get("Location"))
+	}
+
+	rec = request(engine, rec.Header().Get("Location"), jar)
+	authorize := rec.Header().Get("Location")
+	if !strings.HasPrefix(authorize, idp.URL+"/authorize") || !strings.Contains(authorize, "code_challenge_method=S256") {
+		t.Fatalf("expected a PKCE authorization request, actual: %s", authorize)
+	}
+
+	rec = request(engine, followIdP(t, authorize), jar)
+	if rec.Code != http.StatusFound || rec.Header().Get("Location") != "/ui/nodes?q=web" {
+		t.Fatalf("expected the callback to redirect back, actual: %d %s %s", rec.Code, rec.Header().Get("Location"),
+			rec.Body.String())
+	}
+	if _, ok := jar[sessionCookie]; !ok {
+		t.Fatal("expected a session cookie")
+	}
+
+	rec = request(engine, "/api/nodes", jar)
+	var id Identity
+	_ = json.Unmarshal(rec.Body.Bytes(), &id)
+	expected := Identity{Username: "jdoe", Email: "jdoe@example.com", Groups: []string{"sre"}}
+	if rec.Code != http.StatusOK || !reflect.DeepEqual(id, expected) {
+		t.Errorf("expected to be logged in as %+v, actual: %d %s", expected, rec.Code, rec.Body.String())
+	}
+
+	// a tampered session is rejected
+	tampered := *jar[sessionCookie]
+	tampered.Value = strings.Replace(tampered.Value, ".", "x.", 1)
+	if rec = request(engine, "/api/nodes", map[string]*http.Cookie{sessionCookie: &tampered}); rec.Code != http.StatusUnauthorized {
+		t.Errorf("expected a tampered session to be rejected, actual status: %d", rec.Code)
+	}
+
+	// the login can't be replayed once it completed
+	if rec = request(engine, followIdP(t, authorize), jar); rec.Code != http.StatusBadRequest {
+		t.Errorf("expected a replayed callback to be rejected, actual status: %d", rec.Code)
+	}
+
+	// logging out with GET would let other sites log users out
+	if rec = request(engine, "/auth/logout", jar); rec.Code != http.StatusMethodNotAllowed {
+		t.Errorf("expected GET logout to be rejected, actual status: %d", rec.Code)
+	}
+	if rec = request(engine, "/api/nodes", jar); rec.Code != http.StatusOK {
+		t.Errorf("expected to still be logged in, actual status: %d", rec.Code)
+	}
+
+	rec = send(engine, http.MethodPost, "/auth/logout", jar)
+	if rec.Code != http.StatusSeeOther || !strings.HasPrefix(rec.Header().Get("Location"), idp.URL+"/logout?client_id=chefbrowser") {
+		t.Errorf("expected logout to end the session with the identity provider, actual: %s", rec.Header().Get("Location"))
+	}
+	if rec = request(engine, "/api/nodes", jar); rec.Code != http.StatusUnauthorized {
+		t.Errorf("expected to be logged out, actual status: %d", rec.Code)
+	}
+}
+
+func TestLoginRejectsForeignState(t *testing.T) {
+	idp := newMockIdP(t, map[string]interface{}{"preferred_username": "jdoe"})
+	defer idp.Close()
+
+	engine := newTestEngine(t, idp, &config.Config{})
+	jar := map[string]*http.Cookie{}
+
+	request(engine, "/auth/login", jar)
+	// a callback started by someone else's login doesn't match our login cookie
+	if rec := request(engine, "/auth/callback?code=letmein&state=forged", jar); rec.Code != http.StatusBadRequest {
+		t.Errorf("expected a forged state to be rejected, actual status: %d", rec.Code)
+	}
+	if _, ok := jar[sessionCookie]; ok {
+		t.Error("expected no session to be started")
+	}
+}
+
+func TestIdentityFromClaims(t *testing.T) {
+	v := viper.New()
+	v.SetConfigType("ini")
+	err := v.ReadConfig(strings.NewReader(`
+[auth]
+username_claim = preferred_username
+groups_claim = groups
+
+[auth.group_mappings.0]
+group = admins
+claim = groups
+values = chef-admins, sre
+
+[auth.group_mappings.1]
+group = platform
+claim = department
+values = platform
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var cfg config.Config
+	if err = v.Unmarshal(&cfg); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		claims   map[string]interface{}
+		expected *Identity
+	}{
+		{
+			map[string]interface{}{"preferred_username": "jdoe", "groups": []interface{}{"sre", "dev"}, "department": "platform"},
+			&Identity{Username: "jdoe", Groups: []string{"admins", "dev", "platform", "sre"}},
+		},
+		{
+			map[string]interface{}{"email": "jdoe@example.com", "groups": "dev"},
+			&Identity{Username: "jdoe@example.com", Email: "jdoe@example.com", Groups: []string{"dev"}},
+		},
+		{
+			map[string]interface{}{"sub": "1234", "name": "Jane Doe"},
+			&Identity{Username: "1234", Name: "Jane Doe"},
+		},
+	}
+	for _, tt := range tests {
+		if got := identityFromClaims(&cfg, tt.claims); !reflect.DeepEqual(got, tt.expected) {
+			t.Errorf("identityFromClaims(%v) = %+v, want %+v", tt.claims, got, tt.expected)
+		}
+	}
+}
@@ -0,0 +1,258 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/coreos/go-oidc/v3/oidc"
+	"github.com/drewhammond/chefbrowser/config"
+	"github.com/labstack/echo/v4"
+	"go.uber.org/zap"
+	"golang.org/x/oauth2"
+)
+
+var ErrInvalidLogin = errors.New("login state does not match")
+
+// setupOIDC discovers the configured identity provider
+func (s *Service) setupOIDC() {
+	cfg := s.config.Auth
+	if cfg.IssuerURL == "" || cfg.ClientID == "" || cfg.RedirectURL == "" {
+		s.log.Fatal("auth provider oidc requires issuer_url, client_id and redirect_url to be set")
+	}
+
+	provider, err := oidc.NewProvider(context.Background(), cfg.IssuerURL)
+	if err != nil {
+		s.log.Fatal(fmt.Sprintf("failed to discover identity provider %s", cfg.IssuerURL), zap.Error(err))
+	}
+
+	var metadata struct {
+		EndSessionEndpoint string `json:"end_session_endpoint"`
+	}
+	_ = provider.Claims(&metadata)
+
+	secret := []byte(cfg.SessionSecret)
+	if len(secret) == 0 {
+		s.log.Warn("auth.session_secret is not set; using a random secret, users will have to log in again after a restart")
+		secret = make([]byte, 32)
+		_, _ = rand.Read(secret)
+	}
+
+	scopes := []string{oidc.ScopeOpenID}
+	for _, scope := range cfg.Scopes {
+		if scope = strings.TrimSpace(scope); scope != "" && scope != oidc.ScopeOpenID {
+			scopes = append(scopes, scope)
+		}
+	}
+
+	redirect, _ := url.Parse(cfg.RedirectURL)
+
+	s.provider = provider
+	s.verifier = provider.Verifier(&oidc.Config{ClientID: cfg.ClientID})
+	s.oauth = &oauth2.Config{
+		ClientID:     cfg.ClientID,
+		ClientSecret: cfg.ClientSecret,
+		Endpoint:     provider.Endpoint(),
+		RedirectURL:  cfg.RedirectURL,
+		Scopes:       scopes,
+	}
+	s.sessions = newCookieSigner(secret)
+	s.secure = redirect != nil && redirect.Scheme == "https"
+	s.endSessionURL = metadata.EndSessionEndpoint
+
+	s.log.Info("OpenID Connect authentication is enabled", zap.String("issuer", cfg.IssuerURL))
+}
+
+// login redirects the user to the identity provider using the authorization code flow with PKCE. The state, nonce
+// and code verifier are kept in a short-lived signed cookie until the identity provider redirects back.
+func (s *Service) login(c echo.Context) error {
+	login := loginState{
+		State:    randomString(),
+		Nonce:    randomString(),
+		Verifier: oauth2.GenerateVerifier(),
+		Next:     s.safeRedirect(c.QueryParam("next")),
+		Expires:  time.Now().Add(loginTTL).Unix(),
+	}
+	value, err := s.sessions.encode(login)
+	if err != nil {
+		return err
+	}
+	c.SetCookie(s.cookie(loginCookie, value, time.Unix(login.Expires, 0)))
+
+	return c.Redirect(http.StatusFound, s.oauth.AuthCodeURL(login.State,
+		oauth2.S256ChallengeOption(login.Verifier), oidc.Nonce(login.Nonce)))
+}
+
+// callback exchanges the authorization code for an ID token and starts a session for the user it identifies
+func (s *Service) callback(c echo.Context) error {
+	if e := c.QueryParam("error"); e != "" {
+		s.log.Warn("identity provider returned an error", zap.String("error", e),
+			zap.String("description", c.QueryParam("error_description")))
+		return c.String(http.StatusUnauthorized, "login failed: "+e)
+	}
+
+	login, err := s.readLogin(c)
+	if err != nil || c.QueryParam("state") != login.State {
+		s.log.Warn("rejected login callback", zap.Error(errors.Join(ErrInvalidLogin, err)))
+		return c.String(http.StatusBadRequest, "login expired or invalid, please try again")
+	}
+	c.SetCookie(s.cookie(loginCookie, "", time.Time{}))
+
+	ctx := c.Request().Context()
+	token, err := s.oauth.Exchange(ctx, c.QueryParam("code"), oauth2.VerifierOption(login.Verifier))
+	if err != nil {
+		s.log.Error("failed to exchange authorization code", zap.Error(err))
+		return c.String(http.StatusBadGateway, "failed to complete login with the identity provider")
+	}
+
+	rawIDToken, ok := token.Extra("id_token").(string)
+	if !ok {
+		s.log.Error("identity provider did not return an ID token")
+		return c.String(http.StatusBadGateway, "failed to complete login with the identity provider")
+	}
+	idToken, err := s.verifier.Verify(ctx, rawIDToken)
+	if err != nil || idToken.Nonce != login.Nonce {
+		s.log.Error("failed to verify ID token", zap.Error(errors.Join(ErrInvalidLogin, err)))
+		return c.String(http.StatusUnauthorized, "failed to verify ID token")
+	}
+
+	var claims map[string]interface{}
+	if err = idToken.Claims(&claims); err != nil {
+		return err
+	}
+	id := identityFromClaims(s.config, claims)
+	if id.Username == "" {
+		s.log.Error("ID token does not identify the user", zap.String("username_claim", s.config.Auth.UsernameClaim))
+		return c.String(http.StatusUnauthorized, "ID token does not identify the user")
+	}
+
+	if err = s.writeSession(c, id); err != nil {
+		return err
+	}
+	s.log.Info("user logged in", zap.String("user", id.Username), zap.Strings("groups", id.Groups))
+	return c.Redirect(http.StatusFound, login.Next)
+}
+
+// logout ends the session and, if it supports it, ends the session with the identity provider too
+func (s *Service) logout(c echo.Context) error {
+	c.SetCookie(s.cookie(sessionCookie, "", time.Time{}))
+
+	if s.endSessionURL != "" {
+		params := url.Values{"client_id": {s.config.Auth.ClientID}}
+		if s.config.Auth.PostLogoutRedirectURL != "" {
+			params.Set("post_logout_redirect_uri", s.config.Auth.PostLogoutRedirectURL)
+		}
+		return c.Redirect(http.StatusSeeOther, s.endSessionURL+"?"+params.Encode())
+	}
+	if s.config.Auth.PostLogoutRedirectURL != "" {
+		return c.Redirect(http.StatusSeeOther, s.config.Auth.PostLogoutRedirectURL)
+	}
+	return c.Render(http.StatusOK, "logged_out", echo.Map{
+		"title": "Logged out",
+	})
+}
+
+func (s *Service) readLogin(c echo.Context) (*loginState, error) {
+	cookie, err := c.Cookie(loginCookie)
+	if err != nil {
+		return nil, err
+	}
+
+	var login loginState
+	if err = s.sessions.decode(cookie.Value, &login); err != nil {
+		return nil, err
+	}
+	if time.Now().Unix() > login.Expires {
+		return nil, ErrExpiredCookie
+	}
+	return &login, nil
+}
+
+// safeRedirect only allows redirects within chefbrowser after logging in, falling back to the node list
+func (s *Service) safeRedirect(next string) string {
+	base := urlWithBasePath(s.config, "/")
+	if !strings.HasPrefix(next, base) || strings.HasPrefix(next, "//") || strings.Contains(next, "\\") {
+		return urlWithBasePath(s.config, "/ui/nodes")
+	}
+	return next
+}
+
+// identityFromClaims builds the identity of a user from the claims of their ID token. Groups are the values of the
+// groups claim along with every group whose mapping matches a claim.
+func identityFromClaims(config *config.Config, claims map[string]interface{}) *Identity {
+	cfg := config.Auth
+	id := &Identity{
+		Name:  claimString(claims["name"]),
+		Email: claimString(claims["email"]),
+	}
+	for _, claim := range []string{cfg.UsernameClaim, "email", "sub"} {
+		if id.Username = claimString(claims[claim]); id.Username != "" {
+			break
+		}
+	}
+
+	if cfg.GroupsClaim != "" {
+		id.Groups = append(id.Groups, claimStrings(claims[cfg.GroupsClaim])...)
+	}
+
+	keys := make([]int, 0, len(cfg.GroupMappings))
+	for key := range cfg.GroupMappings {
+		keys = append(keys, key)
+	}
+	sort.Ints(keys)
+	for _, key := range keys {
+		m := cfg.GroupMappings[key]
+		for _, value := range claimStrings(claims[m.Claim]) {
+			if slices.ContainsFunc(m.Values, func(v string) bool { return strings.TrimSpace(v) == value }) {
+				id.Groups = append(id.Groups, m.Group)
+				break
+			}
+		}
+	}
+
+	sort.Strings(id.Groups)
+	id.Groups = slices.Compact(id.Groups)
+	return id
+}
+
+// claimString returns a claim as a string, or an empty string if it is missing or not a single value
+func claimString(v interface{}) string {
+	switch v := v.(type) {
+	case string:
+		return v
+	case bool, float64:
+		return fmt.Sprint(v)
+	}
+	return ""
+}
+
+// claimStrings returns the values of a claim that may hold a single value or a list of values
+func claimStrings(v interface{}) []string {
+	if list, ok := v.([]interface{}); ok {
+		values := make([]string, 0, len(list))
+		for _, item := range list {
+			if s := claimString(item); s != "" {
+				values = append(values, s)
+			}
+		}
+		return values
+	}
+	if s := claimString(v); s != "" {
+		return []string{s}
+	}
+	return nil
+}
+
+func randomString() string {
+	b := make([]byte, 32)
+	_, _ = rand.Read(b)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
@@ -0,0 +1,75 @@
+package auth
+
+import (
+	"errors"
+	"net"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+	"go.uber.org/zap"
+)
+
+var (
+	ErrUntrustedProxy  = errors.New("request did not come from a trusted proxy")
+	ErrMissingIdentity = errors.New("trusted proxy did not send a user")
+)
+
+// identityFromHeaders returns the user a trusted reverse proxy sent in the request headers. The headers are only
+// trusted if the request itself (not any X-Forwarded-For address) comes from one of server.trusted_proxies, since
+// anyone else could send them too.
+//
+// Group mappings apply to the username, email and groups the proxy sends, as if they were claims of those names.
+func (s *Service) identityFromHeaders(c echo.Context) (*Identity, error) {
+	if !s.trustedPeer(c.Request().RemoteAddr) {
+		if c.Request().Header.Get(s.config.Auth.UserHeader) != "" {
+			s.log.Warn("ignoring identity headers from untrusted address",
+				zap.String("remote_addr", c.Request().RemoteAddr))
+		}
+		return nil, ErrUntrustedProxy
+	}
+
+	header := c.Request().Header
+	username := strings.TrimSpace(header.Get(s.config.Auth.UserHeader))
+	if username == "" {
+		return nil, ErrMissingIdentity
+	}
+
+	claims := map[string]interface{}{"username": username}
+	if email := strings.TrimSpace(header.Get(s.config.Auth.EmailHeader)); s.config.Auth.EmailHeader != "" && email != "" {
+		claims["email"] = email
+	}
+	if s.config.Auth.GroupsHeader != "" {
+		var groups []interface{}
+		for _, value := range header.Values(s.config.Auth.GroupsHeader) {
+			for _, group := range strings.Split(value, ",") {
+				if group = strings.TrimSpace(group); group != "" {
+					groups = append(groups, group)
+				}
+			}
+		}
+		claims["groups"] = groups
+	}
+
+	cfg := *s.config
+	cfg.Auth.UsernameClaim = "username"
+	cfg.Auth.GroupsClaim = "groups"
+	return identityFromClaims(&cfg, claims), nil
+}
+
+// trustedPeer returns true if the address a request came from is within one of the trusted proxy networks
+func (s *Service) trustedPeer(remoteAddr string) bool {
+	host, _, err := net.SplitHostPort(remoteAddr)
+	if err != nil {
+		host = remoteAddr
+	}
+	ip := net.ParseIP(host)
+	if ip == nil {
+		return false
+	}
+	for _, network := range s.trustedProxies {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
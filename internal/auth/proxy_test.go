@@ -0,0 +1,104 @@
+package auth
+
+import (
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/drewhammond/chefbrowser/config"
+	"github.com/drewhammond/chefbrowser/internal/common/logging"
+	"github.com/labstack/echo/v4"
+	"go.uber.org/zap"
+)
+
+func TestProxyAuthentication(t *testing.T) {
+	cfg := &config.Config{}
+	cfg.Auth.Enabled = true
+	cfg.Auth.Provider = ProviderProxy
+	cfg.Auth.UserHeader = "X-Forwarded-User"
+	cfg.Auth.GroupsHeader = "X-Forwarded-Groups"
+	cfg.Auth.EmailHeader = "X-Forwarded-Email"
+
+	_, trusted, _ := net.ParseCIDR("10.0.0.0/8")
+	engine := echo.New()
+	New(cfg, engine, &logging.Logger{Logger: zap.NewNop()}, []*net.IPNet{trusted}).RegisterRoutes()
+	registerTestRoutes(engine)
+
+	tests := []struct {
+		name       string
+		target     string
+		remoteAddr string
+		headers    map[string]string
+		status     int
+		expected   *Identity
+	}{
+		{
+			name:       "trusted proxy",
+			target:     "/api/nodes",
+			remoteAddr: "10.1.2.3:40000",
+			headers: map[string]string{
+				"X-Forwarded-User":   "jdoe",
+				"X-Forwarded-Email":  "jdoe@example.com",
+				"X-Forwarded-Groups": "sre, dev,,sre",
+			},
+			status:   http.StatusOK,
+			expected: &Identity{Username: "jdoe", Email: "jdoe@example.com", Groups: []string{"dev", "sre"}},
+		},
+		{
+			name:       "trusted proxy without groups",
+			target:     "/orgs/prod/api/nodes",
+			remoteAddr: "10.1.2.3:40000",
+			headers:    map[string]string{"X-Forwarded-User": "jdoe"},
+			status:     http.StatusOK,
+			expected:   &Identity{Username: "jdoe"},
+		},
+		{
+			name:       "untrusted address",
+			target:     "/api/nodes",
+			remoteAddr: "192.0.2.1:40000",
+			headers:    map[string]string{"X-Forwarded-User": "admin", "X-Forwarded-For": "10.1.2.3"},
+			status:     http.StatusUnauthorized,
+		},
+		{
+			name:       "missing user",
+			target:     "/api/nodes",
+			remoteAddr: "10.1.2.3:40000",
+			headers:    map[string]string{"X-Forwarded-Groups": "admins"},
+			status:     http.StatusUnauthorized,
+		},
+		{
+			name:       "health check is public",
+			target:     "/api/health",
+			remoteAddr: "192.0.2.1:40000",
+			status:     http.StatusOK,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tt.target, nil)
+			req.RemoteAddr = tt.remoteAddr
+			for k, v := range tt.headers {
+				req.Header.Set(k, v)
+			}
+			rec := httptest.NewRecorder()
+			engine.ServeHTTP(rec, req)
+
+			if rec.Code != tt.status {
+				t.Fatalf("expected status %d, actual: %d", tt.status, rec.Code)
+			}
+			if tt.expected == nil {
+				return
+			}
+			var id Identity
+			if err := json.Unmarshal(rec.Body.Bytes(), &id); err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(&id, tt.expected) {
+				t.Errorf("expected identity %+v, actual: %+v", tt.expected, &id)
+			}
+		})
+	}
+}
@@ -0,0 +1,241 @@
+package chef
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"slices"
+	"sort"
+
+	"golang.org/x/sync/errgroup"
+)
+
+// maxConcurrentACLs limits the number of ACLs fetched at once when building the ACL report
+const maxConcurrentACLs = 8
+
+var ErrInvalidACLType = errors.New("objects of this type do not have ACLs")
+
+// Object types with ACLs. Each type is also the name of the container that holds its objects, from which new
+// objects get their default ACL.
+const (
+	ACLTypeNode        = "nodes"
+	ACLTypeRole        = "roles"
+	ACLTypeEnvironment = "environments"
+	ACLTypeDataBag     = "data"
+	ACLTypeClient      = "clients"
+	ACLTypeGroup       = "groups"
+	ACLTypeCookbook    = "cookbooks"
+	ACLTypePolicy      = "policies"
+)
+
+// ACLTypes lists every object type with ACLs
+var ACLTypes = []string{ACLTypeNode, ACLTypeRole, ACLTypeEnvironment, ACLTypeDataBag, ACLTypeClient, ACLTypeGroup,
+	ACLTypeCookbook, ACLTypePolicy}
+
+// ACL permissions in the order the chef server documents them
+var ACLPermissions = []string{"create", "read", "update", "delete", "grant"}
+
+// ACE lists who is granted a single permission. Newer chef servers also list the actors split into users and
+// clients.
+type ACE struct {
+	Actors  []string `json:"actors"`
+	Groups  []string `json:"groups"`
+	Users   []string `json:"users,omitempty"`
+	Clients []string `json:"clients,omitempty"`
+}
+
+// ACL holds the access control entry of every permission of an object, keyed by permission
+type ACL map[string]ACE
+
+// ACLPermission is a single permission of an ACL, see ACL.Permissions
+type ACLPermission struct {
+	Name string `json:"name"`
+	ACE
+}
+
+// Permissions returns the entry of every permission in the order of ACLPermissions
+func (a ACL) Permissions() []ACLPermission {
+	permissions := make([]ACLPermission, 0, len(ACLPermissions))
+	for _, p := range ACLPermissions {
+		permissions = append(permissions, ACLPermission{Name: p, ACE: a[p]})
+	}
+	return permissions
+}
+
+// ACLDiff describes how a single permission of an object differs from its container. Added actors and groups are
+// granted the permission on the object but not on the container.
+type ACLDiff struct {
+	Permission    string   `json:"permission"`
+	AddedActors   []string `json:"added_actors,omitempty"`
+	RemovedActors []string `json:"removed_actors,omitempty"`
+	AddedGroups   []string `json:"added_groups,omitempty"`
+	RemovedGroups []string `json:"removed_groups,omitempty"`
+}
+
+// ACLReportItem is an object whose ACL differs from the default ACL of its container
+type ACLReportItem struct {
+	Name  string    `json:"name"`
+	Diffs []ACLDiff `json:"diffs"`
+}
+
+// ACLReport lists the objects of a single type whose ACL differs from their container
+type ACLReport struct {
+	Type      string          `json:"type"`
+	Container ACL             `json:"container"`
+	Total     int             `json:"total"`
+	Objects   []ACLReportItem `json:"objects"`
+	// Unreadable lists the objects whose ACL could not be read with the configured credentials
+	Unreadable []string `json:"unreadable,omitempty"`
+}
+
+func validateACLType(objectType string) error {
+	if !slices.Contains(ACLTypes, objectType) {
+		return fmt.Errorf("%w: %q", ErrInvalidACLType, objectType)
+	}
+	return nil
+}
+
+// GetACL returns the ACL of a single object. objectType is one of ACLTypes.
+func (s Service) GetACL(ctx context.Context, objectType string, name string) (ACL, error) {
+	if err := validateACLType(objectType); err != nil {
+		return nil, err
+	}
+	if objectType == ACLTypeDataBag && !s.canView(ctx, ObjectTypeDataBags, name) {
+		return nil, ErrDatabagDenied
+	}
+	return s.fetchACL(ctx, objectType+"/"+url.PathEscape(name)+"/_acl")
+}
+
+// GetContainerACL returns the ACL of the container holding every object of a type, which new objects inherit
+func (s Service) GetContainerACL(ctx context.Context, objectType string) (ACL, error) {
+	if err := validateACLType(objectType); err != nil {
+		return nil, err
+	}
+	return s.fetchACL(ctx, "containers/"+objectType+"/_acl")
+}
+
+func (s Service) fetchACL(ctx context.Context, path string) (ACL, error) {
+	return cached(ctx, s.cache, "acl:"+path, s.config.Cache.ACLTTL, func(ctx context.Context) (ACL, error) {
+		var acl ACL
+		if err := s.get(ctx, path, &acl); err != nil {
+			return nil, err
+		}
+		return acl, nil
+	})
+}
+
+// GetACLReport compares the ACL of every object of a type with the ACL of its container. The chef server adds the
+// creator of an object to all of its permissions, so most objects have extra actors; set includeActors to report
+// those as well, otherwise only group differences are reported.
+func (s Service) GetACLReport(ctx context.Context, objectType string, includeActors bool) (*ACLReport, error) {
+	if err := validateACLType(objectType); err != nil {
+		return nil, err
+	}
+
+	var container ACL
+	var names []string
+
+	g, gctx := errgroup.WithContext(ctx)
+	g.Go(func() (err error) {
+		container, err = s.GetContainerACL(gctx, objectType)
+		return err
+	})
+	g.Go(func() (err error) {
+		names, err = s.listObjectNames(gctx, objectType)
+		if objectType == ACLTypeDataBag {
+			names = slices.DeleteFunc(names, func(name string) bool {
+				return !s.canView(ctx, ObjectTypeDataBags, name)
+			})
+		}
+		return err
+	})
+	if err := g.Wait(); err != nil {
+		return nil, err
+	}
+
+	acls := make([]ACL, len(names))
+	readable := make([]bool, len(names))
+	g, gctx = errgroup.WithContext(ctx)
+	g.SetLimit(maxConcurrentACLs)
+	for i, name := range names {
+		g.Go(func() error {
+			acl, err := s.GetACL(gctx, objectType, name)
+			if err != nil {
+				// objects can deny us access to their ACL, which shouldn't hide the rest of the report
+				if errors.Is(err, ErrForbidden) || errors.Is(err, ErrNotFound) {
+					return nil
+				}
+				return err
+			}
+			acls[i], readable[i] = acl, true
+			return nil
+		})
+	}
+	if err := g.Wait(); err != nil {
+		return nil, err
+	}
+
+	report := &ACLReport{Type: objectType, Container: container, Total: len(names), Objects: []ACLReportItem{}}
+	for i, name := range names {
+		if !readable[i] {
+			report.Unreadable = append(report.Unreadable, name)
+			continue
+		}
+		if diffs := DiffACL(container, acls[i], includeActors); len(diffs) > 0 {
+			report.Objects = append(report.Objects, ACLReportItem{Name: name, Diffs: diffs})
+		}
+	}
+
+	return report, nil
+}
+
+// listObjectNames returns the names of every object of a type, sorted. Every object list endpoint returns a JSON
+// object keyed by object name.
+func (s Service) listObjectNames(ctx context.Context, objectType string) ([]string, error) {
+	var objects map[string]json.RawMessage
+	if err := s.get(ctx, objectType, &objects); err != nil {
+		return nil, err
+	}
+
+	names := make([]string, 0, len(objects))
+	for name := range objects {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// DiffACL returns the permissions of acl that differ from base. Actors are only compared if includeActors is true.
+func DiffACL(base ACL, acl ACL, includeActors bool) []ACLDiff {
+	var diffs []ACLDiff
+	for _, p := range ACLPermissions {
+		d := ACLDiff{Permission: p}
+		d.AddedGroups, d.RemovedGroups = diffMembers(base[p].Groups, acl[p].Groups)
+		if includeActors {
+			d.AddedActors, d.RemovedActors = diffMembers(base[p].Actors, acl[p].Actors)
+		}
+		if len(d.AddedGroups)+len(d.RemovedGroups)+len(d.AddedActors)+len(d.RemovedActors) > 0 {
+			diffs = append(diffs, d)
+		}
+	}
+	return diffs
+}
+
+// diffMembers returns the sorted members of b that are not in a, and of a that are not in b
+func diffMembers(a []string, b []string) (added []string, removed []string) {
+	for _, m := range b {
+		if !slices.Contains(a, m) {
+			added = append(added, m)
+		}
+	}
+	for _, m := range a {
+		if !slices.Contains(b, m) {
+			removed = append(removed, m)
+		}
+	}
+	sort.Strings(added)
+	sort.Strings(removed)
+	return added, removed
+}
@@ -0,0 +1,110 @@
+package chef
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/drewhammond/chefbrowser/config"
+)
+
+func TestDiffACL(t *testing.T) {
+	base := ACL{
+		"read":   {Actors: []string{"pivotal"}, Groups: []string{"admins", "users", "clients"}},
+		"update": {Actors: []string{"pivotal"}, Groups: []string{"admins", "users"}},
+	}
+	acl := ACL{
+		"read":   {Actors: []string{"pivotal", "web-1"}, Groups: []string{"admins", "users", "clients"}},
+		"update": {Actors: []string{"pivotal", "web-1"}, Groups: []string{"admins", "ops"}},
+	}
+
+	tests := []struct {
+		name          string
+		includeActors bool
+		expected      []ACLDiff
+	}{
+		{
+			name:     "groups only",
+			expected: []ACLDiff{{Permission: "update", AddedGroups: []string{"ops"}, RemovedGroups: []string{"users"}}},
+		},
+		{
+			name:          "with actors",
+			includeActors: true,
+			expected: []ACLDiff{
+				{Permission: "read", AddedActors: []string{"web-1"}},
+				{Permission: "update", AddedActors: []string{"web-1"}, AddedGroups: []string{"ops"}, RemovedGroups: []string{"users"}},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := DiffACL(base, acl, tt.includeActors); !reflect.DeepEqual(got, tt.expected) {
+				t.Errorf("DiffACL() = %+v, want %+v", got, tt.expected)
+			}
+		})
+	}
+
+	if got := DiffACL(base, base, true); got != nil {
+		t.Errorf("expected no differences, actual: %+v", got)
+	}
+}
+
+func TestACLPermissions(t *testing.T) {
+	acl := ACL{"grant": {Groups: []string{"admins"}}}
+	got := acl.Permissions()
+	if len(got) != 5 || got[0].Name != "create" || got[4].Name != "grant" || got[4].Groups[0] != "admins" {
+		t.Errorf("unexpected permissions: %+v", got)
+	}
+}
+
+func TestGetACLReport(t *testing.T) {
+	responses := map[string]string{
+		"/containers/roles/_acl": `{"read": {"actors": ["pivotal"], "groups": ["admins", "users"]}}`,
+		"/roles":                 `{"web": "/roles/web", "db": "/roles/db", "secret": "/roles/secret"}`,
+		"/roles/web/_acl":        `{"read": {"actors": ["pivotal", "alice"], "groups": ["admins", "users"]}}`,
+		"/roles/db/_acl":         `{"read": {"actors": ["pivotal"], "groups": ["admins"]}}`,
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/roles/secret/_acl" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		body, ok := responses[r.URL.Path]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(body))
+	}))
+	defer srv.Close()
+
+	s := newTestService(t, srv.URL, &config.Config{})
+	got, err := s.GetACLReport(context.Background(), ACLTypeRole, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []ACLReportItem{{Name: "db", Diffs: []ACLDiff{{Permission: "read", RemovedGroups: []string{"users"}}}}}
+	if !reflect.DeepEqual(got.Objects, expected) {
+		t.Errorf("objects = %+v, want %+v", got.Objects, expected)
+	}
+	if got.Total != 3 || !reflect.DeepEqual(got.Unreadable, []string{"secret"}) {
+		t.Errorf("unexpected report: %+v", got)
+	}
+
+	withActors, err := s.GetACLReport(context.Background(), ACLTypeRole, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(withActors.Objects) != 2 || withActors.Objects[1].Name != "web" {
+		t.Errorf("expected web to differ by actor, actual: %+v", withActors.Objects)
+	}
+
+	if _, err := s.GetACLReport(context.Background(), "cookies", false); !errors.Is(err, ErrInvalidACLType) {
+		t.Errorf("expected ErrInvalidACLType, actual: %v", err)
+	}
+}
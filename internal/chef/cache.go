@@ -0,0 +1,121 @@
+package chef
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"golang.org/x/sync/singleflight"
+)
+
+// CacheStats describes the current state of the chef object cache
+type CacheStats struct {
+	Enabled bool   `json:"enabled"`
+	Entries int    `json:"entries"`
+	Hits    uint64 `json:"hits"`
+	Misses  uint64 `json:"misses"`
+}
+
+type cacheEntry struct {
+	value   interface{}
+	expires time.Time
+}
+
+// cache is a simple in-memory TTL cache. Concurrent lookups for the same key are
+// collapsed into a single request to the chef server to avoid cache stampedes.
+type cache struct {
+	enabled bool
+	mu      sync.RWMutex
+	entries map[string]cacheEntry
+	group   singleflight.Group
+	hits    atomic.Uint64
+	misses  atomic.Uint64
+	now     func() time.Time
+}
+
+func newCache(enabled bool) *cache {
+	return &cache{
+		enabled: enabled,
+		entries: make(map[string]cacheEntry),
+		now:     time.Now,
+	}
+}
+
+func (c *cache) get(key string) (interface{}, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	e, ok := c.entries[key]
+	if !ok || c.now().After(e.expires) {
+		return nil, false
+	}
+	return e.value, true
+}
+
+func (c *cache) set(key string, value interface{}, ttl time.Duration) {
+	if !c.enabled || ttl <= 0 {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries[key] = cacheEntry{value: value, expires: c.now().Add(ttl)}
+}
+
+// purge removes all expired entries
+func (c *cache) purge() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	now := c.now()
+	for k, e := range c.entries {
+		if now.After(e.expires) {
+			delete(c.entries, k)
+		}
+	}
+}
+
+func (c *cache) stats() CacheStats {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return CacheStats{
+		Enabled: c.enabled,
+		Entries: len(c.entries),
+		Hits:    c.hits.Load(),
+		Misses:  c.misses.Load(),
+	}
+}
+
+// cached returns the cached value for key or calls fetch to populate it. Errors are never cached.
+//
+// A fetch is shared by every caller waiting on the same key, so it must not be cancelled when the caller that
+// started it goes away. Callers still return as soon as their own ctx is done, and the fetch itself remains
+// bounded by the chef server request timeout.
+func cached[T any](ctx context.Context, c *cache, key string, ttl time.Duration, fetch func(context.Context) (T, error)) (T, error) {
+	if c == nil || !c.enabled || ttl <= 0 {
+		return fetch(ctx)
+	}
+
+	if v, ok := c.get(key); ok {
+		c.hits.Add(1)
+		t, _ := v.(T)
+		return t, nil
+	}
+
+	c.misses.Add(1)
+	ch := c.group.DoChan(key, func() (interface{}, error) {
+		v, err := fetch(context.WithoutCancel(ctx))
+		if err != nil {
+			return v, err
+		}
+		c.set(key, v, ttl)
+		return v, nil
+	})
+
+	select {
+	case <-ctx.Done():
+		var zero T
+		return zero, ctx.Err()
+	case r := <-ch:
+		t, _ := r.Val.(T)
+		return t, r.Err
+	}
+}
@@ -0,0 +1,124 @@
+package chef
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestCached(t *testing.T) {
+	ctx := context.Background()
+	c := newCache(true)
+	now := time.Now()
+	c.now = func() time.Time { return now }
+
+	var calls int
+	fetch := func(context.Context) (string, error) {
+		calls++
+		return "value", nil
+	}
+
+	for i := 0; i < 3; i++ {
+		v, err := cached(ctx, c, "key", time.Minute, fetch)
+		if err != nil || v != "value" {
+			t.Fatalf("unexpected result, value: %v, err: %v", v, err)
+		}
+	}
+	if calls != 1 {
+		t.Errorf("expected 1 fetch, actual: %d", calls)
+	}
+
+	now = now.Add(2 * time.Minute)
+	_, _ = cached(ctx, c, "key", time.Minute, fetch)
+	if calls != 2 {
+		t.Errorf("expected expired entry to be fetched again, fetches: %d", calls)
+	}
+
+	stats := c.stats()
+	if stats.Hits != 2 || stats.Misses != 2 || stats.Entries != 1 {
+		t.Errorf("unexpected stats: %+v", stats)
+	}
+}
+
+func TestCachedErrorsAreNotCached(t *testing.T) {
+	ctx := context.Background()
+	c := newCache(true)
+	errFetch := errors.New("boom")
+
+	_, err := cached(ctx, c, "key", time.Minute, func(context.Context) (*Node, error) { return nil, errFetch })
+	if !errors.Is(err, errFetch) {
+		t.Fatalf("expected fetch error, actual: %v", err)
+	}
+
+	v, err := cached(ctx, c, "key", time.Minute, func(context.Context) (*Node, error) { return &Node{}, nil })
+	if err != nil || v == nil {
+		t.Errorf("expected value after failed fetch, value: %v, err: %v", v, err)
+	}
+}
+
+func TestCachedDisabled(t *testing.T) {
+	ctx := context.Background()
+	c := newCache(false)
+
+	var calls int
+	for i := 0; i < 3; i++ {
+		_, _ = cached(ctx, c, "key", time.Minute, func(context.Context) (int, error) {
+			calls++
+			return calls, nil
+		})
+	}
+	if calls != 3 {
+		t.Errorf("expected every call to fetch when cache is disabled, fetches: %d", calls)
+	}
+}
+
+func TestCachedSingleflight(t *testing.T) {
+	ctx := context.Background()
+	c := newCache(true)
+
+	var calls atomic.Int32
+	release := make(chan struct{})
+	fetch := func(context.Context) (int, error) {
+		calls.Add(1)
+		<-release
+		return 1, nil
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, _ = cached(ctx, c, "key", time.Minute, fetch)
+		}()
+	}
+
+	// give the goroutines a chance to pile up behind the first fetch
+	time.Sleep(50 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	if n := calls.Load(); n != 1 {
+		t.Errorf("expected concurrent lookups to share one fetch, fetches: %d", n)
+	}
+}
+
+func TestCachedCallerCancellation(t *testing.T) {
+	c := newCache(true)
+	release := make(chan struct{})
+	defer close(release)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err := cached(ctx, c, "key", time.Minute, func(context.Context) (int, error) {
+		<-release
+		return 1, nil
+	})
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("expected cancelled caller to return immediately, actual: %v", err)
+	}
+}
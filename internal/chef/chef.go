@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"os"
 	"strings"
+	"time"
 
 	"github.com/drewhammond/chefbrowser/config"
 	"github.com/drewhammond/chefbrowser/internal/common/logging"
@@ -19,25 +20,53 @@ type Interface interface {
 
 type Service struct {
 	Interface
+	name   string
 	log    *logging.Logger
 	config *config.Config
 	client chef.Client
+	cache  *cache
+	health *healthState
+	policy *Policy
+	// secrets decrypt encrypted data bag items
+	secrets []DatabagSecret
 }
 
 func (s Service) GetClient() *chef.Client {
 	return &s.client
 }
 
-func New(config *config.Config, logger *logging.Logger) *Service {
+// Organization returns the name of the organization the service browses
+func (s Service) Organization() string {
+	return s.name
+}
+
+// New connects to a single chef organization. A failing connection is not fatal so that other organizations remain
+// available; it is reported by the health checks instead.
+func New(name string, config *config.Config, logger *logging.Logger) *Service {
 	config.Chef.ServerURL = normalizeChefURL(config.Chef.ServerURL)
 	logger.Info(fmt.Sprintf("initializing chef server connection (url: %s, username: %s)",
 		config.Chef.ServerURL,
 		config.Chef.Username))
 
 	s := &Service{
+		name:   name,
 		config: config,
 		log:    logger,
+		cache:  newCache(config.Cache.Enabled),
+		health: &healthState{},
+	}
+
+	policy, err := policyFromConfig(config)
+	if err != nil {
+		logger.Fatal("invalid authorization policy", zap.Error(err))
 	}
+	s.policy = policy
+
+	secrets, err := databagSecretsFromConfig(config)
+	if err != nil {
+		logger.Fatal("invalid encrypted data bag configuration", zap.Error(err))
+	}
+	s.secrets = secrets
 
 	key, err := os.ReadFile(config.Chef.KeyFile)
 	if err != nil {
@@ -64,17 +93,67 @@ func New(config *config.Config, logger *logging.Logger) *Service {
 		logger.Fatal("failed to set up chef client", zap.Error(err))
 	}
 
-	// verify connection (we could use the global _status endpoint, but then it's not checking permissions)
-	_, err = client.Nodes.List()
-	if err != nil {
-		logger.Fatal("failed to verify chef server connection", zap.Error(err))
+	s.client = *client
+
+	if h := s.CheckHealth(context.Background()); !h.Healthy {
+		logger.Error("failed to verify chef server connection", zap.String("error", h.Error))
+	}
+	if config.Chef.HealthCheckInterval > 0 {
+		go s.healthLoop(config.Chef.HealthCheckInterval)
 	}
 
-	s.client = *client
+	if config.Cache.Enabled {
+		logger.Info("chef object cache is enabled", zap.Duration("refresh_interval", config.Cache.RefreshInterval))
+		if config.Cache.RefreshInterval > 0 {
+			go s.refreshLoop(config.Cache.RefreshInterval)
+		}
+	} else {
+		logger.Warn("chef object cache is disabled; every request will query the chef server directly")
+	}
 
 	return s
 }
 
+// CacheStats returns the hit/miss counters of the chef object cache
+func (s Service) CacheStats() CacheStats {
+	return s.cache.stats()
+}
+
+// refreshLoop periodically refreshes the most frequently requested lists (nodes, the first page of the node list,
+// roles and the cookbook universe) so that requests for them are served from the cache instead of waiting on the chef
+// server.
+func (s Service) refreshLoop(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		ctx := context.Background()
+		if nodes, err := s.listNodes(ctx); err != nil {
+			s.log.Warn("failed to refresh node list", zap.Error(err))
+		} else {
+			s.cache.set("nodes", nodes, s.config.Cache.NodeTTL)
+		}
+		if err := s.refreshNodeList(ctx); err != nil {
+			s.log.Warn("failed to refresh first page of node list", zap.Error(err))
+		}
+
+		if roles, err := s.listRoles(ctx); err != nil {
+			s.log.Warn("failed to refresh role list", zap.Error(err))
+		} else {
+			s.cache.set("roles", roles, s.config.Cache.RoleTTL)
+		}
+
+		if universe, err := s.fetchUniverse(ctx); err != nil {
+			s.log.Warn("failed to refresh cookbook universe", zap.Error(err))
+		} else {
+			s.cache.set("universe", universe, s.config.Cache.CookbookTTL)
+			s.cache.set("cookbooks", buildCookbookList(universe), s.config.Cache.CookbookTTL)
+		}
+
+		s.cache.purge()
+	}
+}
+
 // normalizeChefURL simply adds a trailing slash to URLs to reduce confusion for users
 // go-chef requires it organizations are in use.
 func normalizeChefURL(url string) string {
@@ -0,0 +1,283 @@
+package chef
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"slices"
+	"sort"
+
+	"github.com/go-chef/chef"
+	"golang.org/x/sync/errgroup"
+)
+
+var (
+	ErrClientNotFound = fmt.Errorf("client %w", ErrNotFound)
+	ErrUserNotFound   = fmt.Errorf("user %w", ErrNotFound)
+)
+
+// AdminsGroup is the group whose members administer the organization
+const AdminsGroup = "admins"
+
+// ClientListItem is a single API client in the client list
+type ClientListItem struct {
+	Name      string `json:"name"`
+	Validator bool   `json:"validator"`
+	Admin     bool   `json:"admin"`
+}
+
+type ClientList struct {
+	Clients []ClientListItem `json:"clients"`
+}
+
+// APIClient is an API client along with its public keys
+type APIClient struct {
+	Name      string      `json:"name"`
+	Validator bool        `json:"validator"`
+	Admin     bool        `json:"admin"`
+	Keys      []PublicKey `json:"keys"`
+}
+
+// UserListItem is a single member of the organization in the user list
+type UserListItem struct {
+	Name  string `json:"name"`
+	Admin bool   `json:"admin"`
+}
+
+type UserList struct {
+	Users []UserListItem `json:"users"`
+}
+
+// User is a member of the organization along with its public keys
+type User struct {
+	Name        string      `json:"name"`
+	DisplayName string      `json:"display_name,omitempty"`
+	FirstName   string      `json:"first_name,omitempty"`
+	LastName    string      `json:"last_name,omitempty"`
+	Email       string      `json:"email,omitempty"`
+	Admin       bool        `json:"admin"`
+	Keys        []PublicKey `json:"keys"`
+}
+
+// GetAPIClients returns every API client in the organization sorted by name. Validators are found using the client
+// search index since the client list doesn't include them.
+func (s Service) GetAPIClients(ctx context.Context) (*ClientList, error) {
+	return cached(ctx, s.cache, "clients", s.config.Cache.ClientTTL, s.listClients)
+}
+
+func (s Service) listClients(ctx context.Context) (*ClientList, error) {
+	var clients chef.ApiClientListResult
+	validators := map[string]bool{}
+	var admins []string
+
+	g, gctx := errgroup.WithContext(ctx)
+	g.Go(func() error {
+		return s.get(gctx, "clients", &clients)
+	})
+	g.Go(func() error {
+		rows, err := s.partialSearch(gctx, IndexClient, "validator:true", map[string]interface{}{
+			"name": []string{"name"},
+		})
+		if err != nil {
+			return err
+		}
+		for _, row := range rows {
+			var c struct {
+				Name string `json:"name"`
+			}
+			_ = json.Unmarshal(row, &c)
+			validators[c.Name] = true
+		}
+		return nil
+	})
+	g.Go(func() (err error) {
+		admins, err = s.adminMembers(gctx)
+		return err
+	})
+	if err := g.Wait(); err != nil {
+		return nil, err
+	}
+
+	list := &ClientList{Clients: []ClientListItem{}}
+	for name := range clients {
+		list.Clients = append(list.Clients, ClientListItem{
+			Name:      name,
+			Validator: validators[name],
+			Admin:     slices.Contains(admins, name),
+		})
+	}
+	sort.Slice(list.Clients, func(i, j int) bool {
+		return list.Clients[i].Name < list.Clients[j].Name
+	})
+
+	return list, nil
+}
+
+// GetAPIClient returns a single API client along with the fingerprints of its public keys
+func (s Service) GetAPIClient(ctx context.Context, name string) (*APIClient, error) {
+	return cached(ctx, s.cache, "client:"+name, s.config.Cache.ClientTTL, func(ctx context.Context) (*APIClient, error) {
+		var client chef.ApiClient
+		var keys []PublicKey
+		var admins []string
+
+		g, gctx := errgroup.WithContext(ctx)
+		g.Go(func() error {
+			return s.get(gctx, "clients/"+url.PathEscape(name), &client)
+		})
+		g.Go(func() (err error) {
+			keys, err = s.clientKeys(gctx, name)
+			return err
+		})
+		g.Go(func() (err error) {
+			admins, err = s.adminMembers(gctx)
+			return err
+		})
+		if err := g.Wait(); err != nil {
+			if errors.Is(err, ErrNotFound) {
+				return nil, ErrClientNotFound
+			}
+			return nil, err
+		}
+
+		return &APIClient{
+			Name:      name,
+			Validator: client.Validator,
+			Admin:     slices.Contains(admins, name),
+			Keys:      keys,
+		}, nil
+	})
+}
+
+// clientKeys returns every public key of a client. Servers without the keys endpoint (such as goiardi) have no keys
+// to show.
+func (s Service) clientKeys(ctx context.Context, name string) ([]PublicKey, error) {
+	var items []chef.KeyItem
+	if err := s.get(ctx, "clients/"+url.PathEscape(name)+"/keys", &items); err != nil {
+		if errors.Is(err, ErrNotFound) {
+			return []PublicKey{}, nil
+		}
+		return nil, err
+	}
+
+	keys := make([]PublicKey, len(items))
+	g, gctx := errgroup.WithContext(ctx)
+	for i, item := range items {
+		g.Go(func() error {
+			var key chef.AccessKey
+			if err := s.get(gctx, "clients/"+url.PathEscape(name)+"/keys/"+url.PathEscape(item.Name), &key); err != nil {
+				return err
+			}
+			keys[i] = PublicKey{
+				Name:           item.Name,
+				PublicKey:      key.PublicKey,
+				ExpirationDate: key.ExpirationDate,
+				Expired:        item.Expired,
+			}.withFingerprint()
+			return nil
+		})
+	}
+	if err := g.Wait(); err != nil {
+		return nil, err
+	}
+
+	return keys, nil
+}
+
+// GetUsers returns every member of the organization sorted by name
+func (s Service) GetUsers(ctx context.Context) (*UserList, error) {
+	return cached(ctx, s.cache, "users", s.config.Cache.UserTTL, s.listUsers)
+}
+
+func (s Service) listUsers(ctx context.Context) (*UserList, error) {
+	var users []struct {
+		User struct {
+			Username string `json:"username"`
+		} `json:"user"`
+	}
+	var admins []string
+
+	g, gctx := errgroup.WithContext(ctx)
+	g.Go(func() error {
+		return s.get(gctx, "users", &users)
+	})
+	g.Go(func() (err error) {
+		admins, err = s.adminMembers(gctx)
+		return err
+	})
+	if err := g.Wait(); err != nil {
+		return nil, err
+	}
+
+	list := &UserList{Users: []UserListItem{}}
+	for _, u := range users {
+		list.Users = append(list.Users, UserListItem{
+			Name:  u.User.Username,
+			Admin: slices.Contains(admins, u.User.Username),
+		})
+	}
+	sort.Slice(list.Users, func(i, j int) bool {
+		return list.Users[i].Name < list.Users[j].Name
+	})
+
+	return list, nil
+}
+
+// GetUser returns a single member of the organization. The organization users endpoint only returns the default
+// public key of a user; any additional keys require server admin permissions and are not shown.
+func (s Service) GetUser(ctx context.Context, name string) (*User, error) {
+	return cached(ctx, s.cache, "user:"+name, s.config.Cache.UserTTL, func(ctx context.Context) (*User, error) {
+		var user struct {
+			Username    string `json:"username"`
+			DisplayName string `json:"display_name"`
+			FirstName   string `json:"first_name"`
+			LastName    string `json:"last_name"`
+			Email       string `json:"email"`
+			PublicKey   string `json:"public_key"`
+		}
+		var admins []string
+
+		g, gctx := errgroup.WithContext(ctx)
+		g.Go(func() error {
+			return s.get(gctx, "users/"+url.PathEscape(name), &user)
+		})
+		g.Go(func() (err error) {
+			admins, err = s.adminMembers(gctx)
+			return err
+		})
+		if err := g.Wait(); err != nil {
+			if errors.Is(err, ErrNotFound) {
+				return nil, ErrUserNotFound
+			}
+			return nil, err
+		}
+
+		u := &User{
+			Name:        name,
+			DisplayName: user.DisplayName,
+			FirstName:   user.FirstName,
+			LastName:    user.LastName,
+			Email:       user.Email,
+			Admin:       slices.Contains(admins, name),
+			Keys:        []PublicKey{},
+		}
+		if user.PublicKey != "" {
+			u.Keys = append(u.Keys, PublicKey{Name: "default", PublicKey: user.PublicKey}.withFingerprint())
+		}
+		return u, nil
+	})
+}
+
+// adminMembers returns the clients and users that belong to the admins group. Organizations without an admins group
+// (such as chef-zero or goiardi without organizations) have no admins.
+func (s Service) adminMembers(ctx context.Context) ([]string, error) {
+	group, err := s.GetGroup(ctx, AdminsGroup)
+	if err != nil {
+		if errors.Is(err, ErrNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return slices.Concat(group.Actors, group.Clients, group.Users), nil
+}
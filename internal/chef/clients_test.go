@@ -0,0 +1,115 @@
+package chef
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/drewhammond/chefbrowser/config"
+)
+
+func newClientsTestServer(t *testing.T, publicKey string) *httptest.Server {
+	t.Helper()
+
+	key, _ := json.Marshal(publicKey)
+	responses := map[string]string{
+		"/clients":                        `{"web-1": "/clients/web-1", "example-validator": "/clients/example-validator", "ci": "/clients/ci"}`,
+		"/clients/web-1":                  `{"name": "web-1", "validator": false}`,
+		"/clients/web-1/keys":             `[{"name": "default", "expired": false}, {"name": "old", "expired": true}]`,
+		"/clients/web-1/keys/default":     `{"name": "default", "public_key": ` + string(key) + `, "expiration_date": "infinity"}`,
+		"/clients/web-1/keys/old":         `{"name": "old", "public_key": "garbage", "expiration_date": "2020-01-01T00:00:00Z"}`,
+		"/clients/example-validator":      `{"name": "example-validator", "validator": true}`,
+		"/users":                          `[{"user": {"username": "bob"}}, {"user": {"username": "alice"}}]`,
+		"/users/alice":                    `{"username": "alice", "display_name": "Alice", "email": "alice@example.com", "public_key": ` + string(key) + `}`,
+		"/groups/admins":                  `{"name": "admins", "actors": [], "users": ["alice"], "clients": ["ci"], "groups": []}`,
+		"/search/client":                  `{"total": 1, "rows": [{"data": {"name": "example-validator"}}]}`,
+		"/clients/example-validator/keys": `[]`,
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.ReadAll(r.Body)
+		body, ok := responses[r.URL.Path]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(body))
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestGetAPIClients(t *testing.T) {
+	s := newTestService(t, newClientsTestServer(t, "").URL, &config.Config{})
+
+	got, err := s.GetAPIClients(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := &ClientList{Clients: []ClientListItem{
+		{Name: "ci", Admin: true},
+		{Name: "example-validator", Validator: true},
+		{Name: "web-1"},
+	}}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("GetAPIClients() = %+v, want %+v", got, expected)
+	}
+}
+
+func TestGetAPIClient(t *testing.T) {
+	publicKey, _ := testPublicKey(t)
+	fingerprint, _ := KeyFingerprint(publicKey)
+	s := newTestService(t, newClientsTestServer(t, publicKey).URL, &config.Config{})
+
+	got, err := s.GetAPIClient(context.Background(), "web-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := &APIClient{Name: "web-1", Keys: []PublicKey{
+		{Name: "default", PublicKey: publicKey, ExpirationDate: "infinity", Fingerprint: fingerprint},
+		{Name: "old", PublicKey: "garbage", ExpirationDate: "2020-01-01T00:00:00Z", Expired: true},
+	}}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("GetAPIClient() = %+v, want %+v", got, expected)
+	}
+
+	if _, err := s.GetAPIClient(context.Background(), "missing"); !errors.Is(err, ErrClientNotFound) {
+		t.Errorf("expected ErrClientNotFound, actual: %v", err)
+	}
+}
+
+func TestGetUsers(t *testing.T) {
+	publicKey, _ := testPublicKey(t)
+	fingerprint, _ := KeyFingerprint(publicKey)
+	s := newTestService(t, newClientsTestServer(t, publicKey).URL, &config.Config{})
+
+	users, err := s.GetUsers(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := &UserList{Users: []UserListItem{{Name: "alice", Admin: true}, {Name: "bob"}}}
+	if !reflect.DeepEqual(users, expected) {
+		t.Errorf("GetUsers() = %+v, want %+v", users, expected)
+	}
+
+	user, err := s.GetUser(context.Background(), "alice")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectedUser := &User{Name: "alice", DisplayName: "Alice", Email: "alice@example.com", Admin: true,
+		Keys: []PublicKey{{Name: "default", PublicKey: publicKey, Fingerprint: fingerprint}}}
+	if !reflect.DeepEqual(user, expectedUser) {
+		t.Errorf("GetUser() = %+v, want %+v", user, expectedUser)
+	}
+
+	if _, err := s.GetUser(context.Background(), "bob"); !errors.Is(err, ErrUserNotFound) {
+		t.Errorf("expected ErrUserNotFound, actual: %v", err)
+	}
+}
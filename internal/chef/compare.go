@@ -0,0 +1,154 @@
+package chef
+
+import (
+	"context"
+	"reflect"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/drewhammond/chefbrowser/internal/util"
+	"golang.org/x/sync/errgroup"
+)
+
+// Kinds of differences between two objects. Added values only exist in the second object and removed values
+// only exist in the first.
+const (
+	DiffAdded   = "added"
+	DiffRemoved = "removed"
+	DiffChanged = "changed"
+)
+
+// ValueDiff is a single difference between two objects, identified by its flattened path (e.g. "$.nginx.port")
+type ValueDiff struct {
+	Path string      `json:"path"`
+	Kind string      `json:"kind"`
+	A    interface{} `json:"a,omitempty"`
+	B    interface{} `json:"b,omitempty"`
+}
+
+// ListDiff compares two ordered lists such as run lists
+type ListDiff struct {
+	A []string `json:"a"`
+	B []string `json:"b"`
+	// Equal is true if both lists contain the same items in the same order
+	Equal   bool     `json:"equal"`
+	Added   []string `json:"added"`
+	Removed []string `json:"removed"`
+}
+
+// AttributeDiff holds the differences of a single attribute precedence level, or of the merged attributes
+type AttributeDiff struct {
+	Level string      `json:"level"`
+	Diffs []ValueDiff `json:"diffs"`
+}
+
+// NodeComparison holds every difference between two nodes
+type NodeComparison struct {
+	A string `json:"a"`
+	B string `json:"b"`
+	// Fields compares the environment and policy of each node
+	Fields     []ValueDiff     `json:"fields"`
+	RunList    ListDiff        `json:"run_list"`
+	Attributes []AttributeDiff `json:"attributes"`
+}
+
+// CompareNodes fetches two nodes and returns their differences. If hideAutomatic is true the automatic (ohai)
+// attributes are left out, both as a precedence level and from the merged attributes.
+func (s Service) CompareNodes(ctx context.Context, a string, b string, hideAutomatic bool) (*NodeComparison, error) {
+	var nodeA, nodeB *Node
+
+	g, gctx := errgroup.WithContext(ctx)
+	g.Go(func() (err error) {
+		nodeA, err = s.GetNode(gctx, a)
+		return err
+	})
+	g.Go(func() (err error) {
+		nodeB, err = s.GetNode(gctx, b)
+		return err
+	})
+	if err := g.Wait(); err != nil {
+		return nil, err
+	}
+
+	return compareNodes(nodeA, nodeB, hideAutomatic), nil
+}
+
+func compareNodes(a *Node, b *Node, hideAutomatic bool) *NodeComparison {
+	c := &NodeComparison{
+		A: a.Name,
+		B: b.Name,
+		Fields: DiffValues(
+			map[string]interface{}{"chef_environment": a.Environment, "policy_name": a.PolicyName, "policy_group": a.PolicyGroup},
+			map[string]interface{}{"chef_environment": b.Environment, "policy_name": b.PolicyName, "policy_group": b.PolicyGroup},
+		),
+		RunList: DiffLists(a.RunList, b.RunList),
+	}
+
+	merged := DiffValues(util.MakeJSONPath(a.MergedAttributes, "$"), util.MakeJSONPath(b.MergedAttributes, "$"))
+	if hideAutomatic {
+		automatic := util.MakeJSONPath(a.AutomaticAttributes, "$")
+		for k, v := range util.MakeJSONPath(b.AutomaticAttributes, "$") {
+			automatic[k] = v
+		}
+		merged = slices.DeleteFunc(merged, func(d ValueDiff) bool {
+			_, ok := automatic[d.Path]
+			return ok
+		})
+	}
+
+	c.Attributes = []AttributeDiff{
+		{Level: "merged", Diffs: merged},
+		{Level: LevelDefault, Diffs: DiffValues(util.MakeJSONPath(a.DefaultAttributes, "$"), util.MakeJSONPath(b.DefaultAttributes, "$"))},
+		{Level: LevelNormal, Diffs: DiffValues(util.MakeJSONPath(a.NormalAttributes, "$"), util.MakeJSONPath(b.NormalAttributes, "$"))},
+		{Level: LevelOverride, Diffs: DiffValues(util.MakeJSONPath(a.OverrideAttributes, "$"), util.MakeJSONPath(b.OverrideAttributes, "$"))},
+	}
+	if !hideAutomatic {
+		c.Attributes = append(c.Attributes, AttributeDiff{
+			Level: LevelAutomatic,
+			Diffs: DiffValues(util.MakeJSONPath(a.AutomaticAttributes, "$"), util.MakeJSONPath(b.AutomaticAttributes, "$")),
+		})
+	}
+
+	return c
+}
+
+// DiffValues compares two maps of flattened values (see util.MakeJSONPath) and returns the differences sorted by path
+func DiffValues(a map[string]interface{}, b map[string]interface{}) []ValueDiff {
+	diffs := []ValueDiff{}
+	for path, av := range a {
+		bv, ok := b[path]
+		switch {
+		case !ok:
+			diffs = append(diffs, ValueDiff{Path: path, Kind: DiffRemoved, A: av})
+		case !reflect.DeepEqual(av, bv):
+			diffs = append(diffs, ValueDiff{Path: path, Kind: DiffChanged, A: av, B: bv})
+		}
+	}
+	for path, bv := range b {
+		if _, ok := a[path]; !ok {
+			diffs = append(diffs, ValueDiff{Path: path, Kind: DiffAdded, B: bv})
+		}
+	}
+
+	sort.Slice(diffs, func(i, j int) bool {
+		return strings.Compare(diffs[i].Path, diffs[j].Path) < 0
+	})
+	return diffs
+}
+
+// DiffLists compares two ordered lists
+func DiffLists(a []string, b []string) ListDiff {
+	d := ListDiff{A: a, B: b, Equal: slices.Equal(a, b), Added: []string{}, Removed: []string{}}
+	for _, i := range a {
+		if !slices.Contains(b, i) {
+			d.Removed = append(d.Removed, i)
+		}
+	}
+	for _, i := range b {
+		if !slices.Contains(a, i) {
+			d.Added = append(d.Added, i)
+		}
+	}
+	return d
+}
@@ -0,0 +1,100 @@
+package chef
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/go-chef/chef"
+)
+
+func TestDiffValues(t *testing.T) {
+	a := map[string]interface{}{"$.same": 1.0, "$.changed": "a", "$.removed": true, "$.list": []interface{}{"x"}}
+	b := map[string]interface{}{"$.same": 1.0, "$.changed": "b", "$.added": false, "$.list": []interface{}{"x", "y"}}
+
+	expected := []ValueDiff{
+		{Path: "$.added", Kind: DiffAdded, B: false},
+		{Path: "$.changed", Kind: DiffChanged, A: "a", B: "b"},
+		{Path: "$.list", Kind: DiffChanged, A: []interface{}{"x"}, B: []interface{}{"x", "y"}},
+		{Path: "$.removed", Kind: DiffRemoved, A: true},
+	}
+	if got := DiffValues(a, b); !reflect.DeepEqual(got, expected) {
+		t.Errorf("DiffValues() = %v, want %v", got, expected)
+	}
+}
+
+func TestDiffLists(t *testing.T) {
+	tests := []struct {
+		name     string
+		a, b     []string
+		expected ListDiff
+	}{
+		{
+			"equal",
+			[]string{"role[base]", "recipe[web]"},
+			[]string{"role[base]", "recipe[web]"},
+			ListDiff{Equal: true, Added: []string{}, Removed: []string{}},
+		},
+		{
+			"reordered",
+			[]string{"role[base]", "recipe[web]"},
+			[]string{"recipe[web]", "role[base]"},
+			ListDiff{Equal: false, Added: []string{}, Removed: []string{}},
+		},
+		{
+			"added and removed",
+			[]string{"role[base]", "recipe[web]"},
+			[]string{"role[base]", "recipe[db]"},
+			ListDiff{Equal: false, Added: []string{"recipe[db]"}, Removed: []string{"recipe[web]"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.expected.A, tt.expected.B = tt.a, tt.b
+			if got := DiffLists(tt.a, tt.b); !reflect.DeepEqual(got, tt.expected) {
+				t.Errorf("DiffLists() = %v, want %v", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestCompareNodes(t *testing.T) {
+	newNode := func(name string, env string, normal string, kernel string) *Node {
+		n := &Node{Node: chef.Node{
+			Name:                name,
+			Environment:         env,
+			RunList:             []string{"role[base]"},
+			NormalAttributes:    map[string]interface{}{"app": map[string]interface{}{"version": normal}},
+			AutomaticAttributes: map[string]interface{}{"kernel": map[string]interface{}{"release": kernel}},
+		}}
+		n.MergedAttributes = n.MergeAttributes(nil, nil)
+		return n
+	}
+	a := newNode("a", "prod", "1.0", "5.15")
+	b := newNode("b", "staging", "1.1", "6.1")
+
+	levels := func(c *NodeComparison) map[string]int {
+		out := map[string]int{}
+		for _, l := range c.Attributes {
+			out[l.Level] = len(l.Diffs)
+		}
+		return out
+	}
+
+	c := compareNodes(a, b, false)
+	if len(c.Fields) != 1 || c.Fields[0].Path != "chef_environment" {
+		t.Errorf("expected only the environment to differ, actual: %v", c.Fields)
+	}
+	if !c.RunList.Equal {
+		t.Errorf("expected run lists to be equal")
+	}
+	expected := map[string]int{"merged": 2, LevelDefault: 0, LevelNormal: 1, LevelOverride: 0, LevelAutomatic: 1}
+	if got := levels(c); !reflect.DeepEqual(got, expected) {
+		t.Errorf("unexpected differences, expected: %v, actual: %v", expected, got)
+	}
+
+	c = compareNodes(a, b, true)
+	expected = map[string]int{"merged": 1, LevelDefault: 0, LevelNormal: 1, LevelOverride: 0}
+	if got := levels(c); !reflect.DeepEqual(got, expected) {
+		t.Errorf("unexpected differences hiding automatic attributes, expected: %v, actual: %v", expected, got)
+	}
+}
@@ -0,0 +1,91 @@
+package chef
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"golang.org/x/mod/semver"
+)
+
+var ErrInvalidConstraint = errors.New("invalid version constraint")
+
+// VersionConstraint is a cookbook version constraint such as ">= 1.2.0" or "~> 2.1", as used in cookbook
+// dependencies and environment cookbook_versions
+type VersionConstraint struct {
+	Operator string
+	Version  string
+}
+
+// ParseVersionConstraint parses a cookbook version constraint. An empty constraint matches every version and a
+// version without an operator must match exactly.
+// Ref: https://docs.chef.io/cookbook_versioning/#constraints
+func ParseVersionConstraint(s string) (VersionConstraint, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return VersionConstraint{Operator: ">=", Version: "0.0.0"}, nil
+	}
+
+	c := VersionConstraint{Operator: "="}
+	for _, op := range []string{"~>", ">=", "<=", "=", ">", "<"} {
+		if strings.HasPrefix(s, op) {
+			c.Operator = op
+			s = strings.TrimSpace(strings.TrimPrefix(s, op))
+			break
+		}
+	}
+
+	if !semver.IsValid("v"+s) || strings.ContainsAny(s, "-+") {
+		return VersionConstraint{}, fmt.Errorf("%w: %q", ErrInvalidConstraint, s)
+	}
+	c.Version = s
+	return c, nil
+}
+
+func (c VersionConstraint) String() string {
+	return c.Operator + " " + c.Version
+}
+
+// Matches reports whether version satisfies the constraint
+func (c VersionConstraint) Matches(version string) bool {
+	cmp := semver.Compare("v"+version, "v"+c.Version)
+	switch c.Operator {
+	case "=":
+		return cmp == 0
+	case ">":
+		return cmp > 0
+	case "<":
+		return cmp < 0
+	case ">=":
+		return cmp >= 0
+	case "<=":
+		return cmp <= 0
+	case "~>":
+		return cmp >= 0 && semver.Compare("v"+version, "v"+pessimisticUpperBound(c.Version)) < 0
+	}
+	return false
+}
+
+// pessimisticUpperBound returns the first version excluded by "~> version": "~> 2.1" allows any 2.x from 2.1 and
+// "~> 2.1.3" allows any 2.1.x from 2.1.3
+func pessimisticUpperBound(version string) string {
+	parts := strings.Split(version, ".")
+	if len(parts) > 1 {
+		parts = parts[:len(parts)-1]
+	}
+	last, _ := strconv.Atoi(parts[len(parts)-1])
+	parts[len(parts)-1] = strconv.Itoa(last + 1)
+	return strings.Join(parts, ".")
+}
+
+// LatestMatching returns the newest of versions (ordered newest first, see Universe.Versions) that satisfies the
+// constraint, or an empty string if none do
+func (c VersionConstraint) LatestMatching(versions []string) string {
+	for _, v := range versions {
+		if c.Matches(v) {
+			return v
+		}
+	}
+	return ""
+}
@@ -0,0 +1,65 @@
+package chef
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestVersionConstraintMatches(t *testing.T) {
+	tests := []struct {
+		constraint string
+		version    string
+		expected   bool
+	}{
+		{"", "0.1.0", true},
+		{"1.2.0", "1.2.0", true},
+		{"= 1.2.0", "1.2.1", false},
+		{"= 1.2", "1.2.0", true},
+		{"> 1.2.0", "1.2.1", true},
+		{"> 1.2.0", "1.2.0", false},
+		{"< 2.0", "1.9.9", true},
+		{">= 1.2.0", "1.2.0", true},
+		{"<= 1.2.0", "1.3.0", false},
+		{"~> 2.1", "2.1.0", true},
+		{"~> 2.1", "2.9.0", true},
+		{"~> 2.1", "3.0.0", false},
+		{"~> 2.1", "2.0.9", false},
+		{"~> 2.1.3", "2.1.9", true},
+		{"~> 2.1.3", "2.2.0", false},
+		{"~> 2", "2.5.0", true},
+		{"~> 2", "3.0.0", false},
+	}
+	for _, tt := range tests {
+		c, err := ParseVersionConstraint(tt.constraint)
+		if err != nil {
+			t.Fatalf("ParseVersionConstraint(%q) failed: %v", tt.constraint, err)
+		}
+		if got := c.Matches(tt.version); got != tt.expected {
+			t.Errorf("%q.Matches(%q) = %v, want %v", tt.constraint, tt.version, got, tt.expected)
+		}
+	}
+}
+
+func TestParseVersionConstraintInvalid(t *testing.T) {
+	for _, s := range []string{">= foo", "~> 1.x", "1.0.0-beta", "=> 1.0"} {
+		if _, err := ParseVersionConstraint(s); !errors.Is(err, ErrInvalidConstraint) {
+			t.Errorf("ParseVersionConstraint(%q) error = %v, want ErrInvalidConstraint", s, err)
+		}
+	}
+}
+
+func TestLatestMatching(t *testing.T) {
+	versions := []string{"3.0.0", "2.2.0", "2.1.5", "1.0.0"}
+	tests := map[string]string{
+		"~> 2.1":  "2.2.0",
+		"< 2.2":   "2.1.5",
+		"> 3.0.0": "",
+		"":        "3.0.0",
+	}
+	for constraint, expected := range tests {
+		c, _ := ParseVersionConstraint(constraint)
+		if got := c.LatestMatching(versions); got != expected {
+			t.Errorf("%q.LatestMatching() = %q, want %q", constraint, got, expected)
+		}
+	}
+}
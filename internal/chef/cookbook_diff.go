@@ -0,0 +1,217 @@
+package chef
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/drewhammond/chefbrowser/internal/util"
+	"github.com/go-chef/chef"
+	"golang.org/x/sync/errgroup"
+)
+
+const (
+	// diffContextLines is the number of unchanged lines shown around each change in a file diff
+	diffContextLines = 3
+	// maxDiffFileSize is the largest file that is diffed. Larger files are only reported as changed.
+	maxDiffFileSize = 1 << 20
+	// maxConcurrentDownloads limits the number of files fetched from bookshelf at the same time
+	maxConcurrentDownloads = 4
+)
+
+// ErrInvalidVersionRange is returned for version ranges that are not of the form "<from>...<to>"
+var ErrInvalidVersionRange = errors.New("version range must be of the form <from>...<to>")
+
+// CookbookFileDiff describes a file that was added, removed or changed between two cookbook versions
+type CookbookFileDiff struct {
+	Path         string `json:"path"`
+	Kind         string `json:"kind"`
+	FromChecksum string `json:"from_checksum,omitempty"`
+	ToChecksum   string `json:"to_checksum,omitempty"`
+	// Binary is true if either version of a changed file is not text, in which case no diff is shown
+	Binary bool `json:"binary,omitempty"`
+	// TooLarge is true if either version of a changed file is too large to diff
+	TooLarge bool            `json:"too_large,omitempty"`
+	Hunks    []util.DiffHunk `json:"hunks,omitempty"`
+}
+
+// CookbookDiff holds the differences between two versions of a cookbook
+type CookbookDiff struct {
+	Name  string             `json:"name"`
+	From  string             `json:"from"`
+	To    string             `json:"to"`
+	Files []CookbookFileDiff `json:"files"`
+	// Unchanged is the number of files that are the same in both versions
+	Unchanged int         `json:"unchanged"`
+	Metadata  []ValueDiff `json:"metadata"`
+}
+
+// ParseVersionRange splits a version range such as "1.0.0...1.2.0" into the versions being compared
+func ParseVersionRange(r string) (string, string, error) {
+	from, to, ok := strings.Cut(r, "...")
+	if !ok || from == "" || to == "" {
+		return "", "", ErrInvalidVersionRange
+	}
+	return from, to, nil
+}
+
+// CompareCookbookVersions returns the files and metadata that differ between two versions of a cookbook. Files are
+// compared by checksum and only changed files are downloaded to produce a unified diff.
+func (s Service) CompareCookbookVersions(ctx context.Context, name string, from string, to string) (*CookbookDiff, error) {
+	var a, b *Cookbook
+
+	g, gctx := errgroup.WithContext(ctx)
+	g.Go(func() (err error) {
+		a, err = s.GetCookbookVersion(gctx, name, from)
+		return err
+	})
+	g.Go(func() (err error) {
+		b, err = s.GetCookbookVersion(gctx, name, to)
+		return err
+	})
+	if err := g.Wait(); err != nil {
+		return nil, err
+	}
+
+	d := &CookbookDiff{
+		Name:     name,
+		From:     a.Metadata.Version,
+		To:       b.Metadata.Version,
+		Metadata: diffCookbookMetadata(a.Metadata, b.Metadata),
+	}
+	d.Files, d.Unchanged = diffCookbookFiles(a, b)
+
+	ctx, cancel := s.WithTimeout(ctx)
+	defer cancel()
+
+	client := s.fileClient()
+	fromFiles, toFiles := a.files(), b.files()
+
+	g, gctx = errgroup.WithContext(ctx)
+	g.SetLimit(maxConcurrentDownloads)
+	for i := range d.Files {
+		f := &d.Files[i]
+		if f.Kind != DiffChanged {
+			continue
+		}
+		g.Go(func() error {
+			fromContent, err := downloadFile(gctx, client, fromFiles[f.Path].Url)
+			if err != nil {
+				return err
+			}
+			toContent, err := downloadFile(gctx, client, toFiles[f.Path].Url)
+			if err != nil {
+				return err
+			}
+			f.diffContents(fromContent, toContent)
+			return nil
+		})
+	}
+	if err := g.Wait(); err != nil {
+		return nil, err
+	}
+
+	return d, nil
+}
+
+// Patch returns the file changes as a unified diff. Binary and very large files are left out.
+func (d *CookbookDiff) Patch() string {
+	var b strings.Builder
+	for _, f := range d.Files {
+		b.WriteString(util.FormatUnifiedDiff(d.From+"/"+f.Path, d.To+"/"+f.Path, f.Hunks))
+	}
+	return b.String()
+}
+
+// files returns every file of the cookbook keyed by path
+func (s Cookbook) files() map[string]chef.CookbookItem {
+	files := map[string]chef.CookbookItem{}
+	for _, segment := range [][]chef.CookbookItem{
+		s.Attributes, s.Definitions, s.Files, s.Libraries, s.Providers, s.Recipes, s.Resources, s.RootFiles, s.Templates,
+	} {
+		for _, f := range segment {
+			files[f.Path] = f
+		}
+	}
+	return files
+}
+
+// diffCookbookFiles compares the files of two cookbook versions by checksum. The returned files are sorted by path.
+func diffCookbookFiles(a *Cookbook, b *Cookbook) ([]CookbookFileDiff, int) {
+	fromFiles, toFiles := a.files(), b.files()
+	diffs := []CookbookFileDiff{}
+	unchanged := 0
+
+	for path, f := range fromFiles {
+		t, ok := toFiles[path]
+		switch {
+		case !ok:
+			diffs = append(diffs, CookbookFileDiff{Path: path, Kind: DiffRemoved, FromChecksum: f.Checksum})
+		case f.Checksum != t.Checksum:
+			diffs = append(diffs, CookbookFileDiff{Path: path, Kind: DiffChanged, FromChecksum: f.Checksum, ToChecksum: t.Checksum})
+		default:
+			unchanged++
+		}
+	}
+	for path, t := range toFiles {
+		if _, ok := fromFiles[path]; !ok {
+			diffs = append(diffs, CookbookFileDiff{Path: path, Kind: DiffAdded, ToChecksum: t.Checksum})
+		}
+	}
+
+	sort.Slice(diffs, func(i, j int) bool {
+		return diffs[i].Path < diffs[j].Path
+	})
+	return diffs, unchanged
+}
+
+// diffContents sets the hunks of a changed file, unless either version is binary or too large to diff
+func (f *CookbookFileDiff) diffContents(from []byte, to []byte) {
+	if len(from) > maxDiffFileSize || len(to) > maxDiffFileSize {
+		f.TooLarge = true
+		return
+	}
+	if isBinary(from) || isBinary(to) {
+		f.Binary = true
+		return
+	}
+	f.Hunks = util.DiffText(string(from), string(to), diffContextLines)
+}
+
+func isBinary(content []byte) bool {
+	return bytes.IndexByte(content, 0) >= 0 || !utf8.Valid(content)
+}
+
+// diffCookbookMetadata compares the metadata of two cookbook versions, such as dependencies and supported platforms.
+// The name, version and long description (usually a copy of the README) are left out.
+func diffCookbookMetadata(a chef.CookbookMeta, b chef.CookbookMeta) []ValueDiff {
+	return DiffValues(util.MakeJSONPath(metadataValues(a), "$"), util.MakeJSONPath(metadataValues(b), "$"))
+}
+
+func metadataValues(m chef.CookbookMeta) map[string]interface{} {
+	values := map[string]interface{}{}
+	// round trip through JSON so nested values have the same types as other attributes
+	if raw, err := json.Marshal(m); err == nil {
+		_ = json.Unmarshal(raw, &values)
+	}
+
+	delete(values, "name")
+	delete(values, "version")
+	delete(values, "long_description")
+
+	// go-chef does not set JSON names for these fields
+	delete(values, "ChefVersion")
+	delete(values, "OhaiVersion")
+	if m.ChefVersion != "" {
+		values["chef_version"] = m.ChefVersion
+	}
+	if m.OhaiVersion != "" {
+		values["ohai_version"] = m.OhaiVersion
+	}
+
+	return values
+}
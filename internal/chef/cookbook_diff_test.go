@@ -0,0 +1,126 @@
+package chef
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/drewhammond/chefbrowser/internal/util"
+	"github.com/go-chef/chef"
+)
+
+func TestParseVersionRange(t *testing.T) {
+	tests := []struct {
+		in       string
+		from, to string
+		wantErr  bool
+	}{
+		{"1.0.0...1.2.0", "1.0.0", "1.2.0", false},
+		{"1.0.0..._latest", "1.0.0", "_latest", false},
+		{"1.0.0..1.2.0", "", "", true},
+		{"1.0.0...", "", "", true},
+		{"1.0.0", "", "", true},
+	}
+	for _, tt := range tests {
+		from, to, err := ParseVersionRange(tt.in)
+		if (err != nil) != tt.wantErr || from != tt.from || to != tt.to {
+			t.Errorf("ParseVersionRange(%q) = %q, %q, %v", tt.in, from, to, err)
+		}
+	}
+}
+
+func TestDiffCookbookFiles(t *testing.T) {
+	a := &Cookbook{chef.Cookbook{
+		Recipes: []chef.CookbookItem{
+			{Path: "recipes/default.rb", Checksum: "1"},
+			{Path: "recipes/old.rb", Checksum: "2"},
+		},
+		RootFiles: []chef.CookbookItem{{Path: "metadata.rb", Checksum: "3"}},
+	}}
+	b := &Cookbook{chef.Cookbook{
+		Recipes: []chef.CookbookItem{
+			{Path: "recipes/default.rb", Checksum: "1"},
+			{Path: "recipes/new.rb", Checksum: "4"},
+		},
+		RootFiles: []chef.CookbookItem{{Path: "metadata.rb", Checksum: "5"}},
+	}}
+
+	expected := []CookbookFileDiff{
+		{Path: "metadata.rb", Kind: DiffChanged, FromChecksum: "3", ToChecksum: "5"},
+		{Path: "recipes/new.rb", Kind: DiffAdded, ToChecksum: "4"},
+		{Path: "recipes/old.rb", Kind: DiffRemoved, FromChecksum: "2"},
+	}
+
+	files, unchanged := diffCookbookFiles(a, b)
+	if !reflect.DeepEqual(files, expected) {
+		t.Errorf("diffCookbookFiles() = %v, want %v", files, expected)
+	}
+	if unchanged != 1 {
+		t.Errorf("diffCookbookFiles() unchanged = %d, want 1", unchanged)
+	}
+}
+
+func TestCookbookFileDiffContents(t *testing.T) {
+	tests := []struct {
+		name     string
+		from, to []byte
+		binary   bool
+		tooLarge bool
+		hunks    int
+	}{
+		{"text", []byte("a\nb\n"), []byte("a\nc\n"), false, false, 1},
+		{"nul byte", []byte("a\x00b"), []byte("a\nc\n"), true, false, 0},
+		{"invalid utf-8", []byte("a\n"), []byte{0xff, 0xfe}, true, false, 0},
+		{"too large", []byte(strings.Repeat("a", maxDiffFileSize+1)), []byte("a\n"), false, true, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := CookbookFileDiff{Path: "files/test", Kind: DiffChanged}
+			f.diffContents(tt.from, tt.to)
+			if f.Binary != tt.binary || f.TooLarge != tt.tooLarge || len(f.Hunks) != tt.hunks {
+				t.Errorf("diffContents() = binary %v, too large %v, %d hunks; want %v, %v, %d",
+					f.Binary, f.TooLarge, len(f.Hunks), tt.binary, tt.tooLarge, tt.hunks)
+			}
+		})
+	}
+}
+
+func TestDiffCookbookMetadata(t *testing.T) {
+	a := chef.CookbookMeta{
+		Name:            "nginx",
+		Version:         "1.0.0",
+		LongDescription: "old readme",
+		Depends:         map[string]string{"apt": ">= 1.0", "yum": "~> 3.0"},
+		ChefVersion:     ">= 15",
+	}
+	b := chef.CookbookMeta{
+		Name:            "nginx",
+		Version:         "1.1.0",
+		LongDescription: "new readme",
+		Depends:         map[string]string{"apt": ">= 2.0", "ohai": ">= 0.0.0"},
+		ChefVersion:     ">= 16",
+	}
+
+	expected := []ValueDiff{
+		{Path: "$.chef_version", Kind: DiffChanged, A: ">= 15", B: ">= 16"},
+		{Path: "$.dependencies.apt", Kind: DiffChanged, A: ">= 1.0", B: ">= 2.0"},
+		{Path: "$.dependencies.ohai", Kind: DiffAdded, B: ">= 0.0.0"},
+		{Path: "$.dependencies.yum", Kind: DiffRemoved, A: "~> 3.0"},
+	}
+	if got := diffCookbookMetadata(a, b); !reflect.DeepEqual(got, expected) {
+		t.Errorf("diffCookbookMetadata() = %v, want %v", got, expected)
+	}
+}
+
+func TestCookbookDiffPatch(t *testing.T) {
+	d := &CookbookDiff{From: "1.0.0", To: "1.1.0", Files: []CookbookFileDiff{
+		{Path: "files/logo.png", Kind: DiffChanged, Binary: true},
+		{Path: "recipes/default.rb", Kind: DiffChanged, Hunks: util.DiffText("a\nb\n", "a\nc\n", 3)},
+		{Path: "recipes/new.rb", Kind: DiffAdded},
+	}}
+
+	expected := "--- 1.0.0/recipes/default.rb\n+++ 1.1.0/recipes/default.rb\n@@ -1,2 +1,2 @@\n a\n-b\n+c\n"
+	if got := d.Patch(); got != expected {
+		t.Errorf("Patch() = %q, want %q", got, expected)
+	}
+}
@@ -4,8 +4,9 @@ import (
 	"context"
 	"errors"
 	"fmt"
-	"io"
 	"net/http"
+	"net/url"
+	"slices"
 	"sort"
 	"strings"
 
@@ -14,9 +15,9 @@ import (
 )
 
 var (
-	ErrCookbookNotFound        = errors.New("cookbook not found")
-	ErrCookbookVersionNotFound = errors.New("cookbook version not found")
-	ErrCookbookFileNotFound    = errors.New("cookbook file not found")
+	ErrCookbookNotFound        = fmt.Errorf("cookbook %w", ErrNotFound)
+	ErrCookbookVersionNotFound = fmt.Errorf("cookbook version %w", ErrNotFound)
+	ErrCookbookFileNotFound    = fmt.Errorf("cookbook file %w", ErrNotFound)
 	ErrInternalServerError     = errors.New("internal server error")
 )
 
@@ -32,6 +33,8 @@ type CookbookListItem struct {
 
 type CookbookListResult struct {
 	Cookbooks []CookbookListItem `json:"cookbooks"`
+	// Total is the number of cookbooks on the server, which may be more than the number of cookbooks in a page
+	Total int `json:"total"`
 }
 
 type Cookbook struct {
@@ -42,44 +45,112 @@ type CookbookMeta struct {
 	chef.CookbookMeta
 }
 
-func (s Service) GetCookbooks(ctx context.Context) (*CookbookListResult, error) {
-	universe, err := s.client.Universe.Get()
+// universeVersion is a single cookbook version as returned by the /universe endpoint
+type universeVersion struct {
+	LocationPath string            `json:"location_path"`
+	LocationType string            `json:"location_type"`
+	Dependencies map[string]string `json:"dependencies"`
+}
+
+// Universe holds the dependency constraints of every cookbook version on the server, keyed by cookbook name and
+// version. Use Versions to get the versions of a cookbook in order.
+type Universe map[string]map[string]universeVersion
+
+// Versions returns every version of the named cookbook, newest first
+func (u Universe) Versions(name string) []string {
+	var versions []string
+	for v := range u[name] {
+		// semver.Sort requires versions to be prefixed with "v"
+		versions = append(versions, "v"+v)
+	}
+
+	semver.Sort(versions)
+
+	// strip the leading "v" now that we're properly sorted
+	for i := range versions {
+		versions[i] = versions[i][1:]
+	}
+
+	ReverseSlice(versions)
+	return versions
+}
+
+// Dependencies returns the dependency constraints of a cookbook version, keyed by cookbook name
+func (u Universe) Dependencies(name string, version string) map[string]string {
+	return u[name][version].Dependencies
+}
+
+// GetUniverse returns the dependencies of every cookbook version on the server
+func (s Service) GetUniverse(ctx context.Context) (Universe, error) {
+	return cached(ctx, s.cache, "universe", s.config.Cache.CookbookTTL, s.fetchUniverse)
+}
+
+func (s Service) fetchUniverse(ctx context.Context) (Universe, error) {
+	var universe Universe
+	err := s.get(ctx, "universe", &universe)
 	if err != nil {
 		return nil, err
 	}
+	return universe, nil
+}
 
-	var cookbookList []CookbookListItem
-
-	for j, v := range universe.Books {
-		var versions []string
+func (s Service) GetCookbooks(ctx context.Context) (*CookbookListResult, error) {
+	return cached(ctx, s.cache, "cookbooks", s.config.Cache.CookbookTTL, s.listCookbooks)
+}
 
-		for q := range v.Versions {
-			// semver.Sort requires versions to be prefixed with "v"
-			versions = append(versions, "v"+q)
-		}
+func (s Service) listCookbooks(ctx context.Context) (*CookbookListResult, error) {
+	universe, err := s.GetUniverse(ctx)
+	if err != nil {
+		return nil, err
+	}
 
-		semver.Sort(versions)
+	return buildCookbookList(universe), nil
+}
 
-		// strip the leading "v" now that we're properly sorted
-		for i := range versions {
-			versions[i] = versions[i][1:]
-		}
+// buildCookbookList lists every cookbook in the universe sorted by name. The dependencies of each cookbook are
+// taken from its latest version.
+func buildCookbookList(universe Universe) *CookbookListResult {
+	var cookbookList []CookbookListItem
 
-		ReverseSlice(versions)
+	for name := range universe {
+		versions := universe.Versions(name)
 
-		cookbook := CookbookListItem{
-			Name:     j,
-			Versions: versions,
+		var dependencies []string
+		if len(versions) > 0 {
+			for dep := range universe.Dependencies(name, versions[0]) {
+				dependencies = append(dependencies, dep)
+			}
+			sort.Strings(dependencies)
 		}
 
-		cookbookList = append(cookbookList, cookbook)
+		cookbookList = append(cookbookList, CookbookListItem{
+			Name:         name,
+			Versions:     versions,
+			Dependencies: dependencies,
+		})
 	}
 
 	sort.SliceStable(cookbookList, func(i, j int) bool {
 		return cookbookList[i].Name < cookbookList[j].Name
 	})
 
-	return &CookbookListResult{Cookbooks: cookbookList}, nil
+	return &CookbookListResult{Cookbooks: cookbookList, Total: len(cookbookList)}
+}
+
+// GetCookbooksPage returns a single page of cookbooks sorted by name. The chef server does not support paging the
+// cookbook universe, so the full list is fetched (and cached) before the requested page is selected.
+func (s Service) GetCookbooksPage(ctx context.Context, page Page, descending bool) (*CookbookListResult, error) {
+	cookbooks, err := s.GetCookbooks(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	items := slices.Clone(cookbooks.Cookbooks)
+	if descending {
+		slices.Reverse(items)
+	}
+
+	return &CookbookListResult{Cookbooks: paginate(items, page), Total: len(items)}, nil
 }
 
 func ReverseSlice[T comparable](s []T) {
@@ -89,7 +160,8 @@ func ReverseSlice[T comparable](s []T) {
 }
 
 func (s Service) GetLatestCookbooks(ctx context.Context) (*CookbookListResult, error) {
-	cookbooks, err := s.client.Cookbooks.List()
+	var cookbooks chef.CookbookListResult
+	err := s.get(ctx, "cookbooks", &cookbooks)
 	if err != nil {
 		return nil, err
 	}
@@ -110,7 +182,7 @@ func (s Service) GetLatestCookbooks(ctx context.Context) (*CookbookListResult, e
 		cookbookList = append(cookbookList, cookbook)
 	}
 
-	return &CookbookListResult{Cookbooks: cookbookList}, nil
+	return &CookbookListResult{Cookbooks: cookbookList, Total: len(cookbookList)}, nil
 }
 
 // GetCookbook should get the latest version of the cookbook
@@ -124,18 +196,56 @@ func (s Service) GetCookbook(ctx context.Context, name string) (*Cookbook, error
 }
 
 func (s Service) GetCookbookVersion(ctx context.Context, name string, version string) (*Cookbook, error) {
-	cookbook, err := s.client.Cookbooks.GetVersion(name, version)
-	if err != nil {
-		if cerr, ok := err.(*chef.ErrorResponse); ok {
-			if cerr.StatusCode() == 404 {
+	return cached(ctx, s.cache, "cookbook:"+name+"/"+version, s.config.Cache.CookbookTTL, func(ctx context.Context) (*Cookbook, error) {
+		var cookbook chef.Cookbook
+		err := s.get(ctx, "cookbooks/"+url.PathEscape(name)+"/"+url.PathEscape(version), &cookbook)
+		if err != nil {
+			if errors.Is(err, ErrNotFound) {
 				return nil, ErrCookbookVersionNotFound
 			}
+			s.log.Error(err.Error())
+			return nil, err
 		}
-		s.log.Error(err.Error())
-		return nil, ErrInternalServerError
-	}
 
-	return &Cookbook{cookbook}, nil
+		return &Cookbook{cookbook}, nil
+	})
+}
+
+// GetCookbookVersions returns every available version of the named cookbook
+func (s Service) GetCookbookVersions(ctx context.Context, name string) ([]string, error) {
+	return cached(ctx, s.cache, "cookbook_versions:"+name, s.config.Cache.CookbookTTL, func(ctx context.Context) ([]string, error) {
+		var resp chef.CookbookListResult
+		err := s.get(ctx, "cookbooks/"+url.PathEscape(name)+"?num_versions=all", &resp)
+		if err != nil {
+			if errors.Is(err, ErrNotFound) {
+				return nil, ErrCookbookNotFound
+			}
+			return nil, err
+		}
+
+		var versions []string
+		for _, i := range resp {
+			for _, j := range i.Versions {
+				versions = append(versions, j.Version)
+			}
+		}
+
+		return versions, nil
+	})
+}
+
+// GetCookbookFile downloads a file of a cookbook version from bookshelf
+func (s Service) GetCookbookFile(ctx context.Context, cookbook *Cookbook, path string) (string, error) {
+	ctx, cancel := s.WithTimeout(ctx)
+	defer cancel()
+	return cookbook.GetFile(ctx, s.fileClient(), path)
+}
+
+// GetCookbookReadme downloads the README.md of a cookbook version from bookshelf
+func (s Service) GetCookbookReadme(ctx context.Context, cookbook *Cookbook) (string, error) {
+	ctx, cancel := s.WithTimeout(ctx)
+	defer cancel()
+	return cookbook.GetReadme(ctx, s.fileClient())
 }
 
 func (s Cookbook) GetFile(ctx context.Context, client *http.Client, path string) (string, error) {
@@ -161,7 +271,7 @@ func (s Cookbook) GetFile(ctx context.Context, client *http.Client, path string)
 	}
 	for _, f := range loc {
 		if f.Path == path {
-			content, err := downloadFile(client, f.Url)
+			content, err := downloadFile(ctx, client, f.Url)
 			if err != nil {
 				return "", err
 			}
@@ -172,25 +282,13 @@ func (s Cookbook) GetFile(ctx context.Context, client *http.Client, path string)
 	return "", ErrCookbookFileNotFound
 }
 
-func downloadFile(client *http.Client, url string) ([]byte, error) {
-	resp, err := client.Get(url)
-	if err != nil {
-		return nil, err
-	}
-	defer resp.Body.Close()
-	body, _ := io.ReadAll(resp.Body)
-	return body, nil
-}
-
 func (s Cookbook) GetReadme(ctx context.Context, client *http.Client) (string, error) {
 	for _, f := range s.RootFiles {
 		if f.Name == "README.md" {
-			resp, err := client.Get(f.Url)
+			body, err := downloadFile(ctx, client, f.Url)
 			if err != nil {
-				return "", fmt.Errorf("failed to download cookbook readme")
+				return "", fmt.Errorf("failed to download cookbook readme: %w", err)
 			}
-			defer resp.Body.Close()
-			body, _ := io.ReadAll(resp.Body)
 			return string(body), nil
 		}
 	}
@@ -3,37 +3,92 @@ package chef
 import (
 	"context"
 	"errors"
+	"fmt"
+	"net/url"
 
 	"github.com/go-chef/chef"
 )
 
 var (
-	ErrDatabagNotFound     = errors.New("databag not found")
-	ErrDatabagItemNotFound = errors.New("databag item not found")
+	ErrDatabagNotFound     = fmt.Errorf("databag %w", ErrNotFound)
+	ErrDatabagItemNotFound = fmt.Errorf("databag item %w", ErrNotFound)
 )
 
-func (s Service) GetDatabags(ctx context.Context) (interface{}, error) {
-	databags, err := s.client.DataBags.List()
+// GetDatabags returns the data bags the viewer of ctx may see
+func (s Service) GetDatabags(ctx context.Context) (chef.DataBagListResult, error) {
+	databags, err := cached(ctx, s.cache, "databags", s.config.Cache.DatabagTTL, func(ctx context.Context) (chef.DataBagListResult, error) {
+		var databags chef.DataBagListResult
+		err := s.get(ctx, "data", &databags)
+		if err != nil {
+			return nil, err
+		}
+
+		return databags, nil
+	})
 	if err != nil {
 		return nil, err
 	}
 
-	return databags, nil
+	// the cached list is shared, so filter a copy of it
+	visible := make(chef.DataBagListResult, len(databags))
+	for name, uri := range databags {
+		if s.canView(ctx, ObjectTypeDataBags, name) {
+			visible[name] = uri
+		}
+	}
+	return visible, nil
 }
 
 func (s Service) GetDatabagItems(ctx context.Context, name string) (*chef.DataBagListResult, error) {
-	items, err := s.client.DataBags.ListItems(name)
-	if err != nil {
-		return items, ErrDatabagNotFound
+	if !s.canView(ctx, ObjectTypeDataBags, name) {
+		return nil, ErrDatabagDenied
 	}
+	return cached(ctx, s.cache, "databag:"+name, s.config.Cache.DatabagTTL, func(ctx context.Context) (*chef.DataBagListResult, error) {
+		var items chef.DataBagListResult
+		err := s.get(ctx, "data/"+url.PathEscape(name), &items)
+		if err != nil {
+			if errors.Is(err, ErrNotFound) {
+				return &items, ErrDatabagNotFound
+			}
+			return &items, err
+		}
 
-	return items, nil
+		return &items, nil
+	})
 }
 
+// GetDatabagItemContent returns a data bag item without the values the viewer of ctx may not see. Encrypted items are
+// decrypted if the viewer is allowed to see their decrypted values (see GetDatabagItem).
 func (s Service) GetDatabagItemContent(ctx context.Context, databag string, item string) (chef.DataBagItem, error) {
-	contents, err := s.client.DataBags.GetItem(databag, item)
+	i, err := s.GetDatabagItem(ctx, databag, item)
+	if err != nil {
+		return nil, err
+	}
+	return i.Content, nil
+}
+
+// GetDatabagItem returns a data bag item along with its encryption. Encrypted items are decrypted with the secret
+// configured for the data bag if the viewer of ctx is allowed to see their decrypted values, and returned as they
+// are stored otherwise. Redactions apply to the decrypted values too.
+func (s Service) GetDatabagItem(ctx context.Context, databag string, item string) (*DatabagItem, error) {
+	if !s.canView(ctx, ObjectTypeDataBags, databag) {
+		return nil, ErrDatabagDenied
+	}
+	contents, err := cached(ctx, s.cache, "databag_item:"+databag+"/"+item, s.config.Cache.DatabagTTL, func(ctx context.Context) (chef.DataBagItem, error) {
+		var contents chef.DataBagItem
+		err := s.get(ctx, "data/"+url.PathEscape(databag)+"/"+url.PathEscape(item), &contents)
+		if err != nil {
+			if errors.Is(err, ErrNotFound) {
+				return contents, ErrDatabagItemNotFound
+			}
+			return contents, err
+		}
+		return contents, nil
+	})
 	if err != nil {
-		return contents, ErrDatabagItemNotFound
+		return nil, err
 	}
-	return contents, nil
+	// decryptItem copies the item, so the cached item stays encrypted
+	contents, encryption := s.decryptItem(ctx, databag, contents)
+	return &DatabagItem{Content: s.redactorFor(ctx).Redact(contents), Encryption: encryption}, nil
 }
@@ -0,0 +1,394 @@
+package chef
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"golang.org/x/sync/errgroup"
+)
+
+// maxConcurrentRevisions limits the number of policy revisions fetched at the same time
+const maxConcurrentRevisions = 4
+
+// DependencyResolution describes how the versions of a dependency tree are picked. Unlike chef-client's depsolver,
+// every constraint is resolved on its own, so a tree may hold several versions of a cookbook and versions that a
+// single consistent solution would not select.
+const DependencyResolution = "newest matching version per constraint"
+
+// DependencyNode is a cookbook in a dependency tree. Every dependency is resolved to the newest version in the
+// universe that satisfies its constraint (see DependencyResolution).
+type DependencyNode struct {
+	Name string `json:"name"`
+	// Resolution is set to DependencyResolution on the root of a tree
+	Resolution string `json:"resolution,omitempty"`
+	// Constraint is the version constraint the parent cookbook placed on this one. It is empty for the root.
+	Constraint string `json:"constraint,omitempty"`
+	Version    string `json:"version,omitempty"`
+	// Unresolved is true if no version of the cookbook satisfies the constraint
+	Unresolved bool `json:"unresolved,omitempty"`
+	// Cycle is true if the cookbook version already appears further up the tree
+	Cycle bool `json:"cycle,omitempty"`
+	// Deduped is true if the dependencies of this cookbook version are already listed elsewhere in the tree
+	Deduped      bool              `json:"deduped,omitempty"`
+	Dependencies []*DependencyNode `json:"dependencies,omitempty"`
+}
+
+// GetCookbookDependencies returns the transitive dependency tree of a cookbook version, resolving each constraint to
+// its newest matching version independently. Use "_latest" for the newest version.
+func (s Service) GetCookbookDependencies(ctx context.Context, name string, version string) (*DependencyNode, error) {
+	universe, err := s.GetUniverse(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	versions := universe.Versions(name)
+	if len(versions) == 0 {
+		return nil, ErrCookbookNotFound
+	}
+	if version == "_latest" {
+		version = versions[0]
+	}
+	if _, ok := universe[name][version]; !ok {
+		return nil, ErrCookbookVersionNotFound
+	}
+
+	return resolveDependencies(universe, name, version), nil
+}
+
+func resolveDependencies(universe Universe, name string, version string) *DependencyNode {
+	root := &DependencyNode{Name: name, Version: version, Resolution: DependencyResolution}
+	expanded := map[string]bool{}
+	resolveNode(universe, root, map[string]bool{}, expanded)
+	return root
+}
+
+// resolveNode adds the dependencies of n to the tree. path holds the cookbook versions between the root and n,
+// and expanded holds every cookbook version whose dependencies were already added.
+func resolveNode(universe Universe, n *DependencyNode, path map[string]bool, expanded map[string]bool) {
+	key := n.Name + "@" + n.Version
+	path[key] = true
+	expanded[key] = true
+	defer delete(path, key)
+
+	deps := universe.Dependencies(n.Name, n.Version)
+	names := make([]string, 0, len(deps))
+	for dep := range deps {
+		names = append(names, dep)
+	}
+	sort.Strings(names)
+
+	for _, dep := range names {
+		child := &DependencyNode{Name: dep, Constraint: deps[dep]}
+		n.Dependencies = append(n.Dependencies, child)
+
+		c, err := ParseVersionConstraint(deps[dep])
+		if err == nil {
+			child.Version = c.LatestMatching(universe.Versions(dep))
+		}
+		if child.Version == "" {
+			child.Unresolved = true
+			continue
+		}
+
+		childKey := child.Name + "@" + child.Version
+		switch {
+		case path[childKey]:
+			child.Cycle = true
+		case expanded[childKey]:
+			child.Deduped = len(universe.Dependencies(child.Name, child.Version)) > 0
+		default:
+			resolveNode(universe, child, path, expanded)
+		}
+	}
+}
+
+// WriteDOT writes the dependency tree as a Graphviz DOT digraph. Each cookbook version is a single vertex and
+// unresolved dependencies are drawn in red. The graph is labeled with how versions were picked so it is not mistaken
+// for the result of a depsolver.
+func (n *DependencyNode) WriteDOT(w io.Writer) error {
+	var b strings.Builder
+	b.WriteString("digraph dependencies {\n")
+	fmt.Fprintf(&b, "  label=%q;\n  labelloc=t;\n", n.label()+" dependencies, "+DependencyResolution)
+	fmt.Fprintf(&b, "  %q [shape=box];\n", n.label())
+
+	seen := map[string]bool{}
+	var walk func(*DependencyNode)
+	walk = func(parent *DependencyNode) {
+		for _, child := range parent.Dependencies {
+			edge := parent.label() + "\x00" + child.label()
+			if seen[edge] {
+				continue
+			}
+			seen[edge] = true
+
+			if child.Unresolved {
+				fmt.Fprintf(&b, "  %q [color=red, fontcolor=red];\n", child.label())
+			}
+			fmt.Fprintf(&b, "  %q -> %q [label=%q];\n", parent.label(), child.label(), child.Constraint)
+			walk(child)
+		}
+	}
+	walk(n)
+
+	b.WriteString("}\n")
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+func (n *DependencyNode) label() string {
+	if n.Version == "" {
+		return n.Name
+	}
+	return n.Name + " " + n.Version
+}
+
+// CookbookDependent is a cookbook version that depends on another cookbook
+type CookbookDependent struct {
+	Name       string `json:"name"`
+	Version    string `json:"version"`
+	Constraint string `json:"constraint"`
+}
+
+// RunListReference is a run list entry (e.g. "recipe[nginx::default]") of a node or role that refers to a cookbook
+type RunListReference struct {
+	Name string `json:"name"`
+	Item string `json:"item"`
+	// Environment is set for entries of a role's environment specific run list
+	Environment string `json:"environment,omitempty"`
+}
+
+// EnvironmentConstraint is a cookbook version constraint set by an environment
+type EnvironmentConstraint struct {
+	Name       string `json:"name"`
+	Constraint string `json:"constraint"`
+}
+
+// PolicyLock is a policy revision, assigned to a policy group, that locks a cookbook
+type PolicyLock struct {
+	Policy   string `json:"policy"`
+	Group    string `json:"group"`
+	Revision string `json:"revision"`
+	Version  string `json:"version"`
+}
+
+// CookbookUsage lists everything that refers to a cookbook
+type CookbookUsage struct {
+	Cookbook     string                  `json:"cookbook"`
+	Cookbooks    []CookbookDependent     `json:"cookbooks"`
+	Roles        []RunListReference      `json:"roles"`
+	Environments []EnvironmentConstraint `json:"environments"`
+	Policies     []PolicyLock            `json:"policies"`
+	Nodes        []RunListReference      `json:"nodes"`
+}
+
+// usageRole holds the run lists of a single role as returned by a partial search
+type usageRole struct {
+	Name        string              `json:"name"`
+	RunList     []string            `json:"run_list"`
+	EnvRunLists map[string][]string `json:"env_run_lists"`
+}
+
+// usageEnvironment holds the cookbook constraints of a single environment as returned by a partial search
+type usageEnvironment struct {
+	Name             string            `json:"name"`
+	CookbookVersions map[string]string `json:"cookbook_versions"`
+}
+
+// usageNode holds the run list of a single node as returned by a partial search
+type usageNode struct {
+	Name    string   `json:"name"`
+	RunList []string `json:"run_list"`
+}
+
+// GetCookbookUsage returns the cookbooks, roles, environments, policies and nodes that refer to a cookbook.
+// Roles and nodes are matched on their own run lists, so nodes that only get the cookbook from a role or as a
+// dependency are not included. Policies are matched on the revisions currently assigned to policy groups.
+func (s Service) GetCookbookUsage(ctx context.Context, name string) (*CookbookUsage, error) {
+	return cached(ctx, s.cache, "report/usage:"+name, s.config.Cache.NodeTTL, func(ctx context.Context) (*CookbookUsage, error) {
+		u := &CookbookUsage{
+			Cookbook:     name,
+			Roles:        []RunListReference{},
+			Environments: []EnvironmentConstraint{},
+			Policies:     []PolicyLock{},
+			Nodes:        []RunListReference{},
+		}
+
+		g, gctx := errgroup.WithContext(ctx)
+		g.Go(func() error {
+			universe, err := s.GetUniverse(gctx)
+			if err != nil {
+				return err
+			}
+			u.Cookbooks = cookbookDependents(universe, name)
+			return nil
+		})
+		g.Go(func() error {
+			rows, err := s.partialSearch(gctx, IndexRole, "*:*", map[string]interface{}{
+				"name":          []string{"name"},
+				"run_list":      []string{"run_list"},
+				"env_run_lists": []string{"env_run_lists"},
+			})
+			if err != nil {
+				return err
+			}
+			for _, row := range rows {
+				var r usageRole
+				_ = json.Unmarshal(row, &r)
+				u.Roles = append(u.Roles, runListReferences(r.Name, "", r.RunList, name)...)
+				envs := make([]string, 0, len(r.EnvRunLists))
+				for env := range r.EnvRunLists {
+					envs = append(envs, env)
+				}
+				sort.Strings(envs)
+				for _, env := range envs {
+					u.Roles = append(u.Roles, runListReferences(r.Name, env, r.EnvRunLists[env], name)...)
+				}
+			}
+			sortReferences(u.Roles)
+			return nil
+		})
+		g.Go(func() error {
+			rows, err := s.partialSearch(gctx, IndexEnvironment, "*:*", map[string]interface{}{
+				"name":              []string{"name"},
+				"cookbook_versions": []string{"cookbook_versions"},
+			})
+			if err != nil {
+				return err
+			}
+			for _, row := range rows {
+				var e usageEnvironment
+				_ = json.Unmarshal(row, &e)
+				if c, ok := e.CookbookVersions[name]; ok {
+					u.Environments = append(u.Environments, EnvironmentConstraint{Name: e.Name, Constraint: c})
+				}
+			}
+			sort.Slice(u.Environments, func(i, j int) bool {
+				return u.Environments[i].Name < u.Environments[j].Name
+			})
+			return nil
+		})
+		g.Go(func() (err error) {
+			u.Policies, err = s.policyLocks(gctx, name)
+			return err
+		})
+		g.Go(func() error {
+			rows, err := s.partialSearch(gctx, IndexNode, "*:*", map[string]interface{}{
+				"name":     []string{"name"},
+				"run_list": []string{"run_list"},
+			})
+			if err != nil {
+				return err
+			}
+			for _, row := range rows {
+				var n usageNode
+				_ = json.Unmarshal(row, &n)
+				u.Nodes = append(u.Nodes, runListReferences(n.Name, "", n.RunList, name)...)
+			}
+			sortReferences(u.Nodes)
+			return nil
+		})
+
+		if err := g.Wait(); err != nil {
+			return nil, err
+		}
+		return u, nil
+	})
+}
+
+// policyLocks returns the policy revisions assigned to policy groups that lock the named cookbook
+func (s Service) policyLocks(ctx context.Context, name string) ([]PolicyLock, error) {
+	groups, err := s.GetPolicyGroups(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var assigned []PolicyLock
+	for group, pg := range groups {
+		for policy, rev := range pg.Policies {
+			assigned = append(assigned, PolicyLock{Policy: policy, Group: group, Revision: rev["revision_id"]})
+		}
+	}
+
+	g, gctx := errgroup.WithContext(ctx)
+	g.SetLimit(maxConcurrentRevisions)
+	for i := range assigned {
+		l := &assigned[i]
+		g.Go(func() error {
+			revision, err := s.GetPolicyRevision(gctx, l.Policy, l.Revision)
+			if errors.Is(err, ErrNotFound) {
+				return nil
+			}
+			if err != nil {
+				return err
+			}
+			l.Version = revision.CookbookLocks[name].Version
+			return nil
+		})
+	}
+	if err := g.Wait(); err != nil {
+		return nil, err
+	}
+
+	locks := []PolicyLock{}
+	for _, l := range assigned {
+		if l.Version != "" {
+			locks = append(locks, l)
+		}
+	}
+	sort.Slice(locks, func(i, j int) bool {
+		if locks[i].Policy != locks[j].Policy {
+			return locks[i].Policy < locks[j].Policy
+		}
+		return locks[i].Group < locks[j].Group
+	})
+	return locks, nil
+}
+
+// cookbookDependents returns every cookbook version in the universe that depends on the named cookbook
+func cookbookDependents(universe Universe, name string) []CookbookDependent {
+	dependents := []CookbookDependent{}
+	for cookbook := range universe {
+		for _, version := range universe.Versions(cookbook) {
+			if c, ok := universe.Dependencies(cookbook, version)[name]; ok {
+				dependents = append(dependents, CookbookDependent{Name: cookbook, Version: version, Constraint: c})
+			}
+		}
+	}
+	// versions are already ordered newest first, so only sort by name
+	sort.SliceStable(dependents, func(i, j int) bool {
+		return dependents[i].Name < dependents[j].Name
+	})
+	return dependents
+}
+
+// runListReferences returns the entries of a run list that refer to the named cookbook
+func runListReferences(owner string, env string, runList []string, cookbook string) []RunListReference {
+	var refs []RunListReference
+	for _, item := range runList {
+		if c, ok := RunListCookbook(item); ok && c == cookbook {
+			refs = append(refs, RunListReference{Name: owner, Item: item, Environment: env})
+		}
+	}
+	return refs
+}
+
+func sortReferences(refs []RunListReference) {
+	sort.SliceStable(refs, func(i, j int) bool {
+		return refs[i].Name < refs[j].Name
+	})
+}
+
+// RunListCookbook returns the cookbook of a recipe run list entry, e.g. "nginx" for "recipe[nginx::server@1.0.0]"
+// or "nginx::server". It returns false for roles.
+func RunListCookbook(item string) (string, bool) {
+	p := ParseRunListItem(item)
+	if p.Type != RunListRecipe {
+		return "", false
+	}
+	return p.Name, p.Name != ""
+}
@@ -0,0 +1,191 @@
+package chef
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/drewhammond/chefbrowser/config"
+)
+
+func testUniverse() Universe {
+	return Universe{
+		"web": {
+			"1.0.0": {Dependencies: map[string]string{"nginx": "~> 2.0", "base": ">= 0.0.0"}},
+			"2.0.0": {Dependencies: map[string]string{"nginx": ">= 3.0", "base": ">= 0.0.0", "missing": ">= 1.0"}},
+		},
+		"nginx": {
+			"2.0.0": {Dependencies: map[string]string{"base": "< 2.0"}},
+			"2.5.0": {Dependencies: map[string]string{"base": "< 2.0"}},
+			"3.0.0": {Dependencies: map[string]string{"base": "< 2.0"}},
+		},
+		"base": {
+			"1.0.0": {Dependencies: map[string]string{"loop": ">= 0.0.0"}},
+			"2.0.0": {},
+		},
+		"loop": {
+			"1.0.0": {Dependencies: map[string]string{"base": "= 1.0.0"}},
+		},
+	}
+}
+
+func TestResolveDependencies(t *testing.T) {
+	got := resolveDependencies(testUniverse(), "web", "1.0.0")
+
+	// constraints are resolved independently, so base appears in two versions
+	expected := &DependencyNode{Name: "web", Version: "1.0.0", Resolution: DependencyResolution, Dependencies: []*DependencyNode{
+		{Name: "base", Constraint: ">= 0.0.0", Version: "2.0.0"},
+		{Name: "nginx", Constraint: "~> 2.0", Version: "2.5.0", Dependencies: []*DependencyNode{
+			{Name: "base", Constraint: "< 2.0", Version: "1.0.0", Dependencies: []*DependencyNode{
+				{Name: "loop", Constraint: ">= 0.0.0", Version: "1.0.0", Dependencies: []*DependencyNode{
+					{Name: "base", Constraint: "= 1.0.0", Version: "1.0.0", Cycle: true},
+				}},
+			}},
+		}},
+	}}
+	if !reflect.DeepEqual(got, expected) {
+		g, _ := json.MarshalIndent(got, "", "  ")
+		t.Errorf("resolveDependencies() = %s", g)
+	}
+}
+
+func TestResolveDependenciesUnresolvedAndDeduped(t *testing.T) {
+	u := testUniverse()
+	u["app"] = map[string]universeVersion{"1.0.0": {Dependencies: map[string]string{"nginx": "= 2.0.0", "web": "= 1.0.0"}}}
+
+	got := resolveDependencies(u, "app", "1.0.0")
+	if len(got.Dependencies) != 2 {
+		t.Fatalf("unexpected dependencies: %+v", got.Dependencies)
+	}
+	// web 1.0.0 pulls in nginx 2.5.0, which was not expanded yet
+	if web := got.Dependencies[1]; web.Deduped || len(web.Dependencies) != 2 || web.Dependencies[1].Deduped {
+		t.Errorf("unexpected web dependencies: %+v", web)
+	}
+
+	missing := resolveDependencies(u, "web", "2.0.0").Dependencies[1]
+	if missing.Name != "missing" || !missing.Unresolved || missing.Version != "" {
+		t.Errorf("expected an unresolved dependency, actual: %+v", missing)
+	}
+
+	twice := resolveDependencies(Universe{
+		"a": {"1.0.0": {Dependencies: map[string]string{"b": ">= 0.0.0", "c": ">= 0.0.0"}}},
+		"b": {"1.0.0": {Dependencies: map[string]string{"c": ">= 0.0.0"}}},
+		"c": {"1.0.0": {Dependencies: map[string]string{"d": ">= 0.0.0"}}},
+		"d": {"1.0.0": {}},
+	}, "a", "1.0.0")
+	if c := twice.Dependencies[1]; c.Name != "c" || !c.Deduped || c.Dependencies != nil {
+		t.Errorf("expected the second occurrence of c to be deduped, actual: %+v", c)
+	}
+}
+
+func TestDependencyNodeWriteDOT(t *testing.T) {
+	tree := resolveDependencies(testUniverse(), "web", "2.0.0")
+
+	var b strings.Builder
+	if err := tree.WriteDOT(&b); err != nil {
+		t.Fatal(err)
+	}
+	for _, line := range []string{
+		`label="web 2.0.0 dependencies, newest matching version per constraint";`,
+		`"web 2.0.0" [shape=box];`,
+		`"web 2.0.0" -> "nginx 3.0.0" [label=">= 3.0"];`,
+		`"missing" [color=red, fontcolor=red];`,
+		`"web 2.0.0" -> "missing" [label=">= 1.0"];`,
+		`"base 1.0.0" -> "loop 1.0.0" [label=">= 0.0.0"];`,
+	} {
+		if !strings.Contains(b.String(), line) {
+			t.Errorf("expected DOT output to contain %s, actual:\n%s", line, b.String())
+		}
+	}
+}
+
+func TestCookbookDependents(t *testing.T) {
+	expected := []CookbookDependent{
+		{Name: "loop", Version: "1.0.0", Constraint: "= 1.0.0"},
+		{Name: "nginx", Version: "3.0.0", Constraint: "< 2.0"},
+		{Name: "nginx", Version: "2.5.0", Constraint: "< 2.0"},
+		{Name: "nginx", Version: "2.0.0", Constraint: "< 2.0"},
+		{Name: "web", Version: "2.0.0", Constraint: ">= 0.0.0"},
+		{Name: "web", Version: "1.0.0", Constraint: ">= 0.0.0"},
+	}
+	if got := cookbookDependents(testUniverse(), "base"); !reflect.DeepEqual(got, expected) {
+		t.Errorf("cookbookDependents() = %v, want %v", got, expected)
+	}
+}
+
+func TestRunListCookbook(t *testing.T) {
+	tests := map[string]string{
+		"recipe[nginx]":               "nginx",
+		"recipe[nginx::server]":       "nginx",
+		"recipe[nginx::server@1.0.0]": "nginx",
+		"recipe[nginx@1.0.0]":         "nginx",
+		"nginx::server":               "nginx",
+		"role[web]":                   "",
+	}
+	for item, expected := range tests {
+		got, ok := RunListCookbook(item)
+		if got != expected || ok != (expected != "") {
+			t.Errorf("RunListCookbook(%q) = %q, %v", item, got, ok)
+		}
+	}
+}
+
+func TestGetCookbookUsage(t *testing.T) {
+	searches := map[string]string{
+		"/search/role": `{"total": 2, "rows": [
+			{"data": {"name": "web", "run_list": ["recipe[nginx::server]", "role[base]"], "env_run_lists": {"prod": ["recipe[nginx]"]}}},
+			{"data": {"name": "db", "run_list": ["recipe[postgresql]"]}}]}`,
+		"/search/environment": `{"total": 2, "rows": [
+			{"data": {"name": "prod", "cookbook_versions": {"nginx": "= 2.5.0"}}},
+			{"data": {"name": "dev", "cookbook_versions": {}}}]}`,
+		"/search/node": `{"total": 2, "rows": [
+			{"data": {"name": "web-1", "run_list": ["recipe[nginx@2.5.0]"]}},
+			{"data": {"name": "db-1", "run_list": ["role[db]"]}}]}`,
+	}
+	responses := map[string]string{
+		"/universe":                   `{"nginx": {"2.5.0": {}}, "web": {"1.0.0": {"dependencies": {"nginx": "~> 2.0"}}}}`,
+		"/policy_groups":              `{"prod": {"policies": {"app": {"revision_id": "111"}, "gone": {"revision_id": "404"}}}}`,
+		"/policies/app/revisions/111": `{"cookbook_locks": {"nginx": {"version": "2.5.0"}}}`,
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if body, ok := searches[r.URL.Path]; ok {
+			_, _ = io.ReadAll(r.Body)
+			_, _ = w.Write([]byte(body))
+			return
+		}
+		body, ok := responses[r.URL.Path]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		_, _ = w.Write([]byte(body))
+	}))
+	defer srv.Close()
+
+	s := newTestService(t, srv.URL, &config.Config{})
+	got, err := s.GetCookbookUsage(context.Background(), "nginx")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := &CookbookUsage{
+		Cookbook:  "nginx",
+		Cookbooks: []CookbookDependent{{Name: "web", Version: "1.0.0", Constraint: "~> 2.0"}},
+		Roles: []RunListReference{
+			{Name: "web", Item: "recipe[nginx::server]"},
+			{Name: "web", Item: "recipe[nginx]", Environment: "prod"},
+		},
+		Environments: []EnvironmentConstraint{{Name: "prod", Constraint: "= 2.5.0"}},
+		Policies:     []PolicyLock{{Policy: "app", Group: "prod", Revision: "111", Version: "2.5.0"}},
+		Nodes:        []RunListReference{{Name: "web-1", Item: "recipe[nginx@2.5.0]"}},
+	}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("GetCookbookUsage() = %+v, want %+v", got, expected)
+	}
+}
@@ -0,0 +1,274 @@
+package chef
+
+import (
+	"bytes"
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"strings"
+
+	"github.com/drewhammond/chefbrowser/config"
+	"github.com/go-chef/chef"
+	"go.uber.org/zap"
+)
+
+var ErrDecryptionFailed = errors.New("failed to decrypt data bag item")
+
+// DatabagSecret decrypts the encrypted items of the data bags whose name matches Pattern (see path.Match) for users
+// in one of Groups. A secret without groups decrypts items for no one.
+type DatabagSecret struct {
+	Pattern string
+	Secret  []byte
+	Groups  []string
+}
+
+// ItemEncryption describes how a data bag item is encrypted
+type ItemEncryption struct {
+	// Version is the format version of chef encrypted data bag items (1-3)
+	Version int `json:"version"`
+	// Decrypted is true if the item holds the decrypted values
+	Decrypted bool `json:"decrypted"`
+	// Reason explains why the item was not decrypted
+	Reason string `json:"reason,omitempty"`
+}
+
+// Reasons for not decrypting an encrypted data bag item
+const (
+	ReasonNoSecret = "no secret is configured for this data bag"
+	ReasonDenied   = "you are not allowed to view the decrypted item"
+	ReasonFailed   = "the configured secret could not decrypt the item"
+)
+
+// DatabagItem is the content of a data bag item along with its encryption, which is nil for plain items
+type DatabagItem struct {
+	Content    chef.DataBagItem `json:"content"`
+	Encryption *ItemEncryption  `json:"encryption"`
+}
+
+// databagSecretsFromConfig reads the secret files of the [encrypted_data_bags] sections, ordered by their number.
+// Like chef, surrounding whitespace is stripped from secrets.
+func databagSecretsFromConfig(cfg *config.Config) ([]DatabagSecret, error) {
+	var secrets []DatabagSecret
+	for _, key := range sortedKeys(cfg.EncryptedDataBags) {
+		e := cfg.EncryptedDataBags[key]
+		pattern := strings.TrimSpace(e.Pattern)
+		if _, err := path.Match(pattern, ""); err != nil || pattern == "" {
+			return nil, fmt.Errorf("encrypted data bag has invalid pattern %q", pattern)
+		}
+		secret, err := os.ReadFile(strings.TrimSpace(e.SecretFile))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read secret of encrypted data bags %q: %w", pattern, err)
+		}
+		secret = bytes.TrimSpace(secret)
+		if len(secret) == 0 {
+			return nil, fmt.Errorf("secret file %s of encrypted data bags %q is empty", e.SecretFile, pattern)
+		}
+		secrets = append(secrets, DatabagSecret{Pattern: pattern, Secret: secret, Groups: trimList(e.Groups)})
+	}
+	return secrets, nil
+}
+
+// databagSecret returns the first secret whose pattern matches a data bag, or nil if there is none
+func (s Service) databagSecret(databag string) *DatabagSecret {
+	for i, secret := range s.secrets {
+		if ok, _ := path.Match(secret.Pattern, databag); ok {
+			return &s.secrets[i]
+		}
+	}
+	return nil
+}
+
+// decryptItem decrypts an encrypted data bag item if the viewer of ctx is allowed to see its decrypted values.
+// Plain items are returned as is, with a nil ItemEncryption.
+func (s Service) decryptItem(ctx context.Context, databag string, content chef.DataBagItem) (chef.DataBagItem, *ItemEncryption) {
+	item, ok := content.(map[string]interface{})
+	if !ok {
+		return content, nil
+	}
+	version := EncryptionVersion(item)
+	if version == 0 {
+		return content, nil
+	}
+
+	encryption := &ItemEncryption{Version: version}
+	secret := s.databagSecret(databag)
+	switch {
+	case secret == nil:
+		encryption.Reason = ReasonNoSecret
+	case !memberOf(viewerGroups(ctx), secret.Groups):
+		encryption.Reason = ReasonDenied
+	default:
+		decrypted, err := DecryptItem(item, secret.Secret)
+		if err != nil {
+			if s.log != nil {
+				s.log.Warn("failed to decrypt data bag item", zap.String("databag", databag), zap.Error(err))
+			}
+			encryption.Reason = ReasonFailed
+			break
+		}
+		encryption.Decrypted = true
+		return decrypted, encryption
+	}
+	return content, encryption
+}
+
+// EncryptionVersion returns the format version of an encrypted data bag item, or 0 if the item is not encrypted.
+// Every value of an encrypted item but its id holds the encrypted data and how it was encrypted.
+func EncryptionVersion(item map[string]interface{}) int {
+	version := 0
+	for key, v := range item {
+		if key == "id" {
+			continue
+		}
+		value, ok := v.(map[string]interface{})
+		if !ok {
+			return 0
+		}
+		if _, ok = value["encrypted_data"].(string); !ok {
+			return 0
+		}
+		n, ok := value["version"].(float64)
+		if !ok {
+			return 0
+		}
+		version = int(n)
+	}
+	return version
+}
+
+// DecryptItem returns a copy of an encrypted data bag item with every value decrypted using the secret shared with
+// the chef clients. Items in format versions 1, 2 and 3 can be decrypted.
+func DecryptItem(item map[string]interface{}, secret []byte) (map[string]interface{}, error) {
+	decrypted := make(map[string]interface{}, len(item))
+	for key, v := range item {
+		if key == "id" {
+			decrypted[key] = v
+			continue
+		}
+		value, ok := v.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("%w: %s is not encrypted", ErrDecryptionFailed, key)
+		}
+		plain, err := decryptValue(value, secret)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s: %w", ErrDecryptionFailed, key, err)
+		}
+		decrypted[key] = plain
+	}
+	return decrypted, nil
+}
+
+// decryptValue decrypts a single value of an encrypted data bag item. Chef wraps every value in a JSON object before
+// encrypting it so that values which are not valid JSON documents on their own (e.g. strings) can be encrypted too.
+func decryptValue(value map[string]interface{}, secret []byte) (interface{}, error) {
+	version, _ := value["version"].(float64)
+	data, _ := value["encrypted_data"].(string)
+	ciphertext, err := decodeBase64(data)
+	if err != nil {
+		return nil, fmt.Errorf("invalid encrypted_data: %w", err)
+	}
+	iv, err := decodeBase64(stringField(value, "iv"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid iv: %w", err)
+	}
+	key := sha256.Sum256(secret)
+
+	var plaintext []byte
+	switch int(version) {
+	case 1, 2:
+		if c := stringField(value, "cipher"); c != "aes-256-cbc" {
+			return nil, fmt.Errorf("unsupported cipher %q", c)
+		}
+		if version == 2 {
+			// the HMAC is computed over the base64 encoded data using the secret itself as key
+			expected, err := decodeBase64(stringField(value, "hmac"))
+			if err != nil {
+				return nil, fmt.Errorf("invalid hmac: %w", err)
+			}
+			mac := hmac.New(sha256.New, secret)
+			mac.Write([]byte(data))
+			if !hmac.Equal(mac.Sum(nil), expected) {
+				return nil, errors.New("hmac does not match, the secret is probably wrong")
+			}
+		}
+		plaintext, err = decryptCBC(key[:], iv, ciphertext)
+	case 3:
+		if c := stringField(value, "cipher"); c != "aes-256-gcm" {
+			return nil, fmt.Errorf("unsupported cipher %q", c)
+		}
+		var tag []byte
+		if tag, err = decodeBase64(stringField(value, "auth_tag")); err != nil {
+			return nil, fmt.Errorf("invalid auth_tag: %w", err)
+		}
+		plaintext, err = decryptGCM(key[:], iv, append(ciphertext, tag...))
+	default:
+		return nil, fmt.Errorf("unsupported format version %v", value["version"])
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var wrapper struct {
+		Value interface{} `json:"json_wrapper"`
+	}
+	if err = json.Unmarshal(plaintext, &wrapper); err != nil {
+		return nil, errors.New("decrypted data is not valid JSON, the secret is probably wrong")
+	}
+	return wrapper.Value, nil
+}
+
+func decryptCBC(key []byte, iv []byte, ciphertext []byte) ([]byte, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	if len(iv) != block.BlockSize() || len(ciphertext) == 0 || len(ciphertext)%block.BlockSize() != 0 {
+		return nil, errors.New("invalid iv or encrypted_data length")
+	}
+	plaintext := make([]byte, len(ciphertext))
+	cipher.NewCBCDecrypter(block, iv).CryptBlocks(plaintext, ciphertext)
+
+	// remove the PKCS#7 padding
+	n := int(plaintext[len(plaintext)-1])
+	if n == 0 || n > block.BlockSize() || !bytes.Equal(plaintext[len(plaintext)-n:], bytes.Repeat([]byte{byte(n)}, n)) {
+		return nil, errors.New("invalid padding, the secret is probably wrong")
+	}
+	return plaintext[:len(plaintext)-n], nil
+}
+
+func decryptGCM(key []byte, iv []byte, ciphertext []byte) ([]byte, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	if len(iv) == 0 {
+		return nil, errors.New("invalid iv length")
+	}
+	gcm, err := cipher.NewGCMWithNonceSize(block, len(iv))
+	if err != nil {
+		return nil, err
+	}
+	plaintext, err := gcm.Open(nil, iv, ciphertext, nil)
+	if err != nil {
+		return nil, errors.New("authentication failed, the secret is probably wrong")
+	}
+	return plaintext, nil
+}
+
+// decodeBase64 decodes base64 as written by ruby, which breaks lines every 60 characters
+func decodeBase64(s string) ([]byte, error) {
+	return base64.StdEncoding.DecodeString(strings.Join(strings.Fields(s), ""))
+}
+
+func stringField(value map[string]interface{}, key string) string {
+	s, _ := value[key].(string)
+	return s
+}
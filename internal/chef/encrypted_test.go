@@ -0,0 +1,212 @@
+package chef
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/drewhammond/chefbrowser/config"
+)
+
+// encryptValue encrypts a value like chef's EncryptedDataBagItem::Encryptor
+func encryptValue(t *testing.T, v interface{}, secret string, version int) map[string]interface{} {
+	t.Helper()
+
+	plaintext, err := json.Marshal(map[string]interface{}{"json_wrapper": v})
+	if err != nil {
+		t.Fatal(err)
+	}
+	key := sha256.Sum256([]byte(secret))
+	block, err := aes.NewCipher(key[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if version == 3 {
+		iv := []byte("0123456789ab")
+		gcm, _ := cipher.NewGCM(block)
+		sealed := gcm.Seal(nil, iv, plaintext, nil)
+		return map[string]interface{}{
+			"encrypted_data": base64.StdEncoding.EncodeToString(sealed[:len(sealed)-gcm.Overhead()]),
+			"auth_tag":       base64.StdEncoding.EncodeToString(sealed[len(sealed)-gcm.Overhead():]),
+			"iv":             base64.StdEncoding.EncodeToString(iv),
+			"version":        3.0,
+			"cipher":         "aes-256-gcm",
+		}
+	}
+
+	iv := []byte("0123456789abcdef")
+	n := aes.BlockSize - len(plaintext)%aes.BlockSize
+	for i := 0; i < n; i++ {
+		plaintext = append(plaintext, byte(n))
+	}
+	ciphertext := make([]byte, len(plaintext))
+	cipher.NewCBCEncrypter(block, iv).CryptBlocks(ciphertext, plaintext)
+
+	// ruby breaks base64 lines every 60 characters
+	data := base64.StdEncoding.EncodeToString(ciphertext)
+	var lines []string
+	for len(data) > 60 {
+		lines, data = append(lines, data[:60]), data[60:]
+	}
+	data = strings.Join(append(lines, data), "\n") + "\n"
+
+	value := map[string]interface{}{
+		"encrypted_data": data,
+		"iv":             base64.StdEncoding.EncodeToString(iv) + "\n",
+		"version":        float64(version),
+		"cipher":         "aes-256-cbc",
+	}
+	if version == 2 {
+		mac := hmac.New(sha256.New, []byte(secret))
+		mac.Write([]byte(data))
+		value["hmac"] = base64.StdEncoding.EncodeToString(mac.Sum(nil)) + "\n"
+	}
+	return value
+}
+
+func encryptItem(t *testing.T, item map[string]interface{}, secret string, version int) map[string]interface{} {
+	t.Helper()
+
+	encrypted := map[string]interface{}{}
+	for key, v := range item {
+		if key == "id" {
+			encrypted[key] = v
+			continue
+		}
+		encrypted[key] = encryptValue(t, v, secret, version)
+	}
+	return encrypted
+}
+
+func TestDecryptItem(t *testing.T) {
+	item := map[string]interface{}{
+		"id":       "db",
+		"password": strings.Repeat("hunter2", 20),
+		"port":     5432.0,
+		"hosts":    []interface{}{"db1", "db2"},
+		"tls":      map[string]interface{}{"enabled": true},
+	}
+
+	for _, version := range []int{1, 2, 3} {
+		encrypted := encryptItem(t, item, "s3cret", version)
+		if v := EncryptionVersion(encrypted); v != version {
+			t.Errorf("EncryptionVersion() = %d, want %d", v, version)
+		}
+
+		decrypted, err := DecryptItem(encrypted, []byte("s3cret"))
+		if err != nil {
+			t.Fatalf("version %d: %v", version, err)
+		}
+		if !reflect.DeepEqual(decrypted, item) {
+			t.Errorf("version %d: expected %v, actual: %v", version, item, decrypted)
+		}
+
+		if _, err = DecryptItem(encrypted, []byte("wrong")); !errors.Is(err, ErrDecryptionFailed) {
+			t.Errorf("version %d: expected ErrDecryptionFailed with the wrong secret, actual: %v", version, err)
+		}
+	}
+}
+
+func TestDecryptItemTampered(t *testing.T) {
+	for _, version := range []int{2, 3} {
+		encrypted := encryptItem(t, map[string]interface{}{"id": "db", "password": "hunter2"}, "s3cret", version)
+		value := encrypted["password"].(map[string]interface{})
+		data, _ := decodeBase64(value["encrypted_data"].(string))
+		data[0] ^= 1
+		value["encrypted_data"] = base64.StdEncoding.EncodeToString(data)
+
+		if _, err := DecryptItem(encrypted, []byte("s3cret")); !errors.Is(err, ErrDecryptionFailed) {
+			t.Errorf("version %d: expected ErrDecryptionFailed, actual: %v", version, err)
+		}
+	}
+}
+
+func TestEncryptionVersionPlain(t *testing.T) {
+	tests := []map[string]interface{}{
+		{"id": "alice"},
+		{"id": "alice", "uid": 1000.0},
+		{"id": "alice", "password": map[string]interface{}{"encrypted_data": "x"}},
+		{"id": "alice", "password": map[string]interface{}{"version": 1.0}},
+	}
+	for _, item := range tests {
+		if v := EncryptionVersion(item); v != 0 {
+			t.Errorf("EncryptionVersion(%v) = %d, want 0", item, v)
+		}
+	}
+}
+
+func TestGetDatabagItemDecrypts(t *testing.T) {
+	encrypted := encryptItem(t, map[string]interface{}{"id": "db", "password": "hunter2", "port": 5432.0}, "s3cret", 3)
+	body, _ := json.Marshal(encrypted)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write(body)
+	}))
+	defer srv.Close()
+
+	cfg := &config.Config{}
+	cfg.Cache.Enabled = true
+	s := newTestService(t, srv.URL, cfg)
+	s.secrets = []DatabagSecret{
+		{Pattern: "secrets-*", Secret: []byte("s3cret"), Groups: []string{"sre"}},
+		{Pattern: "broken", Secret: []byte("wrong"), Groups: []string{"sre"}},
+		{Pattern: "no-groups", Secret: []byte("s3cret")},
+	}
+	s.policy, _ = NewPolicy(nil, []AttributeRedaction{{Keys: []string{"password"}, Groups: []string{"sre"}}})
+	anonymous := context.Background()
+	sre := WithViewer(context.Background(), []string{"sre"})
+
+	tests := []struct {
+		ctx        context.Context
+		databag    string
+		encryption ItemEncryption
+		port       interface{}
+	}{
+		{sre, "secrets-prod", ItemEncryption{Version: 3, Decrypted: true}, 5432.0},
+		{anonymous, "secrets-prod", ItemEncryption{Version: 3, Reason: ReasonDenied}, encrypted["port"]},
+		{sre, "other", ItemEncryption{Version: 3, Reason: ReasonNoSecret}, encrypted["port"]},
+		{sre, "broken", ItemEncryption{Version: 3, Reason: ReasonFailed}, encrypted["port"]},
+		{sre, "no-groups", ItemEncryption{Version: 3, Reason: ReasonDenied}, encrypted["port"]},
+		{anonymous, "no-groups", ItemEncryption{Version: 3, Reason: ReasonDenied}, encrypted["port"]},
+	}
+	for _, tt := range tests {
+		item, err := s.GetDatabagItem(tt.ctx, tt.databag, "db")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if item.Encryption == nil || *item.Encryption != tt.encryption {
+			t.Errorf("%s: expected encryption %+v, actual: %+v", tt.databag, tt.encryption, item.Encryption)
+		}
+		if port := item.Content.(map[string]interface{})["port"]; !reflect.DeepEqual(port, tt.port) {
+			t.Errorf("%s: expected port %v, actual: %v", tt.databag, tt.port, port)
+		}
+	}
+
+	// decrypted values are redacted like any other value, and the cached item stays encrypted
+	s.policy, _ = NewPolicy(nil, []AttributeRedaction{{Keys: []string{"password"}}})
+	item, err := s.GetDatabagItem(sre, "secrets-prod", "db")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v := item.Content.(map[string]interface{})["password"]; v != RedactedValue {
+		t.Errorf("expected the decrypted password to be redacted, actual: %v", v)
+	}
+	item, err = s.GetDatabagItem(sre, "other", "db")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if port, ok := item.Content.(map[string]interface{})["port"].(map[string]interface{}); !ok || port["version"] != 3.0 {
+		t.Errorf("expected the cached item to remain encrypted, actual: %v", item.Content)
+	}
+}
@@ -0,0 +1,202 @@
+package chef
+
+import (
+	"context"
+	"sort"
+
+	"github.com/go-chef/chef"
+	"golang.org/x/mod/semver"
+	"golang.org/x/sync/errgroup"
+)
+
+// How far a selected cookbook version lags behind the latest version, by the most significant version component
+// that differs
+const (
+	LagNone  = ""
+	LagPatch = "patch"
+	LagMinor = "minor"
+	LagMajor = "major"
+)
+
+// CookbookResolution is an environment cookbook constraint resolved against the cookbook versions on the server
+type CookbookResolution struct {
+	Cookbook string `json:"cookbook"`
+	// Constraint is empty if the environment does not constrain the cookbook, in which case the latest version is
+	// selected
+	Constraint string `json:"constraint"`
+	// Version is the newest version that satisfies the constraint, or empty if none do
+	Version string `json:"version,omitempty"`
+	Latest  string `json:"latest,omitempty"`
+	// Behind is the number of versions on the server that are newer than the selected version
+	Behind int    `json:"behind"`
+	Lag    string `json:"lag,omitempty"`
+	// Unsatisfied is true if no version on the server satisfies the constraint
+	Unsatisfied bool `json:"unsatisfied,omitempty"`
+	// Invalid is true if the constraint could not be parsed
+	Invalid bool `json:"invalid,omitempty"`
+}
+
+// EnvironmentCookbooks holds the resolved cookbook constraints of an environment
+type EnvironmentCookbooks struct {
+	Environment string               `json:"environment"`
+	Cookbooks   []CookbookResolution `json:"cookbooks"`
+}
+
+// EnvironmentCookbookDiff compares how two environments resolve a single cookbook
+type EnvironmentCookbookDiff struct {
+	Cookbook string `json:"cookbook"`
+	// Kind is empty if both environments select the same version with the same constraint
+	Kind string             `json:"kind,omitempty"`
+	A    CookbookResolution `json:"a"`
+	B    CookbookResolution `json:"b"`
+}
+
+// EnvironmentComparison compares the cookbook constraints of two environments
+type EnvironmentComparison struct {
+	A         string                    `json:"a"`
+	B         string                    `json:"b"`
+	Cookbooks []EnvironmentCookbookDiff `json:"cookbooks"`
+}
+
+// ResolveEnvironmentCookbooks resolves every cookbook constraint of an environment against the cookbook versions on
+// the server
+func (s Service) ResolveEnvironmentCookbooks(ctx context.Context, name string) (*EnvironmentCookbooks, error) {
+	var env *chef.Environment
+	var cookbooks *CookbookListResult
+
+	g, gctx := errgroup.WithContext(ctx)
+	g.Go(func() (err error) {
+		env, err = s.GetEnvironment(gctx, name)
+		return err
+	})
+	g.Go(func() (err error) {
+		cookbooks, err = s.GetCookbooks(gctx)
+		return err
+	})
+	if err := g.Wait(); err != nil {
+		return nil, err
+	}
+
+	versions := cookbookVersions(cookbooks)
+	result := &EnvironmentCookbooks{Environment: env.Name, Cookbooks: []CookbookResolution{}}
+	for cookbook, constraint := range env.CookbookVersions {
+		result.Cookbooks = append(result.Cookbooks, resolveCookbookConstraint(cookbook, constraint, versions[cookbook]))
+	}
+	sort.Slice(result.Cookbooks, func(i, j int) bool {
+		return result.Cookbooks[i].Cookbook < result.Cookbooks[j].Cookbook
+	})
+
+	return result, nil
+}
+
+// CompareEnvironmentCookbooks compares the cookbook versions selected by two environments. Every cookbook
+// constrained by either environment is listed; the environment without a constraint selects the latest version.
+func (s Service) CompareEnvironmentCookbooks(ctx context.Context, a string, b string) (*EnvironmentComparison, error) {
+	var envA, envB *chef.Environment
+	var cookbooks *CookbookListResult
+
+	g, gctx := errgroup.WithContext(ctx)
+	g.Go(func() (err error) {
+		envA, err = s.GetEnvironment(gctx, a)
+		return err
+	})
+	g.Go(func() (err error) {
+		envB, err = s.GetEnvironment(gctx, b)
+		return err
+	})
+	g.Go(func() (err error) {
+		cookbooks, err = s.GetCookbooks(gctx)
+		return err
+	})
+	if err := g.Wait(); err != nil {
+		return nil, err
+	}
+
+	return compareEnvironmentCookbooks(envA, envB, cookbookVersions(cookbooks)), nil
+}
+
+func compareEnvironmentCookbooks(a *chef.Environment, b *chef.Environment, versions map[string][]string) *EnvironmentComparison {
+	names := map[string]bool{}
+	for cookbook := range a.CookbookVersions {
+		names[cookbook] = true
+	}
+	for cookbook := range b.CookbookVersions {
+		names[cookbook] = true
+	}
+
+	comparison := &EnvironmentComparison{A: a.Name, B: b.Name, Cookbooks: []EnvironmentCookbookDiff{}}
+	for cookbook := range names {
+		constraintA, okA := a.CookbookVersions[cookbook]
+		constraintB, okB := b.CookbookVersions[cookbook]
+
+		diff := EnvironmentCookbookDiff{
+			Cookbook: cookbook,
+			A:        resolveCookbookConstraint(cookbook, constraintA, versions[cookbook]),
+			B:        resolveCookbookConstraint(cookbook, constraintB, versions[cookbook]),
+		}
+		switch {
+		case !okA:
+			diff.Kind = DiffAdded
+		case !okB:
+			diff.Kind = DiffRemoved
+		case constraintA != constraintB || diff.A.Version != diff.B.Version:
+			diff.Kind = DiffChanged
+		}
+		comparison.Cookbooks = append(comparison.Cookbooks, diff)
+	}
+	sort.Slice(comparison.Cookbooks, func(i, j int) bool {
+		return comparison.Cookbooks[i].Cookbook < comparison.Cookbooks[j].Cookbook
+	})
+
+	return comparison
+}
+
+// cookbookVersions returns the versions of every cookbook, newest first, keyed by cookbook name
+func cookbookVersions(cookbooks *CookbookListResult) map[string][]string {
+	versions := make(map[string][]string, len(cookbooks.Cookbooks))
+	for _, cb := range cookbooks.Cookbooks {
+		versions[cb.Name] = cb.Versions
+	}
+	return versions
+}
+
+// resolveCookbookConstraint selects the newest of versions (ordered newest first) that satisfies the constraint
+func resolveCookbookConstraint(cookbook string, constraint string, versions []string) CookbookResolution {
+	r := CookbookResolution{Cookbook: cookbook, Constraint: constraint}
+	if len(versions) > 0 {
+		r.Latest = versions[0]
+	}
+
+	c, err := ParseVersionConstraint(constraint)
+	if err != nil {
+		r.Invalid = true
+		r.Unsatisfied = true
+		return r
+	}
+
+	for i, v := range versions {
+		if c.Matches(v) {
+			r.Version = v
+			r.Behind = i
+			r.Lag = versionLag(v, r.Latest)
+			return r
+		}
+	}
+	r.Unsatisfied = true
+	return r
+}
+
+// versionLag returns the most significant version component in which version is behind latest
+func versionLag(version string, latest string) string {
+	v, l := "v"+version, "v"+latest
+	switch {
+	case semver.Compare(v, l) >= 0:
+		return LagNone
+	case semver.Major(v) != semver.Major(l):
+		return LagMajor
+	case semver.MajorMinor(v) != semver.MajorMinor(l):
+		return LagMinor
+	default:
+		return LagPatch
+	}
+}
@@ -0,0 +1,115 @@
+package chef
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/drewhammond/chefbrowser/config"
+	"github.com/go-chef/chef"
+)
+
+func TestResolveCookbookConstraint(t *testing.T) {
+	versions := []string{"3.0.0", "2.2.0", "2.1.3", "2.1.0", "1.0.0"}
+	tests := []struct {
+		constraint string
+		expected   CookbookResolution
+	}{
+		{"= 3.0.0", CookbookResolution{Version: "3.0.0", Latest: "3.0.0"}},
+		{"", CookbookResolution{Version: "3.0.0", Latest: "3.0.0"}},
+		{"~> 2.1.0", CookbookResolution{Version: "2.1.3", Latest: "3.0.0", Behind: 2, Lag: LagMajor}},
+		{"< 1.0.0", CookbookResolution{Latest: "3.0.0", Unsatisfied: true}},
+		{"~> banana", CookbookResolution{Latest: "3.0.0", Unsatisfied: true, Invalid: true}},
+	}
+	for _, tt := range tests {
+		tt.expected.Cookbook = "nginx"
+		tt.expected.Constraint = tt.constraint
+		if got := resolveCookbookConstraint("nginx", tt.constraint, versions); !reflect.DeepEqual(got, tt.expected) {
+			t.Errorf("resolveCookbookConstraint(%q) = %+v, want %+v", tt.constraint, got, tt.expected)
+		}
+	}
+
+	if got := resolveCookbookConstraint("gone", ">= 1.0", nil); !got.Unsatisfied || got.Latest != "" {
+		t.Errorf("expected a cookbook without versions to be unsatisfied, actual: %+v", got)
+	}
+}
+
+func TestVersionLag(t *testing.T) {
+	tests := []struct {
+		version, latest, expected string
+	}{
+		{"1.2.3", "1.2.3", LagNone},
+		{"1.2.3", "1.2.4", LagPatch},
+		{"1.2.3", "1.3.0", LagMinor},
+		{"1.2.3", "2.0.0", LagMajor},
+	}
+	for _, tt := range tests {
+		if got := versionLag(tt.version, tt.latest); got != tt.expected {
+			t.Errorf("versionLag(%q, %q) = %q, want %q", tt.version, tt.latest, got, tt.expected)
+		}
+	}
+}
+
+func TestCompareEnvironmentCookbooks(t *testing.T) {
+	a := &chef.Environment{Name: "staging", CookbookVersions: map[string]string{"nginx": "~> 2.0", "app": "= 1.1.0", "db": "= 1.0.0"}}
+	b := &chef.Environment{Name: "prod", CookbookVersions: map[string]string{"nginx": "~> 2.0", "app": "= 1.0.0", "ntp": "= 1.0.0"}}
+	versions := map[string][]string{
+		"nginx": {"2.1.0", "2.0.0"},
+		"app":   {"1.1.0", "1.0.0"},
+		"db":    {"1.0.0"},
+		"ntp":   {"2.0.0", "1.0.0"},
+	}
+
+	got := compareEnvironmentCookbooks(a, b, versions)
+	kinds := map[string]string{}
+	for _, c := range got.Cookbooks {
+		kinds[c.Cookbook] = c.Kind
+	}
+	expected := map[string]string{"app": DiffChanged, "db": DiffRemoved, "nginx": "", "ntp": DiffAdded}
+	if !reflect.DeepEqual(kinds, expected) {
+		t.Errorf("compareEnvironmentCookbooks() kinds = %v, want %v", kinds, expected)
+	}
+
+	// staging doesn't constrain ntp, so it selects the latest version
+	if ntp := got.Cookbooks[3]; ntp.A.Version != "2.0.0" || ntp.B.Version != "1.0.0" || ntp.B.Lag != LagMajor {
+		t.Errorf("unexpected ntp resolution: %+v", ntp)
+	}
+}
+
+func TestResolveEnvironmentCookbooks(t *testing.T) {
+	responses := map[string]string{
+		"/environments/prod": `{"name": "prod", "cookbook_versions": {"nginx": "~> 2.0", "gone": ">= 1.0"}}`,
+		"/universe":          `{"nginx": {"2.0.0": {}, "2.1.0": {}, "3.0.0": {}}}`,
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, ok := responses[r.URL.Path]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(body))
+	}))
+	defer srv.Close()
+
+	s := newTestService(t, srv.URL, &config.Config{})
+	got, err := s.ResolveEnvironmentCookbooks(context.Background(), "prod")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := &EnvironmentCookbooks{Environment: "prod", Cookbooks: []CookbookResolution{
+		{Cookbook: "gone", Constraint: ">= 1.0", Unsatisfied: true},
+		{Cookbook: "nginx", Constraint: "~> 2.0", Version: "2.1.0", Latest: "3.0.0", Behind: 1, Lag: LagMajor},
+	}}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("ResolveEnvironmentCookbooks() = %+v, want %+v", got, expected)
+	}
+
+	if _, err := s.ResolveEnvironmentCookbooks(context.Background(), "missing"); !errors.Is(err, ErrEnvironmentNotFound) {
+		t.Errorf("expected ErrEnvironmentNotFound, actual: %v", err)
+	}
+}
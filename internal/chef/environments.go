@@ -3,27 +3,56 @@ package chef
 import (
 	"context"
 	"errors"
+	"fmt"
+	"net/url"
 
 	"github.com/go-chef/chef"
 )
 
-var ErrEnvironmentNotFound = errors.New("environment not found")
+var ErrEnvironmentNotFound = fmt.Errorf("environment %w", ErrNotFound)
 
 func (s Service) GetEnvironments(ctx context.Context) (interface{}, error) {
-	environments, err := s.client.Environments.List()
-	if err != nil {
-		return nil, err
-	}
+	return cached(ctx, s.cache, "environments", s.config.Cache.EnvironmentTTL, func(ctx context.Context) (interface{}, error) {
+		var environments chef.EnvironmentResult
+		err := s.get(ctx, "environments", &environments)
+		if err != nil {
+			return nil, err
+		}
 
-	return environments, nil
+		return environments, nil
+	})
 }
 
+// GetEnvironment returns a single environment without the attributes the viewer of ctx may not see
 func (s Service) GetEnvironment(ctx context.Context, name string) (*chef.Environment, error) {
-	environment, err := s.client.Environments.Get(name)
-	// todo: handle 404s as more graceful errors so we can treat 5xx errors differently
+	environment, err := s.getEnvironment(ctx, name)
 	if err != nil {
-		return &chef.Environment{}, ErrEnvironmentNotFound
+		return environment, err
+	}
+
+	redactor := s.redactorFor(ctx)
+	if redactor.empty() {
+		return environment, nil
 	}
+	// the cached environment is shared, so redact a copy of it
+	e := *environment
+	e.DefaultAttributes = redactor.Redact(e.DefaultAttributes)
+	e.OverrideAttributes = redactor.Redact(e.OverrideAttributes)
+	return &e, nil
+}
+
+// getEnvironment returns an environment with all of its attributes
+func (s Service) getEnvironment(ctx context.Context, name string) (*chef.Environment, error) {
+	return cached(ctx, s.cache, "environment:"+name, s.config.Cache.EnvironmentTTL, func(ctx context.Context) (*chef.Environment, error) {
+		var environment chef.Environment
+		err := s.get(ctx, "environments/"+url.PathEscape(name), &environment)
+		if err != nil {
+			if errors.Is(err, ErrNotFound) {
+				return &chef.Environment{}, ErrEnvironmentNotFound
+			}
+			return &chef.Environment{}, err
+		}
 
-	return environment, nil
+		return &environment, nil
+	})
 }
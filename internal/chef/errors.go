@@ -0,0 +1,87 @@
+package chef
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"github.com/go-chef/chef"
+)
+
+// Errors returned by Service are classified into one of the following kinds. Use errors.Is to check for them;
+// object specific errors such as ErrRoleNotFound wrap the matching kind.
+var (
+	ErrNotFound     = errors.New("not found")
+	ErrForbidden    = errors.New("permission denied by chef server")
+	ErrUnauthorized = errors.New("not authorized by chef server")
+	ErrUnavailable  = errors.New("chef server unavailable")
+	ErrTimeout      = errors.New("timed out waiting for chef server")
+)
+
+// errorForStatus returns the error kind matching an HTTP status code returned by the chef server
+func errorForStatus(code int) error {
+	switch {
+	case code == http.StatusNotFound:
+		return ErrNotFound
+	case code == http.StatusForbidden:
+		return ErrForbidden
+	case code == http.StatusUnauthorized:
+		return ErrUnauthorized
+	case code == http.StatusGatewayTimeout:
+		return ErrTimeout
+	case code >= 500:
+		return ErrUnavailable
+	}
+	return nil
+}
+
+// classifyError wraps err with the kind of failure it represents so that callers can tell a missing
+// object apart from a permission problem or an unreachable chef server. The original error is preserved.
+func classifyError(ctx context.Context, err error) error {
+	if err == nil {
+		return nil
+	}
+
+	var cerr *chef.ErrorResponse
+	if errors.As(err, &cerr) {
+		if kind := errorForStatus(cerr.StatusCode()); kind != nil {
+			return fmt.Errorf("%w: %w", kind, err)
+		}
+		return err
+	}
+
+	// if the caller went away there is nothing wrong with the chef server
+	if ctx.Err() != nil {
+		return err
+	}
+
+	var uerr *url.Error
+	if errors.As(err, &uerr) {
+		if uerr.Timeout() {
+			return fmt.Errorf("%w: %w", ErrTimeout, err)
+		}
+		return fmt.Errorf("%w: %w", ErrUnavailable, err)
+	}
+
+	return err
+}
+
+// StatusCode returns the HTTP status code that best describes err, or fallback if err is not a classified error.
+//
+// Authentication failures between chefbrowser and the chef server are a server side configuration problem, so they
+// are reported as a bad gateway instead of asking the user to authenticate.
+func StatusCode(err error, fallback int) int {
+	switch {
+	case errors.Is(err, ErrNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, ErrForbidden), errors.Is(err, ErrDenied):
+		return http.StatusForbidden
+	case errors.Is(err, ErrTimeout):
+		return http.StatusGatewayTimeout
+	case errors.Is(err, ErrUnauthorized), errors.Is(err, ErrUnavailable):
+		return http.StatusBadGateway
+	}
+	return fallback
+}
@@ -0,0 +1,63 @@
+package chef
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/drewhammond/chefbrowser/config"
+)
+
+func TestErrorClassification(t *testing.T) {
+	tests := []struct {
+		name   string
+		status int
+		err    error
+		code   int
+	}{
+		{"not found", http.StatusNotFound, ErrRoleNotFound, http.StatusNotFound},
+		{"forbidden", http.StatusForbidden, ErrForbidden, http.StatusForbidden},
+		{"unauthorized", http.StatusUnauthorized, ErrUnauthorized, http.StatusBadGateway},
+		{"server error", http.StatusInternalServerError, ErrUnavailable, http.StatusBadGateway},
+		{"service unavailable", http.StatusServiceUnavailable, ErrUnavailable, http.StatusBadGateway},
+		{"gateway timeout", http.StatusGatewayTimeout, ErrTimeout, http.StatusGatewayTimeout},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tt.status)
+			}))
+			defer srv.Close()
+
+			s := newTestService(t, srv.URL, &config.Config{})
+			_, err := s.GetRole(context.Background(), "foo")
+			if !errors.Is(err, tt.err) {
+				t.Errorf("unexpected error, expected: %v, actual: %v", tt.err, err)
+			}
+			if code := StatusCode(err, http.StatusInternalServerError); code != tt.code {
+				t.Errorf("unexpected status code, expected: %d, actual: %d", tt.code, code)
+			}
+		})
+	}
+}
+
+func TestErrorClassificationUnreachable(t *testing.T) {
+	srv := httptest.NewServer(http.NotFoundHandler())
+	url := srv.URL
+	srv.Close()
+
+	s := newTestService(t, url, &config.Config{})
+	_, err := s.GetNodes(context.Background())
+	if !errors.Is(err, ErrUnavailable) {
+		t.Errorf("expected unreachable server to be unavailable, actual: %v", err)
+	}
+}
+
+func TestStatusCodeFallback(t *testing.T) {
+	if code := StatusCode(errors.New("boom"), http.StatusTeapot); code != http.StatusTeapot {
+		t.Errorf("expected fallback status for unclassified error, actual: %d", code)
+	}
+}
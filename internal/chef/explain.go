@@ -0,0 +1,269 @@
+package chef
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+
+	"github.com/go-chef/chef"
+	"go.uber.org/zap"
+)
+
+// Attribute precedence levels, from lowest to highest precedence
+const (
+	LevelDefault   = "default"
+	LevelNormal    = "normal"
+	LevelOverride  = "override"
+	LevelAutomatic = "automatic"
+)
+
+// Objects that attributes can come from
+const (
+	SourceNode        = "node"
+	SourceRole        = "role"
+	SourceEnvironment = "environment"
+)
+
+// AttributeLayer is the value of an attribute at a single precedence level and source
+type AttributeLayer struct {
+	Level  string `json:"level"`
+	Source string `json:"source"`
+	// Name is the name of the role or environment the layer comes from
+	Name  string      `json:"name,omitempty"`
+	Set   bool        `json:"set"`
+	Value interface{} `json:"value,omitempty"`
+	// Shadows is the path of a parent key the layer sets to a value other than a hash (held in Value), which hides
+	// the attribute of every layer below it
+	Shadows string `json:"shadows,omitempty"`
+	// Wins is true for the layer with the highest precedence that sets the attribute, or one of its parents, to a
+	// value other than nil
+	Wins bool `json:"wins"`
+}
+
+// AttributeExplanation shows how the effective value of a node attribute was determined
+type AttributeExplanation struct {
+	Node   string           `json:"node"`
+	Path   string           `json:"path"`
+	Found  bool             `json:"found"`
+	Value  interface{}      `json:"value"`
+	Layers []AttributeLayer `json:"layers"`
+	// Missing lists the environment and roles that could not be fetched and have no layer
+	Missing []string `json:"missing,omitempty"`
+}
+
+// attributeSource holds the attributes of a single precedence level and source
+type attributeSource struct {
+	level  string
+	source string
+	name   string
+	attrs  map[string]interface{}
+}
+
+// ParseAttributePath splits a dotted attribute path such as "kernel.machine" (optionally prefixed with "$.",
+// as shown on the node page) into its keys
+func ParseAttributePath(path string) []string {
+	path = strings.TrimPrefix(strings.TrimPrefix(path, "$"), ".")
+	if path == "" {
+		return nil
+	}
+	return strings.Split(path, ".")
+}
+
+// ExplainAttribute returns the value of an attribute at every precedence level along with the level that wins.
+//
+// The default and override attributes saved on the node already include the attributes of its environment and roles
+// as of the last chef-client run. Like the merged attributes of the node (see Node.MergeAttributes), the current
+// environment and role attributes are combined with them at their own precedence levels, so the winning layer always
+// holds the effective value. Nodes using policyfiles ignore environments and roles, so only the node's own attributes
+// are shown for them.
+func (s Service) ExplainAttribute(ctx context.Context, node *Node, path []string) (*AttributeExplanation, error) {
+	env, roles, missing := s.attributeComponents(ctx, node)
+
+	// node was already redacted by GetNode, but its environment and roles were not
+	sources := attributeSources(node, env, roles)
+	redactor := s.redactorFor(ctx)
+	for i, src := range sources {
+		if src.source != SourceNode {
+			sources[i].attrs = redactor.RedactMap(src.attrs)
+		}
+	}
+	e := explainAttribute(node, sources, path)
+	e.Missing = missing
+	return e, nil
+}
+
+// attributeComponents returns the environment and roles whose attributes chef-client combines with the attributes of
+// a node. Roles are taken from the roles expanded during the last run (or the run list if the node has never
+// converged) and deleted ones are skipped. Nodes using policyfiles have neither.
+//
+// Components that could not be fetched for any other reason are logged and left out, and returned as missing so
+// callers can tell that the attributes merged without them are incomplete.
+func (s Service) attributeComponents(ctx context.Context, node *Node) (*chef.Environment, []*Role, []string) {
+	if node.PolicyName != "" {
+		return nil, nil, nil
+	}
+
+	var env *chef.Environment
+	names := node.roleNames()
+	roles := make([]*Role, len(names))
+	failed := make([]bool, len(names)+1)
+
+	var wg sync.WaitGroup
+	if node.Environment != "" {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			e, err := s.getEnvironment(ctx, node.Environment)
+			if err == nil {
+				env = e
+			} else if !errors.Is(err, ErrNotFound) {
+				s.logComponentError(node, "environment", node.Environment, err)
+				failed[len(names)] = true
+			}
+		}()
+	}
+	for i, name := range names {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			r, err := s.getRole(ctx, name)
+			if err == nil {
+				roles[i] = r
+			} else if !errors.Is(err, ErrNotFound) {
+				s.logComponentError(node, "role", name, err)
+				failed[i] = true
+			}
+		}()
+	}
+	wg.Wait()
+
+	var missing []string
+	if failed[len(names)] {
+		missing = append(missing, "environment "+node.Environment)
+	}
+	for i, name := range names {
+		if failed[i] {
+			missing = append(missing, "role "+name)
+		}
+	}
+	return env, roles, missing
+}
+
+func (s Service) logComponentError(node *Node, kind string, name string, err error) {
+	if s.log != nil {
+		s.log.Warn("failed to fetch "+kind+" of node, merging its attributes without it",
+			zap.String("node", node.Name), zap.String(kind, name), zap.Error(err))
+	}
+}
+
+// roleNames returns the roles applied to the node, preferring the roles expanded during the last chef-client run
+func (s Node) roleNames() []string {
+	var names []string
+	if roles, ok := s.AutomaticAttributes["roles"].([]interface{}); ok {
+		for _, r := range roles {
+			if name, ok := r.(string); ok {
+				names = append(names, name)
+			}
+		}
+		return names
+	}
+
+	for _, item := range s.RunList {
+		if p := ParseRunListItem(item); p.Type == RunListRole {
+			names = append(names, p.Name)
+		}
+	}
+	return names
+}
+
+// attributeSources returns every source of node attributes ordered from lowest to highest precedence. Within the
+// default and override levels, the attributes saved on the node come first like the attributes of cookbooks do in
+// chef-client, followed by the environment and roles in the order chef-client merges them.
+// Ref: https://docs.chef.io/attribute_precedence/
+func attributeSources(node *Node, env *chef.Environment, roles []*Role) []attributeSource {
+	var envDefault, envOverride map[string]interface{}
+	if env != nil {
+		envDefault, _ = env.DefaultAttributes.(map[string]interface{})
+		envOverride, _ = env.OverrideAttributes.(map[string]interface{})
+	}
+
+	sources := []attributeSource{{level: LevelDefault, source: SourceNode, attrs: node.DefaultAttributes}}
+	if env != nil {
+		sources = append(sources, attributeSource{level: LevelDefault, source: SourceEnvironment, name: env.Name, attrs: envDefault})
+	}
+	for _, r := range roles {
+		if r != nil {
+			attrs, _ := r.DefaultAttributes.(map[string]interface{})
+			sources = append(sources, attributeSource{level: LevelDefault, source: SourceRole, name: r.Name, attrs: attrs})
+		}
+	}
+
+	sources = append(sources,
+		attributeSource{level: LevelNormal, source: SourceNode, attrs: node.NormalAttributes},
+		attributeSource{level: LevelOverride, source: SourceNode, attrs: node.OverrideAttributes},
+	)
+	for _, r := range roles {
+		if r != nil {
+			attrs, _ := r.OverrideAttributes.(map[string]interface{})
+			sources = append(sources, attributeSource{level: LevelOverride, source: SourceRole, name: r.Name, attrs: attrs})
+		}
+	}
+	if env != nil {
+		sources = append(sources, attributeSource{level: LevelOverride, source: SourceEnvironment, name: env.Name, attrs: envOverride})
+	}
+
+	return append(sources, attributeSource{level: LevelAutomatic, source: SourceNode, attrs: node.AutomaticAttributes})
+}
+
+func explainAttribute(node *Node, sources []attributeSource, path []string) *AttributeExplanation {
+	e := &AttributeExplanation{
+		Node:   node.Name,
+		Path:   strings.Join(path, "."),
+		Layers: make([]AttributeLayer, 0, len(sources)),
+	}
+	if len(path) == 0 {
+		return e
+	}
+
+	winner := -1
+	for i, src := range sources {
+		layer := AttributeLayer{Level: src.level, Source: src.source, Name: src.name}
+		if v, err := lookupAttribute(src.attrs, path...); err == nil {
+			layer.Set = true
+			layer.Value = v
+			// nil never replaces a value when merging, so it can't win either
+			if !isNil(v) {
+				winner = i
+			}
+		} else if prefix, v, ok := shadowingValue(src.attrs, path); ok {
+			layer.Shadows = prefix
+			layer.Value = v
+			winner = i
+		}
+		e.Layers = append(e.Layers, layer)
+	}
+
+	if winner >= 0 {
+		e.Layers[winner].Wins = true
+	}
+	v, err := lookupAttribute(node.MergedAttributes, path...)
+	e.Value, e.Found = v, err == nil
+
+	return e
+}
+
+// shadowingValue returns the first parent of path that attrs sets to a value other than a hash or nil, along with
+// that value. Merging replaces the hashes of lower layers with such a value, so the attribute disappears.
+func shadowingValue(attrs map[string]interface{}, path []string) (string, interface{}, bool) {
+	for i, key := range path[:len(path)-1] {
+		v, ok := attrs[key]
+		if !ok || isNil(v) {
+			return "", nil, false
+		}
+		if attrs, ok = v.(map[string]interface{}); !ok {
+			return strings.Join(path[:i+1], "."), v, true
+		}
+	}
+	return "", nil, false
+}
@@ -0,0 +1,176 @@
+package chef
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/go-chef/chef"
+)
+
+func TestParseAttributePath(t *testing.T) {
+	tests := []struct {
+		path     string
+		expected []string
+	}{
+		{"", nil},
+		{"$.", nil},
+		{"kernel", []string{"kernel"}},
+		{"kernel.machine", []string{"kernel", "machine"}},
+		{"$.kernel.machine", []string{"kernel", "machine"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			if got := ParseAttributePath(tt.path); !reflect.DeepEqual(got, tt.expected) {
+				t.Errorf("ParseAttributePath() = %v, want %v", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestRoleNames(t *testing.T) {
+	tests := []struct {
+		name     string
+		node     Node
+		expected []string
+	}{
+		{
+			"expanded roles",
+			Node{Node: chef.Node{
+				RunList:             []string{"role[base]"},
+				AutomaticAttributes: map[string]interface{}{"roles": []interface{}{"base", "nested"}},
+			}},
+			[]string{"base", "nested"},
+		},
+		{
+			"run list",
+			Node{Node: chef.Node{RunList: []string{"role[base]", "recipe[web]", "role[web]"}}},
+			[]string{"base", "web"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.node.roleNames(); !reflect.DeepEqual(got, tt.expected) {
+				t.Errorf("roleNames() = %v, want %v", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestExplainAttribute(t *testing.T) {
+	attrs := func(v interface{}) map[string]interface{} {
+		return map[string]interface{}{"app": map[string]interface{}{"port": v}}
+	}
+	env := &chef.Environment{
+		Name:               "prod",
+		DefaultAttributes:  attrs("env-default"),
+		OverrideAttributes: attrs("env-override"),
+	}
+	base := &Role{&chef.Role{Name: "base", DefaultAttributes: attrs("base-default"), OverrideAttributes: attrs("base-override")}}
+	web := &Role{&chef.Role{Name: "web", DefaultAttributes: attrs("web-default")}}
+	defaultsOnly := &Role{&chef.Role{Name: "defaults", DefaultAttributes: attrs("defaults-default")}}
+
+	type layer struct {
+		level, source, name string
+		value               interface{}
+	}
+	tests := []struct {
+		name   string
+		node   Node
+		env    *chef.Environment
+		roles  []*Role
+		winner layer
+	}{
+		{
+			"later roles win over earlier roles and environments",
+			Node{Node: chef.Node{DefaultAttributes: attrs("cookbook")}},
+			&chef.Environment{Name: "prod", DefaultAttributes: attrs("env-default")},
+			[]*Role{defaultsOnly, web},
+			layer{LevelDefault, SourceRole, "web", "web-default"},
+		},
+		{
+			"normal wins over defaults",
+			Node{Node: chef.Node{NormalAttributes: attrs("normal")}},
+			nil,
+			[]*Role{web},
+			layer{LevelNormal, SourceNode, "", "normal"},
+		},
+		{
+			"environment override wins over role override",
+			Node{Node: chef.Node{OverrideAttributes: attrs("cookbook-override")}},
+			env,
+			[]*Role{base, nil},
+			layer{LevelOverride, SourceEnvironment, "prod", "env-override"},
+		},
+		{
+			"roles win over stale values saved on the node",
+			Node{Node: chef.Node{DefaultAttributes: attrs("web-default-before-last-change")}},
+			nil,
+			[]*Role{web},
+			layer{LevelDefault, SourceRole, "web", "web-default"},
+		},
+		{
+			"nil does not win",
+			Node{Node: chef.Node{NormalAttributes: attrs(nil), OverrideAttributes: attrs(nil)}},
+			nil,
+			[]*Role{web},
+			layer{LevelDefault, SourceRole, "web", "web-default"},
+		},
+		{
+			"automatic always wins",
+			Node{Node: chef.Node{AutomaticAttributes: attrs("automatic")}},
+			env,
+			[]*Role{base},
+			layer{LevelAutomatic, SourceNode, "", "automatic"},
+		},
+		{
+			"parent set to a value other than a hash hides the attribute",
+			Node{Node: chef.Node{OverrideAttributes: map[string]interface{}{"app": "disabled"}}},
+			nil,
+			[]*Role{web},
+			layer{LevelOverride, SourceNode, "", "disabled"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.node.MergedAttributes = tt.node.MergeAttributes(tt.env, tt.roles)
+			e := explainAttribute(&tt.node, attributeSources(&tt.node, tt.env, tt.roles), []string{"app", "port"})
+
+			var winners []AttributeLayer
+			for _, l := range e.Layers {
+				if l.Wins {
+					winners = append(winners, l)
+				}
+			}
+			if len(winners) != 1 {
+				t.Fatalf("expected exactly one winning layer, actual: %v", winners)
+			}
+			w := winners[0]
+			if got := (layer{w.Level, w.Source, w.Name, w.Value}); got != tt.winner {
+				t.Errorf("unexpected winner, expected: %v, actual: %v", tt.winner, got)
+			}
+			if w.Shadows != "" {
+				if e.Found || e.Value != nil {
+					t.Errorf("expected attribute shadowed by %s to be missing, actual: %v", w.Shadows, e.Value)
+				}
+				return
+			}
+			if !e.Found {
+				t.Fatal("expected attribute to be found")
+			}
+			if e.Value != w.Value {
+				t.Errorf("effective value %v does not match the winning layer %v", e.Value, w.Value)
+			}
+		})
+	}
+}
+
+func TestExplainAttributeNotFound(t *testing.T) {
+	node := &Node{Node: chef.Node{NormalAttributes: map[string]interface{}{"app": "value"}}}
+	e := explainAttribute(node, attributeSources(node, nil, nil), []string{"app", "port"})
+	if e.Found {
+		t.Errorf("expected attribute to be missing, actual: %v", e)
+	}
+	if len(e.Layers) != 4 {
+		t.Errorf("expected the 4 node layers, actual: %d", len(e.Layers))
+	}
+}
@@ -0,0 +1,102 @@
+package chef
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+// FederatedNode is a node found by a federated search, tagged with its organization
+type FederatedNode struct {
+	Organization string `json:"organization"`
+	Name         string `json:"name"`
+}
+
+// OrganizationSearchStatus describes how a single organization responded to a federated search
+type OrganizationSearchStatus struct {
+	Organization string `json:"organization"`
+	Total        int    `json:"total"`
+	Error        string `json:"error,omitempty"`
+	TimedOut     bool   `json:"timed_out,omitempty"`
+}
+
+// FederatedNodeList holds the nodes of every organization matching a federated search
+type FederatedNodeList struct {
+	Nodes         []FederatedNode            `json:"nodes"`
+	Total         int                        `json:"total"`
+	Organizations []OrganizationSearchStatus `json:"organizations"`
+}
+
+// Failed returns true if no organization could be searched
+func (l *FederatedNodeList) Failed() bool {
+	for _, o := range l.Organizations {
+		if o.Error == "" {
+			return false
+		}
+	}
+	return len(l.Organizations) > 0
+}
+
+// SearchNodes searches the nodes of every organization concurrently, giving each organization up to timeout to
+// respond (or its own request_timeout if timeout is 0). Organizations that fail or time out are reported in the
+// result instead of failing the search. Like the node list of a single organization, queries without a field such
+// as a bare hostname are expanded by FuzzifyQuery. Nodes are sorted by name, then organization.
+func (o *Organizations) SearchNodes(ctx context.Context, q string, timeout time.Duration) *FederatedNodeList {
+	q = FuzzifyQuery(IndexNode, q)
+	nodes := make([]*NodeList, len(o.names))
+	statuses := make([]OrganizationSearchStatus, len(o.names))
+
+	var wg sync.WaitGroup
+	for i, name := range o.names {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			var orgCtx context.Context
+			var cancel context.CancelFunc
+			if timeout > 0 {
+				orgCtx, cancel = context.WithTimeout(ctx, timeout)
+			} else {
+				orgCtx, cancel = context.WithCancel(ctx)
+			}
+			defer cancel()
+
+			statuses[i].Organization = name
+			list, err := o.services[name].SearchNodes(orgCtx, q)
+			if err != nil {
+				// hitting our own deadline is reported as a timeout; the chef service only does so for its own
+				if ctx.Err() == nil && errors.Is(orgCtx.Err(), context.DeadlineExceeded) {
+					err = fmt.Errorf("%w after %s", ErrTimeout, timeout)
+				}
+				statuses[i].Error = err.Error()
+				statuses[i].TimedOut = errors.Is(err, ErrTimeout)
+				return
+			}
+			nodes[i] = list
+			statuses[i].Total = list.Total
+		}()
+	}
+	wg.Wait()
+
+	result := &FederatedNodeList{Nodes: []FederatedNode{}, Organizations: statuses}
+	for i, list := range nodes {
+		if list == nil {
+			continue
+		}
+		for _, node := range list.Nodes {
+			result.Nodes = append(result.Nodes, FederatedNode{Organization: o.names[i], Name: node})
+		}
+	}
+	sort.Slice(result.Nodes, func(i, j int) bool {
+		if result.Nodes[i].Name != result.Nodes[j].Name {
+			return result.Nodes[i].Name < result.Nodes[j].Name
+		}
+		return result.Nodes[i].Organization < result.Nodes[j].Organization
+	})
+	result.Total = len(result.Nodes)
+
+	return result
+}
@@ -0,0 +1,104 @@
+package chef
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/drewhammond/chefbrowser/config"
+)
+
+func TestFederatedSearchNodes(t *testing.T) {
+	search := func(body string) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = w.Write([]byte(body))
+		}
+	}
+	prod := httptest.NewServer(search(`{"total": 2, "start": 0, "rows": [{"data": {"name": "web1"}}, {"data": {"name": "db1"}}]}`))
+	defer prod.Close()
+	lab := httptest.NewServer(search(`{"total": 1, "start": 0, "rows": [{"data": {"name": "web1"}}]}`))
+	defer lab.Close()
+	broken := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer broken.Close()
+	release := make(chan struct{})
+	slow := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-release:
+		case <-r.Context().Done():
+		}
+	}))
+	defer slow.Close()
+	defer close(release)
+
+	o := &Organizations{names: []string{"prod", "broken", "lab", "slow"}, services: map[string]*Service{}}
+	for name, url := range map[string]string{"prod": prod.URL, "broken": broken.URL, "lab": lab.URL, "slow": slow.URL} {
+		s := newTestService(t, url, &config.Config{})
+		o.services[name] = &s
+	}
+
+	got := o.SearchNodes(context.Background(), "name:*", 100*time.Millisecond)
+
+	expected := []FederatedNode{{"prod", "db1"}, {"lab", "web1"}, {"prod", "web1"}}
+	if !reflect.DeepEqual(got.Nodes, expected) || got.Total != 3 {
+		t.Errorf("SearchNodes() nodes = %+v, want %+v", got.Nodes, expected)
+	}
+
+	statuses := map[string]OrganizationSearchStatus{}
+	for _, s := range got.Organizations {
+		statuses[s.Organization] = s
+	}
+	if s := statuses["broken"]; s.Error == "" || s.TimedOut {
+		t.Errorf("expected the broken organization to fail, actual: %+v", s)
+	}
+	if s := statuses["slow"]; !s.TimedOut {
+		t.Errorf("expected the slow organization to time out, actual: %+v", s)
+	}
+	if s := statuses["lab"]; s.Error != "" || s.Total != 1 {
+		t.Errorf("unexpected lab status: %+v", s)
+	}
+	if got.Failed() {
+		t.Error("expected the search to succeed while some organizations are available")
+	}
+}
+
+func TestFederatedSearchNodesFuzzy(t *testing.T) {
+	var queries []string
+	var mu sync.Mutex
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		queries = append(queries, r.URL.Query().Get("q"))
+		mu.Unlock()
+
+		w.Header().Set("Content-Type", "application/json")
+		if strings.Contains(r.URL.Query().Get("q"), "fqdn:*web1*") {
+			_, _ = w.Write([]byte(`{"total": 1, "start": 0, "rows": [{"data": {"name": "web1.example.com"}}]}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"total": 0, "start": 0, "rows": []}`))
+	}))
+	defer srv.Close()
+
+	o := &Organizations{names: []string{"prod", "lab"}, services: map[string]*Service{}}
+	for _, name := range o.names {
+		s := newTestService(t, srv.URL, &config.Config{})
+		o.services[name] = &s
+	}
+
+	got := o.SearchNodes(context.Background(), "web1", time.Second)
+	if got.Total != 2 {
+		t.Errorf("expected a bare hostname to find the node in both organizations, actual: %+v", got.Nodes)
+	}
+	for _, q := range queries {
+		if q != FuzzifyQuery(IndexNode, "web1") {
+			t.Errorf("expected the query to be fuzzified, actual: %q", q)
+		}
+	}
+}
@@ -2,24 +2,31 @@ package chef
 
 import (
 	"context"
+	"net/url"
 
 	"github.com/go-chef/chef"
 )
 
 func (s Service) GetGroups(ctx context.Context) (interface{}, error) {
-	groups, err := s.client.Groups.List()
-	if err != nil {
-		return groups, err
-	}
+	return cached(ctx, s.cache, "groups", s.config.Cache.GroupTTL, func(ctx context.Context) (interface{}, error) {
+		var groups map[string]string
+		err := s.get(ctx, "groups", &groups)
+		if err != nil {
+			return groups, err
+		}
 
-	return groups, nil
+		return groups, nil
+	})
 }
 
 func (s Service) GetGroup(ctx context.Context, name string) (chef.Group, error) {
-	group, err := s.client.Groups.Get(name)
-	if err != nil {
-		return group, err
-	}
+	return cached(ctx, s.cache, "group:"+name, s.config.Cache.GroupTTL, func(ctx context.Context) (chef.Group, error) {
+		var group chef.Group
+		err := s.get(ctx, "groups/"+url.PathEscape(name), &group)
+		if err != nil {
+			return group, err
+		}
 
-	return group, nil
+		return group, nil
+	})
 }
@@ -0,0 +1,125 @@
+package chef
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"slices"
+)
+
+// InventoryCount is the number of nodes sharing a single attribute value. An empty Value counts the nodes
+// without the attribute.
+type InventoryCount struct {
+	Value string `json:"value"`
+	Count int    `json:"count"`
+}
+
+// PlatformCount is the number of nodes running a platform, broken down by platform version
+type PlatformCount struct {
+	Platform string           `json:"platform"`
+	Count    int              `json:"count"`
+	Versions []InventoryCount `json:"versions"`
+}
+
+// Inventory summarizes the node fleet. Every list is sorted by descending count.
+type Inventory struct {
+	Total        int              `json:"total"`
+	Platforms    []PlatformCount  `json:"platforms"`
+	ChefVersions []InventoryCount `json:"chef_versions"`
+	Environments []InventoryCount `json:"environments"`
+	PolicyGroups []InventoryCount `json:"policy_groups"`
+	// Roles counts the expanded roles of each node, so a node is counted once for every role it has
+	Roles []InventoryCount `json:"roles"`
+}
+
+// inventoryNode holds the attributes of a single node that are summarized by the inventory
+type inventoryNode struct {
+	Platform        string   `json:"platform"`
+	PlatformVersion string   `json:"platform_version"`
+	ChefVersion     string   `json:"chef_version"`
+	Environment     string   `json:"chef_environment"`
+	PolicyGroup     string   `json:"policy_group"`
+	Roles           []string `json:"roles"`
+}
+
+// GetInventory returns node counts by platform, chef client version, environment, policy group and role.
+// The attributes of every node are fetched with a single partial search.
+func (s Service) GetInventory(ctx context.Context) (*Inventory, error) {
+	return cached(ctx, s.cache, "report/inventory", s.config.Cache.NodeTTL, func(ctx context.Context) (*Inventory, error) {
+		rows, err := s.partialSearch(ctx, IndexNode, "*:*", map[string]interface{}{
+			"platform":         []string{"platform"},
+			"platform_version": []string{"platform_version"},
+			"chef_version":     []string{"chef_packages", "chef", "version"},
+			"chef_environment": []string{"chef_environment"},
+			"policy_group":     []string{"policy_group"},
+			"roles":            []string{"roles"},
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		nodes := make([]inventoryNode, 0, len(rows))
+		for _, i := range rows {
+			var node inventoryNode
+			_ = json.Unmarshal(i, &node)
+			nodes = append(nodes, node)
+		}
+
+		return buildInventory(nodes), nil
+	})
+}
+
+func buildInventory(nodes []inventoryNode) *Inventory {
+	platforms := map[string]map[string]int{}
+	chefVersions := map[string]int{}
+	environments := map[string]int{}
+	policyGroups := map[string]int{}
+	roles := map[string]int{}
+
+	for _, n := range nodes {
+		if platforms[n.Platform] == nil {
+			platforms[n.Platform] = map[string]int{}
+		}
+		platforms[n.Platform][n.PlatformVersion]++
+		chefVersions[n.ChefVersion]++
+		environments[n.Environment]++
+		policyGroups[n.PolicyGroup]++
+		for _, r := range n.Roles {
+			roles[r]++
+		}
+	}
+
+	inv := &Inventory{
+		Total:        len(nodes),
+		Platforms:    []PlatformCount{},
+		ChefVersions: sortedCounts(chefVersions),
+		Environments: sortedCounts(environments),
+		PolicyGroups: sortedCounts(policyGroups),
+		Roles:        sortedCounts(roles),
+	}
+
+	for platform, versions := range platforms {
+		pc := PlatformCount{Platform: platform, Versions: sortedCounts(versions)}
+		for _, v := range pc.Versions {
+			pc.Count += v.Count
+		}
+		inv.Platforms = append(inv.Platforms, pc)
+	}
+	slices.SortFunc(inv.Platforms, func(a, b PlatformCount) int {
+		return cmp.Or(cmp.Compare(b.Count, a.Count), cmp.Compare(a.Platform, b.Platform))
+	})
+
+	return inv
+}
+
+// sortedCounts converts a map of counts into a list sorted by descending count, then by value
+func sortedCounts(counts map[string]int) []InventoryCount {
+	out := make([]InventoryCount, 0, len(counts))
+	for v, c := range counts {
+		out = append(out, InventoryCount{Value: v, Count: c})
+	}
+	slices.SortFunc(out, func(a, b InventoryCount) int {
+		return cmp.Or(cmp.Compare(b.Count, a.Count), cmp.Compare(a.Value, b.Value))
+	})
+	return out
+}
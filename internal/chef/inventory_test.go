@@ -0,0 +1,47 @@
+package chef
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestBuildInventory(t *testing.T) {
+	nodes := []inventoryNode{
+		{Platform: "ubuntu", PlatformVersion: "22.04", ChefVersion: "18.2.7", Environment: "prod", Roles: []string{"base", "web"}},
+		{Platform: "ubuntu", PlatformVersion: "20.04", ChefVersion: "18.2.7", Environment: "prod", Roles: []string{"base"}},
+		{Platform: "ubuntu", PlatformVersion: "22.04", ChefVersion: "17.10.0", Environment: "_default", PolicyGroup: "prod"},
+		{Platform: "centos", PlatformVersion: "7.9", ChefVersion: "17.10.0", Environment: "_default", PolicyGroup: "prod"},
+	}
+
+	inv := buildInventory(nodes)
+
+	if inv.Total != 4 {
+		t.Errorf("expected 4 nodes, actual: %d", inv.Total)
+	}
+
+	expectedPlatforms := []PlatformCount{
+		{Platform: "ubuntu", Count: 3, Versions: []InventoryCount{{"22.04", 2}, {"20.04", 1}}},
+		{Platform: "centos", Count: 1, Versions: []InventoryCount{{"7.9", 1}}},
+	}
+	if !reflect.DeepEqual(inv.Platforms, expectedPlatforms) {
+		t.Errorf("unexpected platforms: %v", inv.Platforms)
+	}
+
+	tests := []struct {
+		name     string
+		actual   []InventoryCount
+		expected []InventoryCount
+	}{
+		{"chef versions", inv.ChefVersions, []InventoryCount{{"17.10.0", 2}, {"18.2.7", 2}}},
+		{"environments", inv.Environments, []InventoryCount{{"_default", 2}, {"prod", 2}}},
+		{"policy groups", inv.PolicyGroups, []InventoryCount{{"", 2}, {"prod", 2}}},
+		{"roles", inv.Roles, []InventoryCount{{"base", 2}, {"web", 1}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if !reflect.DeepEqual(tt.actual, tt.expected) {
+				t.Errorf("expected %v, actual: %v", tt.expected, tt.actual)
+			}
+		})
+	}
+}
@@ -0,0 +1,58 @@
+package chef
+
+import (
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+)
+
+var ErrInvalidPublicKey = errors.New("invalid public key")
+
+// PublicKey is a public key of a client or user
+type PublicKey struct {
+	Name           string `json:"name"`
+	PublicKey      string `json:"public_key,omitempty"`
+	ExpirationDate string `json:"expiration_date,omitempty"`
+	Expired        bool   `json:"expired"`
+	// Fingerprint identifies the key, see KeyFingerprint. It is empty if the key could not be parsed.
+	Fingerprint string `json:"fingerprint,omitempty"`
+}
+
+// KeyFingerprint returns the SHA-256 fingerprint of a PEM encoded public key, e.g. "SHA256:47DEQpj8HBSa+/TImW+5JC...".
+// The fingerprint is taken over the DER encoded (PKIX) key, so a key gives the same fingerprint whether the chef server
+// returns it in PKIX or PKCS #1 form.
+func KeyFingerprint(publicKey string) (string, error) {
+	block, _ := pem.Decode([]byte(publicKey))
+	if block == nil {
+		return "", ErrInvalidPublicKey
+	}
+
+	der := block.Bytes
+	switch block.Type {
+	case "PUBLIC KEY":
+		if _, err := x509.ParsePKIXPublicKey(der); err != nil {
+			return "", ErrInvalidPublicKey
+		}
+	case "RSA PUBLIC KEY":
+		key, err := x509.ParsePKCS1PublicKey(der)
+		if err != nil {
+			return "", ErrInvalidPublicKey
+		}
+		if der, err = x509.MarshalPKIXPublicKey(key); err != nil {
+			return "", ErrInvalidPublicKey
+		}
+	default:
+		return "", ErrInvalidPublicKey
+	}
+
+	sum := sha256.Sum256(der)
+	return "SHA256:" + base64.RawStdEncoding.EncodeToString(sum[:]), nil
+}
+
+// withFingerprint sets the fingerprint of the key if it can be parsed
+func (k PublicKey) withFingerprint() PublicKey {
+	k.Fingerprint, _ = KeyFingerprint(k.PublicKey)
+	return k
+}
@@ -0,0 +1,55 @@
+package chef
+
+import (
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"strings"
+	"testing"
+)
+
+// testPublicKey returns the public half of testKey in PKIX and PKCS #1 form
+func testPublicKey(t *testing.T) (string, string) {
+	t.Helper()
+
+	block, _ := pem.Decode([]byte(testKey()))
+	key, err := x509.ParsePKCS1PrivateKey(block.Bytes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pkix, err := x509.MarshalPKIXPublicKey(key.Public())
+	if err != nil {
+		t.Fatal(err)
+	}
+	pkcs1 := x509.MarshalPKCS1PublicKey(key.Public().(*rsa.PublicKey))
+
+	return string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: pkix})),
+		string(pem.EncodeToMemory(&pem.Block{Type: "RSA PUBLIC KEY", Bytes: pkcs1}))
+}
+
+func TestKeyFingerprint(t *testing.T) {
+	pkix, pkcs1 := testPublicKey(t)
+
+	a, err := KeyFingerprint(pkix)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(a, "SHA256:") || len(a) != len("SHA256:")+43 {
+		t.Errorf("unexpected fingerprint format: %s", a)
+	}
+
+	b, err := KeyFingerprint(pkcs1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if a != b {
+		t.Errorf("expected PKIX and PKCS #1 keys to have the same fingerprint, actual: %s and %s", a, b)
+	}
+
+	for _, key := range []string{"", "not a key", testKey()} {
+		if _, err := KeyFingerprint(key); !errors.Is(err, ErrInvalidPublicKey) {
+			t.Errorf("expected ErrInvalidPublicKey, actual: %v", err)
+		}
+	}
+}
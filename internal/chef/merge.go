@@ -0,0 +1,144 @@
+package chef
+
+import (
+	"reflect"
+	"strings"
+)
+
+// knockoutPrefix removes values while merging attributes: "!merge:foo" in an array removes "foo" from the array
+// being merged into, and a bare "!merge:" removes everything merged so far.
+const knockoutPrefix = "!merge:"
+
+// hashOnlyMerge merges with onto onto the way chef-client merges attribute precedence levels (default, normal,
+// override and automatic). Hashes are merged recursively, nil never replaces a value and everything else, including
+// arrays, is replaced by the higher precedence value. Neither argument is modified.
+// Ref: https://github.com/chef/chef/blob/main/lib/chef/mixin/deep_merge.rb
+func hashOnlyMerge(onto interface{}, with interface{}) interface{} {
+	if isNil(with) {
+		return onto
+	}
+
+	ontoMap, ok := onto.(map[string]interface{})
+	withMap, ok2 := with.(map[string]interface{})
+	if !ok || !ok2 {
+		return with
+	}
+
+	out := make(map[string]interface{}, len(ontoMap)+len(withMap))
+	for k, v := range ontoMap {
+		out[k] = v
+	}
+	for k, v := range withMap {
+		if existing, ok := out[k]; ok {
+			out[k] = hashOnlyMerge(existing, v)
+		} else {
+			out[k] = v
+		}
+	}
+	return out
+}
+
+// mergeSources merges attribute sources ordered from lowest to highest precedence the way chef-client does: the
+// sources of a single precedence level are combined with DeepMerge, then the levels are merged with hashOnlyMerge.
+func mergeSources(sources []attributeSource) map[string]interface{} {
+	var merged interface{} = map[string]interface{}{}
+	for i := 0; i < len(sources); {
+		level := sources[i].level
+		var attrs interface{} = sources[i].attrs
+		for i++; i < len(sources) && sources[i].level == level; i++ {
+			attrs = DeepMerge(attrs, sources[i].attrs)
+		}
+		merged = hashOnlyMerge(merged, attrs)
+	}
+	return merged.(map[string]interface{})
+}
+
+// DeepMerge merges source into dest the way chef-client merges the components of a single precedence level, such as
+// the default attributes of several roles. Unlike merging precedence levels, arrays are combined (keeping the order
+// of dest and dropping duplicates) and the "!merge:" knockout prefix can remove values from dest. Neither argument
+// is modified.
+func DeepMerge(dest interface{}, source interface{}) interface{} {
+	if isNil(source) {
+		return dest
+	}
+
+	switch src := source.(type) {
+	case map[string]interface{}:
+		destMap, ok := dest.(map[string]interface{})
+		if !ok {
+			destMap = nil
+		}
+
+		out := make(map[string]interface{}, len(destMap)+len(src))
+		for k, v := range destMap {
+			out[k] = v
+		}
+		for k, v := range src {
+			if v == knockoutPrefix {
+				delete(out, k)
+				continue
+			}
+			if existing, ok := out[k]; ok && existing != nil && existing != false {
+				out[k] = DeepMerge(existing, v)
+			} else {
+				out[k] = DeepMerge(nil, v)
+			}
+		}
+		return out
+	case []interface{}:
+		destArr, _ := dest.([]interface{})
+		return mergeArrays(destArr, src)
+	default:
+		return source
+	}
+}
+
+// mergeArrays returns the union of dest and source after removing the values knocked out by source
+func mergeArrays(dest []interface{}, source []interface{}) []interface{} {
+	var add, knockout []interface{}
+	keepDest := true
+	for _, v := range source {
+		s, ok := v.(string)
+		if !ok || !strings.HasPrefix(s, knockoutPrefix) {
+			add = append(add, v)
+			continue
+		}
+		if s == knockoutPrefix {
+			keepDest = false
+		} else {
+			knockout = append(knockout, strings.TrimPrefix(s, knockoutPrefix))
+		}
+	}
+
+	out := []interface{}{}
+	if keepDest {
+		for _, v := range dest {
+			if !containsValue(knockout, v) && !containsValue(out, v) {
+				out = append(out, v)
+			}
+		}
+	}
+	for _, v := range add {
+		if !containsValue(out, v) {
+			out = append(out, v)
+		}
+	}
+	return out
+}
+
+func containsValue(values []interface{}, v interface{}) bool {
+	for _, i := range values {
+		if reflect.DeepEqual(i, v) {
+			return true
+		}
+	}
+	return false
+}
+
+// isNil reports whether v is nil or a nil map, which is how missing attribute levels are decoded
+func isNil(v interface{}) bool {
+	if m, ok := v.(map[string]interface{}); ok {
+		return m == nil
+	}
+	return v == nil
+}
@@ -0,0 +1,156 @@
+package chef
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// NodeRow is a single node with the values of the requested attribute paths
+type NodeRow struct {
+	Name   string                 `json:"name"`
+	Fields map[string]interface{} `json:"fields"`
+}
+
+// NodeTable is a list of nodes with a fixed set of attribute columns
+type NodeTable struct {
+	Columns []string  `json:"columns"`
+	Nodes   []NodeRow `json:"nodes"`
+	// Total is the number of nodes matching the query, which may be more than the number of rows in a page
+	Total int `json:"total"`
+}
+
+// SortNodeTable describes how the rows of a NodeTable are sorted. Rows are sorted by name if Column is empty.
+type SortNodeTable struct {
+	Column     string
+	Descending bool
+}
+
+// GetNodeTable returns every node matching q along with the values of the given attribute paths
+// (e.g. "platform" or "chef_packages.chef.version"). All values are fetched with a single partial search.
+// Since rows can be sorted by any column, every matching node is fetched before the requested page is selected.
+func (s Service) GetNodeTable(ctx context.Context, q string, columns []string, order SortNodeTable, page Page) (*NodeTable, error) {
+	if q == "" {
+		q = "*:*"
+	}
+
+	table, err := cached(ctx, s.cache, nodeTableKey(q, columns), s.config.Cache.NodeTTL, func(ctx context.Context) (*NodeTable, error) {
+		return s.searchNodeTable(ctx, q, columns)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// the cached table is shared, so redact and sort a copy of it
+	rows := slices.Clone(table.Nodes)
+	if redactor := s.redactorFor(ctx); !redactor.empty() {
+		for i, row := range rows {
+			fields := make(map[string]interface{}, len(row.Fields))
+			for c, v := range row.Fields {
+				fields[c] = redactor.RedactAt(v, ParseAttributePath(c))
+			}
+			rows[i].Fields = fields
+		}
+	}
+	sortNodeRows(rows, order)
+
+	return &NodeTable{Columns: table.Columns, Nodes: paginate(rows, page), Total: len(rows)}, nil
+}
+
+func nodeTableKey(q string, columns []string) string {
+	return fmt.Sprintf("node_table:%s:%s", q, strings.Join(columns, ","))
+}
+
+// ParseColumns splits comma-separated lists of attribute paths into a clean list of columns
+func ParseColumns(lists ...string) []string {
+	var columns []string
+	for _, l := range lists {
+		for _, c := range strings.Split(l, ",") {
+			c = strings.TrimSpace(c)
+			if c != "" && c != "name" && !slices.Contains(columns, c) {
+				columns = append(columns, c)
+			}
+		}
+	}
+	return columns
+}
+
+func (s Service) searchNodeTable(ctx context.Context, q string, columns []string) (*NodeTable, error) {
+	partial := map[string]interface{}{
+		"name": []string{"name"},
+	}
+	for _, c := range columns {
+		partial["field:"+c] = strings.Split(c, ".")
+	}
+
+	rows, err := s.partialSearch(ctx, IndexNode, q, partial)
+	if err != nil {
+		return nil, err
+	}
+
+	table := &NodeTable{Columns: columns, Nodes: []NodeRow{}}
+	for _, i := range rows {
+		var data map[string]interface{}
+		_ = json.Unmarshal(i, &data)
+
+		row := NodeRow{Fields: make(map[string]interface{}, len(columns))}
+		row.Name, _ = data["name"].(string)
+		for _, c := range columns {
+			row.Fields[c] = data["field:"+c]
+		}
+		table.Nodes = append(table.Nodes, row)
+	}
+
+	return table, nil
+}
+
+func sortNodeRows(rows []NodeRow, order SortNodeTable) {
+	slices.SortStableFunc(rows, func(a, b NodeRow) int {
+		var c int
+		if order.Column == "" || order.Column == "name" {
+			c = strings.Compare(a.Name, b.Name)
+		} else {
+			x, y := a.Fields[order.Column], b.Fields[order.Column]
+			// nodes without a value always go last, regardless of the sort direction
+			if (x == nil) != (y == nil) {
+				if x == nil {
+					return 1
+				}
+				return -1
+			}
+			c = compareValues(x, y)
+		}
+
+		if order.Descending {
+			c = -c
+		}
+		if c == 0 {
+			c = strings.Compare(a.Name, b.Name)
+		}
+		return c
+	})
+}
+
+// compareValues orders attribute values of mixed types. Numbers are compared numerically, missing values sort
+// after everything else and anything else is compared by its string representation.
+func compareValues(a, b interface{}) int {
+	switch {
+	case a == nil && b == nil:
+		return 0
+	case a == nil:
+		return 1
+	case b == nil:
+		return -1
+	}
+
+	if x, ok := a.(float64); ok {
+		if y, ok := b.(float64); ok {
+			return cmp.Compare(x, y)
+		}
+	}
+
+	return strings.Compare(fmt.Sprint(a), fmt.Sprint(b))
+}
@@ -0,0 +1,64 @@
+package chef
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseColumns(t *testing.T) {
+	tests := []struct {
+		name     string
+		lists    []string
+		expected []string
+	}{
+		{"empty", []string{""}, nil},
+		{"single list", []string{"platform, platform_version"}, []string{"platform", "platform_version"}},
+		{"multiple lists", []string{"platform", "fqdn,ohai_time"}, []string{"platform", "fqdn", "ohai_time"}},
+		{"duplicates and name", []string{"name,fqdn,,fqdn"}, []string{"fqdn"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ParseColumns(tt.lists...); !reflect.DeepEqual(got, tt.expected) {
+				t.Errorf("ParseColumns() = %v, want %v", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestSortNodeRows(t *testing.T) {
+	rows := func() []NodeRow {
+		return []NodeRow{
+			{Name: "c", Fields: map[string]interface{}{"ohai_time": 20.0, "platform": "ubuntu"}},
+			{Name: "a", Fields: map[string]interface{}{"ohai_time": 3.0, "platform": nil}},
+			{Name: "d", Fields: map[string]interface{}{"ohai_time": nil, "platform": "centos"}},
+			{Name: "b", Fields: map[string]interface{}{"ohai_time": 100.0, "platform": "ubuntu"}},
+		}
+	}
+
+	tests := []struct {
+		name     string
+		order    SortNodeTable
+		expected []string
+	}{
+		{"default", SortNodeTable{}, []string{"a", "b", "c", "d"}},
+		{"name descending", SortNodeTable{Column: "name", Descending: true}, []string{"d", "c", "b", "a"}},
+		{"numeric", SortNodeTable{Column: "ohai_time"}, []string{"a", "c", "b", "d"}},
+		{"numeric descending", SortNodeTable{Column: "ohai_time", Descending: true}, []string{"b", "c", "a", "d"}},
+		{"string with ties", SortNodeTable{Column: "platform"}, []string{"d", "b", "c", "a"}},
+		{"string descending", SortNodeTable{Column: "platform", Descending: true}, []string{"b", "c", "d", "a"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := rows()
+			sortNodeRows(r, tt.order)
+
+			var got []string
+			for _, row := range r {
+				got = append(got, row.Name)
+			}
+			if !reflect.DeepEqual(got, tt.expected) {
+				t.Errorf("sortNodeRows() = %v, want %v", got, tt.expected)
+			}
+		})
+	}
+}
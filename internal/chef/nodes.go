@@ -4,25 +4,41 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
+	"net/url"
+	"slices"
 	"sort"
 
-	"dario.cat/mergo"
 	"github.com/go-chef/chef"
 )
 
 type NodeList struct {
 	Nodes []string `json:"nodes"`
+	// Total is the number of nodes matching the request, which may be more than the number of nodes in a page
+	Total int `json:"total"`
 }
 
 type Node struct {
 	chef.Node
 	MergedAttributes map[string]interface{}
+	// PartialMerge is true if MergedAttributes lacks the attributes of the environment or roles in MissingComponents,
+	// which could not be fetched
+	PartialMerge      bool
+	MissingComponents []string
 }
 
-var ErrPathNotFound = errors.New("attribute not found at path")
+var (
+	ErrNodeNotFound = fmt.Errorf("node %w", ErrNotFound)
+	ErrPathNotFound = errors.New("attribute not found at path")
+)
 
 func (s Service) GetNodes(ctx context.Context) (*NodeList, error) {
-	nodes, err := s.client.Nodes.List()
+	return cached(ctx, s.cache, "nodes", s.config.Cache.NodeTTL, s.listNodes)
+}
+
+func (s Service) listNodes(ctx context.Context) (*NodeList, error) {
+	var nodes map[string]string
+	err := s.get(ctx, "nodes", &nodes)
 	if err != nil {
 		return nil, err
 	}
@@ -35,52 +51,160 @@ func (s Service) GetNodes(ctx context.Context) (*NodeList, error) {
 
 	sort.Strings(nl)
 
-	return &NodeList{Nodes: nl}, nil
+	return &NodeList{Nodes: nl, Total: len(nl)}, nil
 }
 
 func (s Service) SearchNodes(ctx context.Context, q string) (*NodeList, error) {
+	return cached(ctx, s.cache, "search/node:"+q, s.config.Cache.NodeTTL, func(ctx context.Context) (*NodeList, error) {
+		return s.searchNodes(ctx, q)
+	})
+}
+
+func (s Service) searchNodes(ctx context.Context, q string) (*NodeList, error) {
 	partial := map[string]interface{}{
 		"name": []string{"name"},
 	}
-	query, err := s.client.Search.PartialExecJSON("node", q, partial)
+	rows, err := s.partialSearch(ctx, "node", q, partial)
 	if err != nil {
 		return nil, err
 	}
 
 	var nodes NodeList
 
-	for _, i := range query.Rows {
+	for _, i := range rows {
 		var node Node
-		_ = json.Unmarshal(i.Data, &node)
+		_ = json.Unmarshal(i, &node)
 		nodes.Nodes = append(nodes.Nodes, node.Name)
 	}
 
 	sort.Strings(nodes.Nodes)
+	nodes.Total = len(nodes.Nodes)
 
 	return &nodes, nil
 }
 
-func (s Service) GetNode(ctx context.Context, name string) (*Node, error) {
-	node, err := s.client.Nodes.Get(name)
+// GetNodesPage returns a single page of the nodes matching q (or every node if q is empty) sorted by name. Only the
+// requested page is fetched from the chef server.
+func (s Service) GetNodesPage(ctx context.Context, q string, page Page, descending bool) (*NodeList, error) {
+	if q == "" {
+		q = "*:*"
+	}
+
+	if page.Rows <= 0 {
+		// everything after start was requested so there is nothing to gain from paging the search
+		nodes, err := s.SearchNodes(ctx, q)
+		if err != nil {
+			return nil, err
+		}
+		names := slices.Clone(nodes.Nodes)
+		if descending {
+			slices.Reverse(names)
+		}
+		return &NodeList{Nodes: paginate(names, page), Total: nodes.Total}, nil
+	}
+
+	return cached(ctx, s.cache, nodesPageKey(q, page, descending), s.config.Cache.NodeTTL, func(ctx context.Context) (*NodeList, error) {
+		return s.searchNodesPage(ctx, q, page, descending)
+	})
+}
+
+func nodesPageKey(q string, page Page, descending bool) string {
+	return fmt.Sprintf("search/node:%s:%d:%d:%t", q, page.Start, page.Rows, descending)
+}
+
+// refreshNodeList refreshes the cached first page of the node list shown by the UI, which is searched for
+// separately from the list of every node
+func (s Service) refreshNodeList(ctx context.Context) error {
+	q := "*:*"
+	if columns := ParseColumns(s.config.NodeList.Columns...); len(columns) > 0 {
+		table, err := s.searchNodeTable(ctx, q, columns)
+		if err != nil {
+			return err
+		}
+		s.cache.set(nodeTableKey(q, columns), table, s.config.Cache.NodeTTL)
+		return nil
+	}
+
+	page := Page{Rows: max(s.config.UI.PageSize, 0)}
+	if page.Rows == 0 {
+		nodes, err := s.searchNodes(ctx, q)
+		if err != nil {
+			return err
+		}
+		s.cache.set("search/node:"+q, nodes, s.config.Cache.NodeTTL)
+		return nil
+	}
+
+	nodes, err := s.searchNodesPage(ctx, q, page, false)
+	if err != nil {
+		return err
+	}
+	s.cache.set(nodesPageKey(q, page, false), nodes, s.config.Cache.NodeTTL)
+	return nil
+}
+
+func (s Service) searchNodesPage(ctx context.Context, q string, page Page, descending bool) (*NodeList, error) {
+	order := "name asc"
+	if descending {
+		order = "name desc"
+	}
+
+	partial := map[string]interface{}{
+		"name": []string{"name"},
+	}
+	res, err := s.partialSearchPage(ctx, IndexNode, q, order, max(page.Start, 0), page.Rows, partial)
 	if err != nil {
 		return nil, err
 	}
 
-	ret := &Node{Node: node}
-	ret.MergedAttributes = ret.MergeAttributes()
+	nodes := NodeList{Nodes: []string{}, Total: res.Total}
+	for _, i := range res.Rows {
+		var node Node
+		_ = json.Unmarshal(i.Data, &node)
+		nodes.Nodes = append(nodes.Nodes, node.Name)
+	}
 
-	return ret, nil
+	sort.Strings(nodes.Nodes)
+	if descending {
+		slices.Reverse(nodes.Nodes)
+	}
+
+	return &nodes, nil
+}
+
+// GetNode returns a single node without the attributes the viewer of ctx may not see
+func (s Service) GetNode(ctx context.Context, name string) (*Node, error) {
+	node, err := cached(ctx, s.cache, "node:"+name, s.config.Cache.NodeTTL, func(ctx context.Context) (*Node, error) {
+		var node chef.Node
+		err := s.get(ctx, "nodes/"+url.PathEscape(name), &node)
+		if err != nil {
+			if errors.Is(err, ErrNotFound) {
+				return nil, ErrNodeNotFound
+			}
+			return nil, err
+		}
+
+		ret := &Node{Node: node}
+		env, roles, missing := s.attributeComponents(ctx, ret)
+		ret.MergedAttributes = ret.MergeAttributes(env, roles)
+		ret.PartialMerge, ret.MissingComponents = len(missing) > 0, missing
+
+		return ret, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s.redactNode(ctx, node), nil
 }
 
 // MergeAttributes returns the merged set of all node attributes taking attribute precedence into consideration.
+// Levels are merged like chef-client does: hashes are merged, while arrays and other values at a higher precedence
+// level replace those at lower levels. The attributes of the node's environment and roles (both optional) are
+// combined with the default and override levels first, which merges arrays and applies "!merge:" knockouts (see
+// DeepMerge).
 // Ref: https://docs.chef.io/attribute_precedence/
-func (s Node) MergeAttributes() map[string]interface{} {
-	var attrs map[string]interface{}
-	_ = mergo.Merge(&attrs, s.DefaultAttributes, mergo.WithOverride)
-	_ = mergo.Merge(&attrs, s.NormalAttributes, mergo.WithOverride)
-	_ = mergo.Merge(&attrs, s.OverrideAttributes, mergo.WithOverride)
-	_ = mergo.Merge(&attrs, s.AutomaticAttributes, mergo.WithOverride)
-	return attrs
+func (s Node) MergeAttributes(env *chef.Environment, roles []*Role) map[string]interface{} {
+	return mergeSources(attributeSources(&s, env, roles))
 }
 
 // GetEffectiveAttributeValue returns the effective attribute value of a given path considering attribute precedence.
@@ -96,7 +220,9 @@ func lookupAttribute(attrs map[string]interface{}, paths ...string) (interface{}
 		if len(remainingPaths) <= 0 {
 			return attr, nil
 		}
-		return lookupAttribute(attr.(map[string]interface{}), remainingPaths...)
+		if nested, ok := attr.(map[string]interface{}); ok {
+			return lookupAttribute(nested, remainingPaths...)
+		}
 	}
 
 	return nil, ErrPathNotFound
@@ -1,9 +1,14 @@
 package chef
 
 import (
+	"context"
 	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
 	"testing"
 
+	"github.com/drewhammond/chefbrowser/config"
 	"github.com/go-chef/chef"
 )
 
@@ -109,13 +114,96 @@ func TestGetEffectiveAttributes(t *testing.T) {
 			"automatic",
 			nil,
 		},
+		{
+			"false overrides true",
+			Node{
+				Node: chef.Node{
+					DefaultAttributes:  map[string]interface{}{"enabled": true},
+					OverrideAttributes: map[string]interface{}{"enabled": false},
+				},
+			},
+			[]string{"enabled"},
+			false,
+			nil,
+		},
+		{
+			"empty string overrides",
+			Node{
+				Node: chef.Node{
+					DefaultAttributes: map[string]interface{}{"foo": "default"},
+					NormalAttributes:  map[string]interface{}{"foo": ""},
+				},
+			},
+			[]string{"foo"},
+			"",
+			nil,
+		},
+		{
+			"nil does not override",
+			Node{
+				Node: chef.Node{
+					DefaultAttributes:  map[string]interface{}{"foo": "default"},
+					OverrideAttributes: map[string]interface{}{"foo": nil},
+				},
+			},
+			[]string{"foo"},
+			"default",
+			nil,
+		},
+		{
+			"arrays are replaced by higher precedence levels",
+			Node{
+				Node: chef.Node{
+					DefaultAttributes:  map[string]interface{}{"foo": []interface{}{"a", "b"}},
+					OverrideAttributes: map[string]interface{}{"foo": []interface{}{"c"}},
+				},
+			},
+			[]string{"foo"},
+			[]interface{}{"c"},
+			nil,
+		},
+		{
+			"scalar replaces hash",
+			Node{
+				Node: chef.Node{
+					DefaultAttributes: map[string]interface{}{"foo": map[string]interface{}{"bar": "baz"}},
+					NormalAttributes:  map[string]interface{}{"foo": "flat"},
+				},
+			},
+			[]string{"foo"},
+			"flat",
+			nil,
+		},
+		{
+			"sibling keys are kept",
+			Node{
+				Node: chef.Node{
+					DefaultAttributes:   map[string]interface{}{"foo": map[string]interface{}{"bar": "default", "baz": "default"}},
+					AutomaticAttributes: map[string]interface{}{"foo": map[string]interface{}{"bar": "automatic"}},
+				},
+			},
+			[]string{"foo", "baz"},
+			"default",
+			nil,
+		},
+		{
+			"path through a non-map value",
+			Node{
+				Node: chef.Node{
+					NormalAttributes: map[string]interface{}{"foo": "bar"},
+				},
+			},
+			[]string{"foo", "bar"},
+			nil,
+			ErrPathNotFound,
+		},
 	}
 
 	for _, tt := range tests {
 		tt := tt
 
 		t.Run(tt.name, func(t *testing.T) {
-			tt.node.MergedAttributes = tt.node.MergeAttributes()
+			tt.node.MergedAttributes = tt.node.MergeAttributes(nil, nil)
 			output, err := tt.node.GetEffectiveAttributeValue(tt.paths...)
 			if err != nil {
 				if tt.err == nil {
@@ -130,9 +218,178 @@ func TestGetEffectiveAttributes(t *testing.T) {
 				t.Errorf("should have error, expected: %v, actual: %v", tt.err, err)
 			}
 
-			if output != tt.expected {
+			if !reflect.DeepEqual(output, tt.expected) {
+				t.Errorf("unexpected result, paths: %v, expected: %v, actual: %v", tt.paths, tt.expected, output)
+			}
+		})
+	}
+}
+
+func TestMergeAttributesDoesNotModifyLevels(t *testing.T) {
+	node := Node{
+		Node: chef.Node{
+			DefaultAttributes: map[string]interface{}{"foo": map[string]interface{}{"bar": "default"}},
+			NormalAttributes:  map[string]interface{}{"foo": map[string]interface{}{"bar": "normal"}},
+		},
+	}
+	_ = node.MergeAttributes(nil, nil)
+
+	if v := node.DefaultAttributes["foo"].(map[string]interface{})["bar"]; v != "default" {
+		t.Errorf("default attributes were modified: %v", v)
+	}
+}
+
+func TestMergeAttributesWithEnvironmentAndRoles(t *testing.T) {
+	env := &chef.Environment{
+		Name:               "prod",
+		DefaultAttributes:  map[string]interface{}{"packages": []interface{}{"curl"}, "debug": true},
+		OverrideAttributes: map[string]interface{}{"port": 443.0},
+	}
+	base := &Role{&chef.Role{
+		Name:               "base",
+		DefaultAttributes:  map[string]interface{}{"packages": []interface{}{"!merge:telnet", "vim"}, "debug": "!merge:"},
+		OverrideAttributes: map[string]interface{}{"port": 8443.0, "users": []interface{}{"!merge:"}},
+	}}
+	node := Node{
+		Node: chef.Node{
+			DefaultAttributes:  map[string]interface{}{"packages": []interface{}{"telnet", "git"}},
+			OverrideAttributes: map[string]interface{}{"users": []interface{}{"root"}},
+		},
+	}
+
+	tests := []struct {
+		name     string
+		env      *chef.Environment
+		roles    []*Role
+		paths    []string
+		expected interface{}
+		err      error
+	}{
+		{"knockouts remove array values of lower components", env, []*Role{base}, []string{"packages"}, []interface{}{"git", "curl", "vim"}, nil},
+		{"arrays of a level are combined", env, nil, []string{"packages"}, []interface{}{"telnet", "git", "curl"}, nil},
+		{"knockouts remove keys of lower components", env, []*Role{base}, []string{"debug"}, nil, ErrPathNotFound},
+		{"environment defaults apply without roles", env, nil, []string{"debug"}, true, nil},
+		{"environment overrides win over role overrides", env, []*Role{base, nil}, []string{"port"}, 443.0, nil},
+		{"a bare knockout empties an array", nil, []*Role{base}, []string{"users"}, []interface{}{}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			n := node
+			n.MergedAttributes = n.MergeAttributes(tt.env, tt.roles)
+			output, err := n.GetEffectiveAttributeValue(tt.paths...)
+			if !errors.Is(err, tt.err) {
+				t.Errorf("unexpected error, expected: %v, actual: %v", tt.err, err)
+			}
+			if !reflect.DeepEqual(output, tt.expected) {
 				t.Errorf("unexpected result, paths: %v, expected: %v, actual: %v", tt.paths, tt.expected, output)
 			}
 		})
 	}
 }
+
+func TestGetNodeWithMissingComponents(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/nodes/web1":
+			_, _ = w.Write([]byte(`{"name": "web1", "chef_environment": "prod", "run_list": ["role[base]", "role[web]", "role[gone]"]}`))
+		case "/roles/base":
+			_, _ = w.Write([]byte(`{"name": "base", "default_attributes": {"port": 80}}`))
+		case "/environments/prod":
+			w.WriteHeader(http.StatusInternalServerError)
+		case "/roles/web":
+			w.WriteHeader(http.StatusForbidden)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+
+	s := newTestService(t, srv.URL, &config.Config{})
+	node, err := s.GetNode(context.Background(), "web1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !node.PartialMerge {
+		t.Error("expected the merge to be partial")
+	}
+	// deleted roles are skipped like chef-client does, so they are not missing
+	if expected := []string{"environment prod", "role web"}; !reflect.DeepEqual(node.MissingComponents, expected) {
+		t.Errorf("expected missing components %v, actual: %v", expected, node.MissingComponents)
+	}
+	if port, _ := node.GetEffectiveAttributeValue("port"); port != 80.0 {
+		t.Errorf("expected the attributes of the fetched role to be merged, actual: %v", port)
+	}
+}
+
+func TestDeepMerge(t *testing.T) {
+	tests := []struct {
+		name     string
+		dest     interface{}
+		source   interface{}
+		expected interface{}
+	}{
+		{
+			"nested hashes",
+			map[string]interface{}{"foo": map[string]interface{}{"bar": "dest", "baz": "dest"}},
+			map[string]interface{}{"foo": map[string]interface{}{"bar": "source"}},
+			map[string]interface{}{"foo": map[string]interface{}{"bar": "source", "baz": "dest"}},
+		},
+		{
+			"arrays are combined without duplicates",
+			[]interface{}{"a", "b", "a"},
+			[]interface{}{"b", "c"},
+			[]interface{}{"a", "b", "c"},
+		},
+		{
+			"array replaces scalar",
+			"a",
+			[]interface{}{"b"},
+			[]interface{}{"b"},
+		},
+		{
+			"knockout removes array values",
+			[]interface{}{"a", "b", "c"},
+			[]interface{}{"!merge:b", "d"},
+			[]interface{}{"a", "c", "d"},
+		},
+		{
+			"bare knockout clears the array",
+			[]interface{}{"a", "b"},
+			[]interface{}{"!merge:", "c"},
+			[]interface{}{"c"},
+		},
+		{
+			"knockout values are dropped without a destination",
+			nil,
+			map[string]interface{}{"foo": []interface{}{"!merge:a", "b"}},
+			map[string]interface{}{"foo": []interface{}{"b"}},
+		},
+		{
+			"bare knockout removes a key",
+			map[string]interface{}{"foo": "dest", "bar": "dest"},
+			map[string]interface{}{"foo": "!merge:"},
+			map[string]interface{}{"bar": "dest"},
+		},
+		{
+			"nil keeps destination",
+			map[string]interface{}{"foo": "dest"},
+			nil,
+			map[string]interface{}{"foo": "dest"},
+		},
+		{
+			"false destination is replaced",
+			map[string]interface{}{"foo": false},
+			map[string]interface{}{"foo": map[string]interface{}{"bar": true}},
+			map[string]interface{}{"foo": map[string]interface{}{"bar": true}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := DeepMerge(tt.dest, tt.source); !reflect.DeepEqual(got, tt.expected) {
+				t.Errorf("DeepMerge() = %v, want %v", got, tt.expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,113 @@
+package chef
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sync"
+	"time"
+
+	"github.com/drewhammond/chefbrowser/config"
+	"github.com/drewhammond/chefbrowser/internal/common/logging"
+	"go.uber.org/zap"
+)
+
+// organization names become part of every URL, so they are limited to the characters chef allows in its own
+// organization names
+var validOrganizationName = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+// Organizations holds a separate chef service for every configured organization
+type Organizations struct {
+	names    []string
+	services map[string]*Service
+}
+
+// NewOrganizations connects to every configured organization. The first organization is the default one.
+func NewOrganizations(cfg *config.Config, logger *logging.Logger) *Organizations {
+	o := &Organizations{services: map[string]*Service{}}
+	for _, name := range cfg.OrganizationNames() {
+		if !validOrganizationName.MatchString(name) {
+			logger.Fatal(fmt.Sprintf("invalid organization name %q; only letters, digits, - and _ are allowed", name))
+		}
+		orgLogger := &logging.Logger{Logger: logger.With(zap.String("organization", name))}
+		o.names = append(o.names, name)
+		o.services[name] = New(name, cfg.ForOrganization(name), orgLogger)
+	}
+	return o
+}
+
+// Names returns the name of every organization, default organization first
+func (o *Organizations) Names() []string {
+	return o.names
+}
+
+// Default returns the service of the default organization
+func (o *Organizations) Default() *Service {
+	return o.services[o.names[0]]
+}
+
+// Get returns the service of the named organization
+func (o *Organizations) Get(name string) (*Service, bool) {
+	s, ok := o.services[name]
+	return s, ok
+}
+
+// Health is the result of the most recent health check of an organization
+type Health struct {
+	Healthy   bool      `json:"healthy"`
+	CheckedAt time.Time `json:"checked_at"`
+	Error     string    `json:"error,omitempty"`
+}
+
+type healthState struct {
+	mu     sync.RWMutex
+	health Health
+}
+
+// Health returns the result of the most recent health check
+func (s Service) Health() Health {
+	if s.health == nil {
+		return Health{}
+	}
+	s.health.mu.RLock()
+	defer s.health.mu.RUnlock()
+	return s.health.health
+}
+
+// CheckHealth verifies that the organization can be read with the configured credentials and records the result.
+// Every organization has a _default environment, which makes it a cheap object to fetch (the global _status
+// endpoint would not check permissions).
+func (s Service) CheckHealth(ctx context.Context) Health {
+	var env json.RawMessage
+	err := s.get(ctx, "environments/_default", &env)
+
+	h := Health{Healthy: err == nil, CheckedAt: time.Now()}
+	if err != nil {
+		h.Error = err.Error()
+	}
+
+	if s.health != nil {
+		s.health.mu.Lock()
+		s.health.health = h
+		s.health.mu.Unlock()
+	}
+	return h
+}
+
+// healthLoop periodically checks the health of the organization, logging whenever it changes
+func (s Service) healthLoop(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		healthy := s.Health().Healthy
+		h := s.CheckHealth(context.Background())
+		switch {
+		case healthy && !h.Healthy:
+			s.log.Error("chef server health check failed", zap.String("error", h.Error))
+		case !healthy && h.Healthy:
+			s.log.Info("chef server health check recovered")
+		}
+	}
+}
@@ -0,0 +1,36 @@
+package chef
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/drewhammond/chefbrowser/config"
+)
+
+func TestCheckHealth(t *testing.T) {
+	healthy := true
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !healthy {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"name": "_default"}`))
+	}))
+	defer srv.Close()
+
+	s := newTestService(t, srv.URL, &config.Config{})
+	s.health = &healthState{}
+
+	if h := s.CheckHealth(context.Background()); !h.Healthy || h.Error != "" {
+		t.Errorf("expected a healthy organization, actual: %+v", h)
+	}
+
+	healthy = false
+	s.CheckHealth(context.Background())
+	if h := s.Health(); h.Healthy || h.Error == "" {
+		t.Errorf("expected the failed health check to be recorded, actual: %+v", h)
+	}
+}
@@ -0,0 +1,19 @@
+package chef
+
+import "slices"
+
+// Page selects a window of a sorted list of objects. A Rows value of 0 selects every object after Start.
+type Page struct {
+	Start int
+	Rows  int
+}
+
+// paginate returns a copy of the items selected by p
+func paginate[T any](items []T, p Page) []T {
+	start := min(max(p.Start, 0), len(items))
+	end := len(items)
+	if p.Rows > 0 {
+		end = min(start+p.Rows, end)
+	}
+	return slices.Clone(items[start:end])
+}
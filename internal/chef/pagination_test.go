@@ -0,0 +1,30 @@
+package chef
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestPaginate(t *testing.T) {
+	items := []int{1, 2, 3, 4, 5}
+	tests := []struct {
+		name     string
+		page     Page
+		expected []int
+	}{
+		{"everything", Page{}, []int{1, 2, 3, 4, 5}},
+		{"first page", Page{Rows: 2}, []int{1, 2}},
+		{"middle page", Page{Start: 2, Rows: 2}, []int{3, 4}},
+		{"last partial page", Page{Start: 4, Rows: 2}, []int{5}},
+		{"everything after start", Page{Start: 3}, []int{4, 5}},
+		{"beyond the end", Page{Start: 10, Rows: 2}, []int{}},
+		{"negative start", Page{Start: -1, Rows: 1}, []int{1}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := paginate(items, tt.page); !reflect.DeepEqual(got, tt.expected) {
+				t.Errorf("paginate() = %v, want %v", got, tt.expected)
+			}
+		})
+	}
+}
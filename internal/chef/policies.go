@@ -2,6 +2,7 @@ package chef
 
 import (
 	"context"
+	"net/url"
 
 	"github.com/go-chef/chef"
 )
@@ -11,47 +12,73 @@ type PolicyGroup struct {
 }
 
 func (s Service) GetPolicies(ctx context.Context) (chef.PoliciesGetResponse, error) {
-	policies, err := s.client.Policies.List()
-	if err != nil {
-		return policies, err
-	}
+	return cached(ctx, s.cache, "policies", s.config.Cache.PolicyTTL, func(ctx context.Context) (chef.PoliciesGetResponse, error) {
+		var policies chef.PoliciesGetResponse
+		err := s.get(ctx, "policies", &policies)
+		if err != nil {
+			return policies, err
+		}
 
-	return policies, nil
+		return policies, nil
+	})
 }
 
 func (s Service) GetPolicy(ctx context.Context, name string) (chef.PolicyGetResponse, error) {
-	policy, err := s.client.Policies.Get(name)
-	if err != nil {
-		return policy, err
-	}
+	return cached(ctx, s.cache, "policy:"+name, s.config.Cache.PolicyTTL, func(ctx context.Context) (chef.PolicyGetResponse, error) {
+		var policy chef.PolicyGetResponse
+		err := s.get(ctx, "policies/"+url.PathEscape(name), &policy)
+		if err != nil {
+			return policy, err
+		}
 
-	return policy, nil
+		return policy, nil
+	})
 }
 
+// GetPolicyRevision returns a single revision of a policy without the attributes the viewer of ctx may not see
 func (s Service) GetPolicyRevision(ctx context.Context, name string, revision string) (chef.RevisionDetailsResponse, error) {
-	policyRevision, err := s.client.Policies.GetRevisionDetails(name, revision)
+	// policy revisions are immutable, so they can be cached just like any other policy object
+	policyRevision, err := cached(ctx, s.cache, "policy_revision:"+name+"/"+revision, s.config.Cache.PolicyTTL, func(ctx context.Context) (chef.RevisionDetailsResponse, error) {
+		var policyRevision chef.RevisionDetailsResponse
+		err := s.get(ctx, "policies/"+url.PathEscape(name)+"/revisions/"+url.PathEscape(revision), &policyRevision)
+		if err != nil {
+			return policyRevision, err
+		}
+
+		return policyRevision, nil
+	})
 	if err != nil {
 		return policyRevision, err
 	}
 
+	// the attribute maps are shared by the cache, but RedactMap only returns copies
+	redactor := s.redactorFor(ctx)
+	policyRevision.DefaultAttributes = redactor.RedactMap(policyRevision.DefaultAttributes)
+	policyRevision.OverrideAttributes = redactor.RedactMap(policyRevision.OverrideAttributes)
 	return policyRevision, nil
 }
 
 func (s Service) GetPolicyGroups(ctx context.Context) (chef.PolicyGroupGetResponse, error) {
-	policyGroups, err := s.client.PolicyGroups.List()
-	if err != nil {
-		return policyGroups, err
-	}
+	return cached(ctx, s.cache, "policy_groups", s.config.Cache.PolicyTTL, func(ctx context.Context) (chef.PolicyGroupGetResponse, error) {
+		var policyGroups chef.PolicyGroupGetResponse
+		err := s.get(ctx, "policy_groups", &policyGroups)
+		if err != nil {
+			return policyGroups, err
+		}
 
-	return policyGroups, nil
+		return policyGroups, nil
+	})
 }
 
 func (s Service) GetPolicyGroup(ctx context.Context, name string) (PolicyGroup, error) {
-	policyGroup, err := s.client.PolicyGroups.Get(name)
-	resp := PolicyGroup{policyGroup}
-	if err != nil {
-		return resp, err
-	}
+	return cached(ctx, s.cache, "policy_group:"+name, s.config.Cache.PolicyTTL, func(ctx context.Context) (PolicyGroup, error) {
+		var policyGroup chef.PolicyGroup
+		err := s.get(ctx, "policy_groups/"+url.PathEscape(name), &policyGroup)
+		resp := PolicyGroup{policyGroup}
+		if err != nil {
+			return resp, err
+		}
 
-	return resp, nil
+		return resp, nil
+	})
 }
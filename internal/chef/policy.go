@@ -0,0 +1,217 @@
+package chef
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/drewhammond/chefbrowser/config"
+)
+
+// Object types that access rules can restrict
+const (
+	ObjectTypeDataBags = "data_bags"
+)
+
+var policyObjectTypes = []string{ObjectTypeDataBags}
+
+// ErrDenied is returned for objects the access policy hides from the user. Unlike ErrForbidden, it is chefbrowser
+// and not the chef server that denies access.
+var (
+	ErrDenied        = errors.New("denied by access policy")
+	ErrDatabagDenied = fmt.Errorf("databag %w", ErrDenied)
+)
+
+// AccessRule only allows users in one of Groups to see the objects of Type whose name matches Pattern
+// (e.g. "secrets-*", see path.Match). A rule without groups hides matching objects from everyone.
+type AccessRule struct {
+	Type    string
+	Pattern string
+	Groups  []string
+}
+
+// AttributeRedaction redacts the values at Paths and the values whose key matches one of Keys (see NewRedactor) for
+// every user who is not in one of Groups. A redaction without groups applies to everyone.
+type AttributeRedaction struct {
+	Paths  []string
+	Keys   []string
+	Groups []string
+}
+
+// Policy decides which objects and attributes a user may see based on their groups
+type Policy struct {
+	rules      []AccessRule
+	redactions []redaction
+}
+
+type redaction struct {
+	redactor *Redactor
+	groups   []string
+}
+
+// NewPolicy validates the rules and redactions of a policy. Rules are evaluated in order.
+func NewPolicy(rules []AccessRule, redactions []AttributeRedaction) (*Policy, error) {
+	for _, r := range rules {
+		if !slices.Contains(policyObjectTypes, r.Type) {
+			return nil, fmt.Errorf("access rule has unsupported object type %q (supported: %s)", r.Type,
+				strings.Join(policyObjectTypes, ", "))
+		}
+		if _, err := path.Match(r.Pattern, ""); err != nil || r.Pattern == "" {
+			return nil, fmt.Errorf("access rule has invalid pattern %q", r.Pattern)
+		}
+	}
+	p := &Policy{rules: rules}
+	for _, r := range redactions {
+		if len(r.Paths) == 0 && len(r.Keys) == 0 {
+			return nil, errors.New("attribute redaction has no paths or keys")
+		}
+		redactor, err := NewRedactor(r.Paths, r.Keys)
+		if err != nil {
+			return nil, err
+		}
+		p.redactions = append(p.redactions, redaction{redactor: redactor, groups: r.Groups})
+	}
+	return p, nil
+}
+
+// policyFromConfig builds the policy of the [redaction] and [authorization] sections. Rules and redactions are
+// ordered by their number.
+func policyFromConfig(cfg *config.Config) (*Policy, error) {
+	var rules []AccessRule
+	for _, key := range sortedKeys(cfg.Authorization.Rules) {
+		r := cfg.Authorization.Rules[key]
+		rules = append(rules, AccessRule{
+			Type:    strings.TrimSpace(r.Type),
+			Pattern: strings.TrimSpace(r.Pattern),
+			Groups:  trimList(r.Groups),
+		})
+	}
+
+	var redactions []AttributeRedaction
+	if r := cfg.Redaction; r.Enabled && len(trimList(r.Paths))+len(trimList(r.Keys)) > 0 {
+		redactions = append(redactions, AttributeRedaction{
+			Paths:  trimList(r.Paths),
+			Keys:   trimList(r.Keys),
+			Groups: trimList(r.Groups),
+		})
+	}
+	for _, key := range sortedKeys(cfg.Authorization.Redactions) {
+		r := cfg.Authorization.Redactions[key]
+		redactions = append(redactions, AttributeRedaction{
+			Paths:  trimList([]string{r.Path}),
+			Groups: trimList(r.Groups),
+		})
+	}
+
+	return NewPolicy(rules, redactions)
+}
+
+func sortedKeys[V any](m map[int]V) []int {
+	keys := make([]int, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Ints(keys)
+	return keys
+}
+
+// trimList trims every item of a comma-separated config list and drops empty ones
+func trimList(list []string) []string {
+	var out []string
+	for _, item := range list {
+		if item = strings.TrimSpace(item); item != "" {
+			out = append(out, item)
+		}
+	}
+	return out
+}
+
+type viewerKey struct{}
+
+// WithViewer returns a context for requests made on behalf of a user in groups. The access policy applies to every
+// object returned by Service for that context. Without a viewer, requests are made on behalf of an anonymous user
+// who is in no groups.
+func WithViewer(ctx context.Context, groups []string) context.Context {
+	return context.WithValue(ctx, viewerKey{}, groups)
+}
+
+func viewerGroups(ctx context.Context) []string {
+	groups, _ := ctx.Value(viewerKey{}).([]string)
+	return groups
+}
+
+// CanView returns true if a user in groups may see an object. The first rule matching the type and name of the
+// object decides; objects that no rule matches are visible to everyone.
+func (p *Policy) CanView(groups []string, objectType string, name string) bool {
+	for _, r := range p.rules {
+		if r.Type != objectType {
+			continue
+		}
+		if ok, _ := path.Match(r.Pattern, name); ok {
+			return memberOf(groups, r.Groups)
+		}
+	}
+	return true
+}
+
+// RedactorFor returns the Redactor of every redaction that applies to a user in groups, or nil if none do
+func (p *Policy) RedactorFor(groups []string) *Redactor {
+	var out *Redactor
+	for _, r := range p.redactions {
+		if memberOf(groups, r.groups) {
+			continue
+		}
+		if out == nil {
+			out = r.redactor
+			continue
+		}
+		out = &Redactor{
+			paths: append(slices.Clip(out.paths), r.redactor.paths...),
+			keys:  append(slices.Clip(out.keys), r.redactor.keys...),
+		}
+	}
+	return out
+}
+
+func memberOf(groups []string, allowed []string) bool {
+	for _, g := range groups {
+		if slices.Contains(allowed, g) {
+			return true
+		}
+	}
+	return false
+}
+
+// canView returns true if the viewer of ctx may see an object
+func (s Service) canView(ctx context.Context, objectType string, name string) bool {
+	return s.policy == nil || s.policy.CanView(viewerGroups(ctx), objectType, name)
+}
+
+// redactorFor returns the Redactor for the viewer of ctx, or nil if nothing is redacted for them
+func (s Service) redactorFor(ctx context.Context) *Redactor {
+	if s.policy == nil {
+		return nil
+	}
+	return s.policy.RedactorFor(viewerGroups(ctx))
+}
+
+// redactNode returns a copy of node without the values the viewer of ctx may not see. Nodes are shared by the cache,
+// so node itself is never modified.
+func (s Service) redactNode(ctx context.Context, node *Node) *Node {
+	r := s.redactorFor(ctx)
+	if node == nil || r.empty() {
+		return node
+	}
+
+	n := *node
+	n.DefaultAttributes = r.RedactMap(n.DefaultAttributes)
+	n.NormalAttributes = r.RedactMap(n.NormalAttributes)
+	n.OverrideAttributes = r.RedactMap(n.OverrideAttributes)
+	n.AutomaticAttributes = r.RedactMap(n.AutomaticAttributes)
+	n.MergedAttributes = r.RedactMap(n.MergedAttributes)
+	return &n
+}
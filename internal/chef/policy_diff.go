@@ -0,0 +1,145 @@
+package chef
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/drewhammond/chefbrowser/internal/util"
+	"github.com/go-chef/chef"
+	"golang.org/x/sync/errgroup"
+)
+
+var ErrPolicyNotInGroup = fmt.Errorf("policy %w in policy group", ErrNotFound)
+
+// PolicyRevisionRef identifies one side of a policy comparison
+type PolicyRevisionRef struct {
+	Revision string `json:"revision"`
+	// Group is the policy group the revision was looked up from, if any
+	Group string `json:"group,omitempty"`
+}
+
+// CookbookLockDiff is a cookbook lock that was added, removed or changed between two policy revisions. A lock
+// changes if either its version or its identifier (the hash of the cookbook contents) changes.
+type CookbookLockDiff struct {
+	Name           string `json:"name"`
+	Kind           string `json:"kind"`
+	FromVersion    string `json:"from_version,omitempty"`
+	ToVersion      string `json:"to_version,omitempty"`
+	FromIdentifier string `json:"from_identifier,omitempty"`
+	ToIdentifier   string `json:"to_identifier,omitempty"`
+}
+
+// PolicyComparison holds every difference between two revisions of a policy
+type PolicyComparison struct {
+	Policy        string             `json:"policy"`
+	From          PolicyRevisionRef  `json:"from"`
+	To            PolicyRevisionRef  `json:"to"`
+	RunList       ListDiff           `json:"run_list"`
+	CookbookLocks []CookbookLockDiff `json:"cookbook_locks"`
+	// Attributes compares the default and override attributes of both revisions
+	Attributes []AttributeDiff `json:"attributes"`
+}
+
+// ComparePolicyRevisions returns the differences between two revisions of a policy
+func (s Service) ComparePolicyRevisions(ctx context.Context, name string, from string, to string) (*PolicyComparison, error) {
+	return s.comparePolicies(ctx, name, PolicyRevisionRef{Revision: from}, PolicyRevisionRef{Revision: to})
+}
+
+// ComparePolicyGroups returns the differences between the revisions of a policy assigned to two policy groups,
+// e.g. staging and prod
+func (s Service) ComparePolicyGroups(ctx context.Context, name string, fromGroup string, toGroup string) (*PolicyComparison, error) {
+	var from, to PolicyRevisionRef
+
+	g, gctx := errgroup.WithContext(ctx)
+	g.Go(func() (err error) {
+		from, err = s.policyGroupRevision(gctx, name, fromGroup)
+		return err
+	})
+	g.Go(func() (err error) {
+		to, err = s.policyGroupRevision(gctx, name, toGroup)
+		return err
+	})
+	if err := g.Wait(); err != nil {
+		return nil, err
+	}
+
+	return s.comparePolicies(ctx, name, from, to)
+}
+
+// policyGroupRevision returns the revision of the policy that is assigned to a policy group
+func (s Service) policyGroupRevision(ctx context.Context, name string, group string) (PolicyRevisionRef, error) {
+	pg, err := s.GetPolicyGroup(ctx, group)
+	if err != nil {
+		return PolicyRevisionRef{}, err
+	}
+
+	revision := pg.Policies[name]["revision_id"]
+	if revision == "" {
+		return PolicyRevisionRef{}, fmt.Errorf("%w: %s is not assigned to %s", ErrPolicyNotInGroup, name, group)
+	}
+	return PolicyRevisionRef{Revision: revision, Group: group}, nil
+}
+
+func (s Service) comparePolicies(ctx context.Context, name string, from PolicyRevisionRef, to PolicyRevisionRef) (*PolicyComparison, error) {
+	var a, b chef.RevisionDetailsResponse
+
+	g, gctx := errgroup.WithContext(ctx)
+	g.Go(func() (err error) {
+		a, err = s.GetPolicyRevision(gctx, name, from.Revision)
+		return err
+	})
+	g.Go(func() (err error) {
+		b, err = s.GetPolicyRevision(gctx, name, to.Revision)
+		return err
+	})
+	if err := g.Wait(); err != nil {
+		return nil, err
+	}
+
+	c := comparePolicyRevisions(a, b)
+	c.Policy, c.From, c.To = name, from, to
+	return c, nil
+}
+
+func comparePolicyRevisions(a chef.RevisionDetailsResponse, b chef.RevisionDetailsResponse) *PolicyComparison {
+	return &PolicyComparison{
+		RunList:       DiffLists(a.RunList, b.RunList),
+		CookbookLocks: diffCookbookLocks(a.CookbookLocks, b.CookbookLocks),
+		Attributes: []AttributeDiff{
+			{Level: LevelDefault, Diffs: DiffValues(util.MakeJSONPath(a.DefaultAttributes, "$"), util.MakeJSONPath(b.DefaultAttributes, "$"))},
+			{Level: LevelOverride, Diffs: DiffValues(util.MakeJSONPath(a.OverrideAttributes, "$"), util.MakeJSONPath(b.OverrideAttributes, "$"))},
+		},
+	}
+}
+
+// diffCookbookLocks compares the cookbook locks of two policy revisions and returns the differences sorted by name
+func diffCookbookLocks(a map[string]chef.CookbookLock, b map[string]chef.CookbookLock) []CookbookLockDiff {
+	diffs := []CookbookLockDiff{}
+	for name, from := range a {
+		to, ok := b[name]
+		switch {
+		case !ok:
+			diffs = append(diffs, CookbookLockDiff{Name: name, Kind: DiffRemoved, FromVersion: from.Version, FromIdentifier: from.Identifier})
+		case from.Version != to.Version || from.Identifier != to.Identifier:
+			diffs = append(diffs, CookbookLockDiff{
+				Name:           name,
+				Kind:           DiffChanged,
+				FromVersion:    from.Version,
+				ToVersion:      to.Version,
+				FromIdentifier: from.Identifier,
+				ToIdentifier:   to.Identifier,
+			})
+		}
+	}
+	for name, to := range b {
+		if _, ok := a[name]; !ok {
+			diffs = append(diffs, CookbookLockDiff{Name: name, Kind: DiffAdded, ToVersion: to.Version, ToIdentifier: to.Identifier})
+		}
+	}
+
+	sort.Slice(diffs, func(i, j int) bool {
+		return diffs[i].Name < diffs[j].Name
+	})
+	return diffs
+}
@@ -0,0 +1,104 @@
+package chef
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/drewhammond/chefbrowser/config"
+	"github.com/go-chef/chef"
+)
+
+func TestDiffCookbookLocks(t *testing.T) {
+	a := map[string]chef.CookbookLock{
+		"apt":   {Version: "7.0.0", Identifier: "aaa"},
+		"nginx": {Version: "1.0.0", Identifier: "bbb"},
+		"old":   {Version: "0.1.0", Identifier: "ccc"},
+		"web":   {Version: "2.0.0", Identifier: "ddd"},
+	}
+	b := map[string]chef.CookbookLock{
+		"apt":   {Version: "7.0.0", Identifier: "aaa"},
+		"nginx": {Version: "1.1.0", Identifier: "eee"},
+		"new":   {Version: "0.2.0", Identifier: "fff"},
+		"web":   {Version: "2.0.0", Identifier: "ggg"},
+	}
+
+	expected := []CookbookLockDiff{
+		{Name: "new", Kind: DiffAdded, ToVersion: "0.2.0", ToIdentifier: "fff"},
+		{Name: "nginx", Kind: DiffChanged, FromVersion: "1.0.0", ToVersion: "1.1.0", FromIdentifier: "bbb", ToIdentifier: "eee"},
+		{Name: "old", Kind: DiffRemoved, FromVersion: "0.1.0", FromIdentifier: "ccc"},
+		{Name: "web", Kind: DiffChanged, FromVersion: "2.0.0", ToVersion: "2.0.0", FromIdentifier: "ddd", ToIdentifier: "ggg"},
+	}
+	if got := diffCookbookLocks(a, b); !reflect.DeepEqual(got, expected) {
+		t.Errorf("diffCookbookLocks() = %v, want %v", got, expected)
+	}
+}
+
+func TestComparePolicyRevisions(t *testing.T) {
+	a := chef.RevisionDetailsResponse{
+		RunList:            []string{"recipe[base::default]", "recipe[web::default]"},
+		DefaultAttributes:  map[string]interface{}{"web": map[string]interface{}{"port": 80.0}},
+		OverrideAttributes: map[string]interface{}{"debug": true},
+	}
+	b := chef.RevisionDetailsResponse{
+		RunList:           []string{"recipe[base::default]", "recipe[web::default]", "recipe[monitoring::default]"},
+		DefaultAttributes: map[string]interface{}{"web": map[string]interface{}{"port": 8080.0}},
+	}
+
+	c := comparePolicyRevisions(a, b)
+	if !reflect.DeepEqual(c.RunList.Added, []string{"recipe[monitoring::default]"}) || c.RunList.Equal {
+		t.Errorf("unexpected run list diff: %+v", c.RunList)
+	}
+
+	expected := []AttributeDiff{
+		{Level: LevelDefault, Diffs: []ValueDiff{{Path: "$.web.port", Kind: DiffChanged, A: 80.0, B: 8080.0}}},
+		{Level: LevelOverride, Diffs: []ValueDiff{{Path: "$.debug", Kind: DiffRemoved, A: true}}},
+	}
+	if !reflect.DeepEqual(c.Attributes, expected) {
+		t.Errorf("unexpected attribute diffs: %+v", c.Attributes)
+	}
+}
+
+func TestComparePolicyGroups(t *testing.T) {
+	responses := map[string]string{
+		"/policy_groups/staging":      `{"policies": {"web": {"revision_id": "222"}}}`,
+		"/policy_groups/prod":         `{"policies": {"web": {"revision_id": "111"}, "db": {"revision_id": "333"}}}`,
+		"/policy_groups/empty":        `{"policies": {}}`,
+		"/policies/web/revisions/111": `{"revision_id": "111", "run_list": ["recipe[web]"], "cookbook_locks": {"web": {"version": "1.0.0", "identifier": "a"}}}`,
+		"/policies/web/revisions/222": `{"revision_id": "222", "run_list": ["recipe[web]"], "cookbook_locks": {"web": {"version": "1.1.0", "identifier": "b"}}}`,
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, ok := responses[r.URL.Path]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(body))
+	}))
+	defer srv.Close()
+
+	s := newTestService(t, srv.URL, &config.Config{})
+
+	c, err := s.ComparePolicyGroups(context.Background(), "web", "prod", "staging")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.From != (PolicyRevisionRef{Revision: "111", Group: "prod"}) || c.To != (PolicyRevisionRef{Revision: "222", Group: "staging"}) {
+		t.Errorf("unexpected revisions: %+v, %+v", c.From, c.To)
+	}
+	if len(c.CookbookLocks) != 1 || c.CookbookLocks[0].FromVersion != "1.0.0" || c.CookbookLocks[0].ToVersion != "1.1.0" {
+		t.Errorf("unexpected cookbook lock diffs: %+v", c.CookbookLocks)
+	}
+	if !c.RunList.Equal {
+		t.Errorf("expected equal run lists: %+v", c.RunList)
+	}
+
+	_, err = s.ComparePolicyGroups(context.Background(), "web", "prod", "empty")
+	if !errors.Is(err, ErrPolicyNotInGroup) || !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected a not found error for a group without the policy, actual: %v", err)
+	}
+}
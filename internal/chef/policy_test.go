@@ -0,0 +1,153 @@
+package chef
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/drewhammond/chefbrowser/config"
+)
+
+func TestPolicyCanView(t *testing.T) {
+	policy, err := NewPolicy([]AccessRule{
+		{Type: ObjectTypeDataBags, Pattern: "secrets-ci", Groups: []string{"sre", "ci"}},
+		{Type: ObjectTypeDataBags, Pattern: "secrets-*", Groups: []string{"sre"}},
+		{Type: ObjectTypeDataBags, Pattern: "vault"},
+	}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		groups   []string
+		name     string
+		expected bool
+	}{
+		{nil, "users", true},
+		{nil, "secrets-prod", false},
+		{[]string{"dev"}, "secrets-prod", false},
+		{[]string{"dev", "sre"}, "secrets-prod", true},
+		{[]string{"ci"}, "secrets-ci", true},
+		{[]string{"ci"}, "secrets-prod", false},
+		{[]string{"sre"}, "vault", false},
+	}
+	for _, tt := range tests {
+		if got := policy.CanView(tt.groups, ObjectTypeDataBags, tt.name); got != tt.expected {
+			t.Errorf("CanView(%v, %q) = %v, want %v", tt.groups, tt.name, got, tt.expected)
+		}
+	}
+}
+
+func TestNewPolicyInvalid(t *testing.T) {
+	tests := []struct {
+		rules      []AccessRule
+		redactions []AttributeRedaction
+	}{
+		{rules: []AccessRule{{Type: "nodes", Pattern: "*"}}},
+		{rules: []AccessRule{{Type: ObjectTypeDataBags, Pattern: "[secrets"}}},
+		{rules: []AccessRule{{Type: ObjectTypeDataBags}}},
+		{redactions: []AttributeRedaction{{Groups: []string{"sre"}}}},
+		{redactions: []AttributeRedaction{{Keys: []string{"pass(word"}}}},
+	}
+	for _, tt := range tests {
+		if _, err := NewPolicy(tt.rules, tt.redactions); err == nil {
+			t.Errorf("expected an error for rules %+v and redactions %+v", tt.rules, tt.redactions)
+		}
+	}
+}
+
+func TestPolicyAppliesToService(t *testing.T) {
+	responses := map[string]string{
+		"/data":              `{"users": "/data/users", "secrets-prod": "/data/secrets-prod"}`,
+		"/data/secrets-prod": `{"db": "/data/secrets-prod/db"}`,
+		"/data/users/alice":  `{"id": "alice", "password": "hunter2"}`,
+		"/nodes/web":         `{"name": "web", "automatic": {"etc": {"passwd": {"root": {"uid": 0}}}, "platform": "ubuntu"}}`,
+		"/roles/db":          `{"name": "db", "override_attributes": {"mysql": {"password": "hunter2", "port": 3306}}}`,
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, ok := responses[r.URL.Path]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(body))
+	}))
+	defer srv.Close()
+
+	cfg := &config.Config{}
+	cfg.Cache.Enabled = true
+	s := newTestService(t, srv.URL, cfg)
+	s.policy, _ = NewPolicy(
+		[]AccessRule{{Type: ObjectTypeDataBags, Pattern: "secrets-*", Groups: []string{"sre"}}},
+		[]AttributeRedaction{
+			{Paths: []string{"$.etc.passwd"}, Groups: []string{"sre"}},
+			{Keys: []string{"password"}},
+		},
+	)
+	anonymous := context.Background()
+	sre := WithViewer(context.Background(), []string{"sre"})
+
+	databags, err := s.GetDatabags(anonymous)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := databags["secrets-prod"]; ok || len(databags) != 1 {
+		t.Errorf("expected secrets-prod to be hidden, actual: %v", databags)
+	}
+	if databags, _ = s.GetDatabags(sre); len(databags) != 2 {
+		t.Errorf("expected sre to see every data bag, actual: %v", databags)
+	}
+
+	if _, err = s.GetDatabagItems(anonymous, "secrets-prod"); !errors.Is(err, ErrDenied) {
+		t.Errorf("expected ErrDenied, actual: %v", err)
+	}
+	if _, err = s.GetDatabagItemContent(anonymous, "secrets-prod", "db"); !errors.Is(err, ErrDenied) {
+		t.Errorf("expected ErrDenied, actual: %v", err)
+	}
+	if _, err = s.Search(anonymous, "secrets-prod", "*:*"); !errors.Is(err, ErrDenied) {
+		t.Errorf("expected ErrDenied, actual: %v", err)
+	}
+	if items, err := s.GetDatabagItems(sre, "secrets-prod"); err != nil || len(*items) != 1 {
+		t.Errorf("expected sre to see the items, actual: %v, %v", items, err)
+	}
+
+	node, err := s.GetNode(anonymous, "web")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v, _ := node.GetEffectiveAttributeValue("etc", "passwd"); v != RedactedValue {
+		t.Errorf("expected etc.passwd to be redacted, actual: %v", v)
+	}
+	if v := node.AutomaticAttributes["etc"].(map[string]interface{})["passwd"]; v != RedactedValue {
+		t.Errorf("expected automatic etc.passwd to be redacted, actual: %v", v)
+	}
+
+	// the cached node must not have been redacted for everyone
+	node, err = s.GetNode(sre, "web")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v, _ := node.GetEffectiveAttributeValue("etc", "passwd", "root"); v == nil {
+		t.Error("expected sre to see etc.passwd")
+	}
+
+	// redactions without groups apply to everyone
+	item, err := s.GetDatabagItemContent(sre, "users", "alice")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v := item.(map[string]interface{})["password"]; v != RedactedValue {
+		t.Errorf("expected the data bag item password to be redacted, actual: %v", v)
+	}
+	role, err := s.GetRole(sre, "db")
+	if err != nil {
+		t.Fatal(err)
+	}
+	mysql := role.OverrideAttributes.(map[string]interface{})["mysql"].(map[string]interface{})
+	if mysql["password"] != RedactedValue || mysql["port"] != 3306.0 {
+		t.Errorf("expected only the role password to be redacted, actual: %v", mysql)
+	}
+}
@@ -0,0 +1,169 @@
+package chef
+
+import (
+	"fmt"
+	"maps"
+	"path"
+	"regexp"
+	"slices"
+	"strconv"
+)
+
+// RedactedValue replaces every value the user may not see
+const RedactedValue = "[redacted]"
+
+// Redactor replaces sensitive values in the payloads of chef objects (node, role, environment and policy attributes
+// and data bag items) with RedactedValue. A value is redacted if its path matches one of the path globs, or its key
+// matches one of the key patterns, in which case everything below it is redacted too.
+//
+// Payloads may be shared by the cache, so they are never modified; only the maps and arrays leading to a redacted
+// value are copied.
+type Redactor struct {
+	// paths are split into keys, each of which may be a glob (see path.Match), e.g. ec2.*.token
+	paths [][]string
+	keys  []*regexp.Regexp
+}
+
+// NewRedactor returns a Redactor for attribute path globs such as "$.ec2.*.token" and key name patterns such as
+// "password". Key patterns are case-insensitive regular expressions that match anywhere in a key, so "token" also
+// redacts "api_token".
+func NewRedactor(paths []string, keys []string) (*Redactor, error) {
+	r := &Redactor{}
+	for _, p := range paths {
+		glob := ParseAttributePath(p)
+		if len(glob) == 0 {
+			return nil, fmt.Errorf("empty redaction path %q", p)
+		}
+		for _, key := range glob {
+			if _, err := path.Match(key, ""); err != nil {
+				return nil, fmt.Errorf("invalid redaction path %q: %w", p, err)
+			}
+		}
+		r.paths = append(r.paths, glob)
+	}
+	for _, k := range keys {
+		re, err := regexp.Compile("(?i)" + k)
+		if err != nil {
+			return nil, fmt.Errorf("invalid redaction key pattern %q: %w", k, err)
+		}
+		r.keys = append(r.keys, re)
+	}
+	return r, nil
+}
+
+func (r *Redactor) empty() bool {
+	return r == nil || (len(r.paths) == 0 && len(r.keys) == 0)
+}
+
+// Redact returns v with every sensitive value within it redacted. Paths are relative to v.
+func (r *Redactor) Redact(v interface{}) interface{} {
+	if r.empty() {
+		return v
+	}
+	v, _ = r.redact(v, nil)
+	return v
+}
+
+// RedactMap is Redact for attribute maps
+func (r *Redactor) RedactMap(attrs map[string]interface{}) map[string]interface{} {
+	if r.empty() || attrs == nil {
+		return attrs
+	}
+	v, _ := r.redact(attrs, nil)
+	return v.(map[string]interface{})
+}
+
+// RedactAt redacts a value found at a path, such as a column of a node table. The value is redacted entirely if its
+// path or any path above it is sensitive.
+func (r *Redactor) RedactAt(v interface{}, at []string) interface{} {
+	if r.empty() || v == nil {
+		return v
+	}
+	for i := 1; i <= len(at); i++ {
+		if r.matches(at[:i]) {
+			return RedactedValue
+		}
+	}
+	v, _ = r.redact(v, at)
+	return v
+}
+
+// redact walks v (found at path at) and returns it with sensitive values replaced, along with whether anything was
+// replaced
+func (r *Redactor) redact(v interface{}, at []string) (interface{}, bool) {
+	if len(r.keys) == 0 && !r.mayMatchBelow(at) {
+		return v, false
+	}
+
+	switch t := v.(type) {
+	case map[string]interface{}:
+		var out map[string]interface{}
+		for k, child := range t {
+			if redacted, ok := r.redactChild(child, append(slices.Clip(at), k)); ok {
+				if out == nil {
+					out = maps.Clone(t)
+				}
+				out[k] = redacted
+			}
+		}
+		if out != nil {
+			return out, true
+		}
+	case []interface{}:
+		var out []interface{}
+		for i, child := range t {
+			if redacted, ok := r.redactChild(child, append(slices.Clip(at), strconv.Itoa(i))); ok {
+				if out == nil {
+					out = slices.Clone(t)
+				}
+				out[i] = redacted
+			}
+		}
+		if out != nil {
+			return out, true
+		}
+	}
+	return v, false
+}
+
+func (r *Redactor) redactChild(v interface{}, at []string) (interface{}, bool) {
+	if r.matches(at) {
+		return RedactedValue, true
+	}
+	return r.redact(v, at)
+}
+
+// matches returns true if the value at a path is sensitive
+func (r *Redactor) matches(at []string) bool {
+	key := at[len(at)-1]
+	for _, re := range r.keys {
+		if re.MatchString(key) {
+			return true
+		}
+	}
+	for _, glob := range r.paths {
+		if len(glob) == len(at) && globMatch(glob, at) {
+			return true
+		}
+	}
+	return false
+}
+
+// mayMatchBelow returns true if any path glob could match a value below the path at
+func (r *Redactor) mayMatchBelow(at []string) bool {
+	for _, glob := range r.paths {
+		if len(glob) > len(at) && globMatch(glob[:len(at)], at) {
+			return true
+		}
+	}
+	return false
+}
+
+func globMatch(glob []string, keys []string) bool {
+	for i, pattern := range glob {
+		if ok, _ := path.Match(pattern, keys[i]); !ok {
+			return false
+		}
+	}
+	return true
+}
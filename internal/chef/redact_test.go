@@ -0,0 +1,151 @@
+package chef
+
+import (
+	"bufio"
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/drewhammond/chefbrowser/config"
+)
+
+func TestRedact(t *testing.T) {
+	r, err := NewRedactor([]string{"$.etc.passwd", "ec2.*.token", "users.*.ssh_keys"}, []string{"password", "^private_key$"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	attrs := map[string]interface{}{
+		"etc": map[string]interface{}{
+			"passwd": map[string]interface{}{"root": "x"},
+			"group":  "wheel",
+		},
+		"ec2": map[string]interface{}{
+			"iam":  map[string]interface{}{"token": "abc", "role": "web"},
+			"spot": map[string]interface{}{"token": "def"},
+		},
+		"users": []interface{}{
+			map[string]interface{}{"name": "alice", "ssh_keys": []interface{}{"ssh-ed25519 AAAA"}},
+		},
+		"mysql": map[string]interface{}{
+			"root_Password": "hunter2",
+			"private_key":   "-----BEGIN",
+			"private_keys":  "kept",
+		},
+		"platform": "ubuntu",
+	}
+
+	expected := map[string]interface{}{
+		"etc": map[string]interface{}{
+			"passwd": RedactedValue,
+			"group":  "wheel",
+		},
+		"ec2": map[string]interface{}{
+			"iam":  map[string]interface{}{"token": RedactedValue, "role": "web"},
+			"spot": map[string]interface{}{"token": RedactedValue},
+		},
+		"users": []interface{}{
+			map[string]interface{}{"name": "alice", "ssh_keys": RedactedValue},
+		},
+		"mysql": map[string]interface{}{
+			"root_Password": RedactedValue,
+			"private_key":   RedactedValue,
+			"private_keys":  "kept",
+		},
+		"platform": "ubuntu",
+	}
+	if got := r.RedactMap(attrs); !reflect.DeepEqual(got, expected) {
+		t.Errorf("RedactMap() = %v, want %v", got, expected)
+	}
+
+	// payloads are shared by the cache and must never be modified
+	if attrs["etc"].(map[string]interface{})["passwd"] == RedactedValue ||
+		attrs["mysql"].(map[string]interface{})["root_Password"] != "hunter2" ||
+		attrs["users"].([]interface{})[0].(map[string]interface{})["ssh_keys"] == RedactedValue {
+		t.Error("expected the attributes passed in to be left as is")
+	}
+}
+
+func TestRedactUnchanged(t *testing.T) {
+	r, _ := NewRedactor([]string{"etc.passwd"}, nil)
+	attrs := map[string]interface{}{"etc": map[string]interface{}{"group": "wheel"}}
+	if got := r.RedactMap(attrs); reflect.ValueOf(got).Pointer() != reflect.ValueOf(attrs).Pointer() {
+		t.Error("expected attributes without sensitive values not to be copied")
+	}
+
+	var nilRedactor *Redactor
+	if got := nilRedactor.Redact("value"); got != "value" {
+		t.Errorf("expected a nil redactor to redact nothing, actual: %v", got)
+	}
+}
+
+func TestRedactAt(t *testing.T) {
+	r, _ := NewRedactor([]string{"etc.passwd"}, []string{"token"})
+	tests := []struct {
+		at       string
+		value    interface{}
+		expected interface{}
+	}{
+		{"etc.passwd", map[string]interface{}{"root": "x"}, RedactedValue},
+		{"etc.passwd.root", "x", RedactedValue},
+		{"etc", map[string]interface{}{"passwd": "x", "group": "wheel"}, map[string]interface{}{"passwd": RedactedValue, "group": "wheel"}},
+		{"etc.passwd", nil, nil},
+		{"cloud.api_token", "abc", RedactedValue},
+		{"cloud", map[string]interface{}{"token": "abc"}, map[string]interface{}{"token": RedactedValue}},
+		{"platform", "ubuntu", "ubuntu"},
+		{"kernel.modules.loaded", "yes", "yes"},
+	}
+	for _, tt := range tests {
+		if got := r.RedactAt(tt.value, ParseAttributePath(tt.at)); !reflect.DeepEqual(got, tt.expected) {
+			t.Errorf("RedactAt(%q) = %v, want %v", tt.at, got, tt.expected)
+		}
+	}
+}
+
+func TestNewRedactorInvalid(t *testing.T) {
+	if _, err := NewRedactor([]string{"etc.[passwd"}, nil); err == nil {
+		t.Error("expected an invalid glob to be rejected")
+	}
+	if _, err := NewRedactor(nil, []string{"(token"}); err == nil {
+		t.Error("expected an invalid key pattern to be rejected")
+	}
+	if _, err := NewRedactor([]string{"$."}, nil); err == nil {
+		t.Error("expected an empty path to be rejected")
+	}
+}
+
+func TestDefaultRedactionKeys(t *testing.T) {
+	var keys []string
+	scanner := bufio.NewScanner(bytes.NewReader(config.DefaultConfig))
+	for scanner.Scan() {
+		if v, ok := strings.CutPrefix(scanner.Text(), "keys = "); ok {
+			keys = strings.Split(v, ",")
+		}
+	}
+	r, err := NewRedactor(nil, keys)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		key      string
+		redacted bool
+	}{
+		{"password", true},
+		{"DB_PASSWORD", true},
+		{"client_secret", true},
+		{"api-token", true},
+		{"ssl_private_key", true},
+		{"token_bucket_size", false},
+		{"secret_santa_enabled", false},
+		{"password_min_length", false},
+		{"tokenizer", false},
+	}
+	for _, tt := range tests {
+		got := r.Redact(map[string]interface{}{tt.key: "value"}).(map[string]interface{})[tt.key]
+		if (got == RedactedValue) != tt.redacted {
+			t.Errorf("%s: expected redacted to be %v, actual value: %v", tt.key, tt.redacted, got)
+		}
+	}
+}
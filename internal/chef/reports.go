@@ -0,0 +1,158 @@
+package chef
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"math"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Node attributes that reports can group nodes by
+const (
+	GroupByEnvironment = "environment"
+	GroupByPolicyGroup = "policy_group"
+)
+
+// StaleNode is a node that has not completed a chef-client run within the report threshold
+type StaleNode struct {
+	Name        string `json:"name"`
+	FQDN        string `json:"fqdn"`
+	Platform    string `json:"platform"`
+	Environment string `json:"chef_environment"`
+	PolicyName  string `json:"policy_name"`
+	PolicyGroup string `json:"policy_group"`
+	// LastRun is the time of the last chef-client run (ohai_time), or nil if the node has never completed a run
+	LastRun *time.Time `json:"last_run"`
+}
+
+// StaleNodeGroup holds the stale nodes of a single environment or policy group
+type StaleNodeGroup struct {
+	Name  string      `json:"name"`
+	Nodes []StaleNode `json:"nodes"`
+}
+
+// StaleNodeReport lists every node whose last chef-client run is older than Cutoff
+type StaleNodeReport struct {
+	Threshold string           `json:"threshold"`
+	Cutoff    time.Time        `json:"cutoff"`
+	GroupBy   string           `json:"group_by"`
+	Total     int              `json:"total"`
+	Groups    []StaleNodeGroup `json:"groups"`
+}
+
+// GetStaleNodes returns the nodes that have not completed a chef-client run within threshold, grouped by
+// environment or policy group (see GroupByEnvironment and GroupByPolicyGroup). Nodes that have never completed
+// a run are always included. The run times of every node are fetched with a single partial search.
+func (s Service) GetStaleNodes(ctx context.Context, threshold time.Duration, groupBy string) (*StaleNodeReport, error) {
+	nodes, err := cached(ctx, s.cache, "report/stale", s.config.Cache.NodeTTL, s.searchNodeRuns)
+	if err != nil {
+		return nil, err
+	}
+
+	return buildStaleNodeReport(nodes, time.Now(), threshold, groupBy), nil
+}
+
+// searchNodeRuns returns the last chef-client run of every node
+func (s Service) searchNodeRuns(ctx context.Context) ([]StaleNode, error) {
+	rows, err := s.partialSearch(ctx, IndexNode, "*:*", map[string]interface{}{
+		"name":             []string{"name"},
+		"fqdn":             []string{"fqdn"},
+		"platform":         []string{"platform"},
+		"chef_environment": []string{"chef_environment"},
+		"policy_name":      []string{"policy_name"},
+		"policy_group":     []string{"policy_group"},
+		"ohai_time":        []string{"ohai_time"},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	nodes := make([]StaleNode, 0, len(rows))
+	for _, i := range rows {
+		var row struct {
+			StaleNode
+			OhaiTime *float64 `json:"ohai_time"`
+		}
+		_ = json.Unmarshal(i, &row)
+
+		node := row.StaleNode
+		if row.OhaiTime != nil && *row.OhaiTime > 0 {
+			sec, frac := math.Modf(*row.OhaiTime)
+			t := time.Unix(int64(sec), int64(frac*1e9)).UTC()
+			node.LastRun = &t
+		}
+		nodes = append(nodes, node)
+	}
+
+	return nodes, nil
+}
+
+func buildStaleNodeReport(nodes []StaleNode, now time.Time, threshold time.Duration, groupBy string) *StaleNodeReport {
+	if groupBy != GroupByPolicyGroup {
+		groupBy = GroupByEnvironment
+	}
+
+	report := &StaleNodeReport{
+		Threshold: threshold.String(),
+		Cutoff:    now.Add(-threshold).UTC(),
+		GroupBy:   groupBy,
+		Groups:    []StaleNodeGroup{},
+	}
+
+	groups := map[string][]StaleNode{}
+	for _, n := range nodes {
+		if n.LastRun != nil && !n.LastRun.Before(report.Cutoff) {
+			continue
+		}
+
+		group := n.Environment
+		if groupBy == GroupByPolicyGroup {
+			group = n.PolicyGroup
+		}
+		groups[group] = append(groups[group], n)
+		report.Total++
+	}
+
+	for name, nodes := range groups {
+		// oldest first, starting with nodes that have never completed a run
+		slices.SortFunc(nodes, func(a, b StaleNode) int {
+			switch {
+			case a.LastRun == nil && b.LastRun != nil:
+				return -1
+			case a.LastRun != nil && b.LastRun == nil:
+				return 1
+			case a.LastRun != nil && !a.LastRun.Equal(*b.LastRun):
+				return a.LastRun.Compare(*b.LastRun)
+			}
+			return strings.Compare(a.Name, b.Name)
+		})
+		report.Groups = append(report.Groups, StaleNodeGroup{Name: name, Nodes: nodes})
+	}
+
+	slices.SortFunc(report.Groups, func(a, b StaleNodeGroup) int {
+		return strings.Compare(a.Name, b.Name)
+	})
+
+	return report
+}
+
+// WriteCSV writes every node in the report as CSV, one node per line in the same order as the report
+func (r *StaleNodeReport) WriteCSV(w io.Writer) error {
+	cw := csv.NewWriter(w)
+	_ = cw.Write([]string{"name", "fqdn", "platform", "chef_environment", "policy_name", "policy_group", "last_run"})
+	for _, g := range r.Groups {
+		for _, n := range g.Nodes {
+			var lastRun string
+			if n.LastRun != nil {
+				lastRun = n.LastRun.Format(time.RFC3339)
+			}
+			_ = cw.Write([]string{n.Name, n.FQDN, n.Platform, n.Environment, n.PolicyName, n.PolicyGroup, lastRun})
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
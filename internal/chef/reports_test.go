@@ -0,0 +1,86 @@
+package chef
+
+import (
+	"bytes"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestBuildStaleNodeReport(t *testing.T) {
+	now := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	at := func(d time.Duration) *time.Time {
+		t := now.Add(-d)
+		return &t
+	}
+	nodes := []StaleNode{
+		{Name: "fresh", Environment: "prod", LastRun: at(time.Hour)},
+		{Name: "old", Environment: "prod", LastRun: at(48 * time.Hour)},
+		{Name: "older", Environment: "prod", LastRun: at(72 * time.Hour)},
+		{Name: "never", Environment: "prod"},
+		{Name: "policy", Environment: "_default", PolicyName: "web", PolicyGroup: "staging", LastRun: at(30 * time.Hour)},
+	}
+
+	tests := []struct {
+		name     string
+		groupBy  string
+		expected map[string][]string
+		order    []string
+	}{
+		{
+			"by environment",
+			GroupByEnvironment,
+			map[string][]string{"_default": {"policy"}, "prod": {"never", "older", "old"}},
+			[]string{"_default", "prod"},
+		},
+		{
+			"by policy group",
+			GroupByPolicyGroup,
+			map[string][]string{"": {"never", "older", "old"}, "staging": {"policy"}},
+			[]string{"", "staging"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			report := buildStaleNodeReport(nodes, now, 24*time.Hour, tt.groupBy)
+			if report.Total != 4 {
+				t.Errorf("expected 4 stale nodes, actual: %d", report.Total)
+			}
+			if len(report.Groups) != len(tt.order) {
+				t.Fatalf("expected %d groups, actual: %d", len(tt.order), len(report.Groups))
+			}
+			for i, g := range report.Groups {
+				if g.Name != tt.order[i] {
+					t.Errorf("expected group %d to be %q, actual: %q", i, tt.order[i], g.Name)
+				}
+				var names []string
+				for _, n := range g.Nodes {
+					names = append(names, n.Name)
+				}
+				if !slices.Equal(names, tt.expected[g.Name]) {
+					t.Errorf("group %q: expected %v, actual: %v", g.Name, tt.expected[g.Name], names)
+				}
+			}
+		})
+	}
+}
+
+func TestStaleNodeReportCSV(t *testing.T) {
+	lastRun := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	report := &StaleNodeReport{Groups: []StaleNodeGroup{{Name: "prod", Nodes: []StaleNode{
+		{Name: "web-1", FQDN: "web-1.example.com", Platform: "ubuntu", Environment: "prod", LastRun: &lastRun},
+		{Name: "web,2", Environment: "prod"},
+	}}}}
+
+	var buf bytes.Buffer
+	if err := report.WriteCSV(&buf); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := "name,fqdn,platform,chef_environment,policy_name,policy_group,last_run\n" +
+		"web-1,web-1.example.com,ubuntu,prod,,,2024-06-01T12:00:00Z\n" +
+		"\"web,2\",,,prod,,,\n"
+	if buf.String() != expected {
+		t.Errorf("unexpected CSV:\n%s\nwant:\n%s", buf.String(), expected)
+	}
+}
@@ -0,0 +1,80 @@
+package chef
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// WithTimeout returns a copy of ctx that is cancelled after the configured chef server request timeout
+func (s Service) WithTimeout(ctx context.Context) (context.Context, context.CancelFunc) {
+	if s.config.Chef.RequestTimeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, s.config.Chef.RequestTimeout)
+}
+
+// get performs a signed GET request against the chef server and decodes the JSON response into v
+func (s Service) get(ctx context.Context, path string, v interface{}) error {
+	return s.do(ctx, http.MethodGet, path, nil, v)
+}
+
+// do performs a signed request against the chef server. go-chef does not support contexts, so we build
+// the request with go-chef and attach ctx ourselves before sending it.
+func (s Service) do(parent context.Context, method string, path string, body io.Reader, v interface{}) error {
+	ctx, cancel := s.WithTimeout(parent)
+	defer cancel()
+
+	req, err := s.client.NewRequest(method, path, body)
+	if err != nil {
+		return err
+	}
+
+	res, err := s.client.Do(req.WithContext(ctx), v)
+	if res != nil {
+		_ = res.Body.Close()
+	}
+	// only report a timeout if our own deadline was hit, not if the caller went away
+	if err != nil && parent.Err() == nil && errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return fmt.Errorf("%w: %s %s", ErrTimeout, method, path)
+	}
+
+	return classifyError(parent, err)
+}
+
+// downloadFile fetches a file (e.g. a cookbook file from bookshelf) using the pre-signed URL provided by the chef server
+func downloadFile(ctx context.Context, client *http.Client, url string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			return nil, fmt.Errorf("%w: %w", ErrTimeout, err)
+		}
+		return nil, classifyError(ctx, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		if kind := errorForStatus(resp.StatusCode); kind != nil {
+			return nil, fmt.Errorf("%w: unexpected status downloading file: %s", kind, resp.Status)
+		}
+		return nil, fmt.Errorf("unexpected status downloading file: %s", resp.Status)
+	}
+
+	return io.ReadAll(resp.Body)
+}
+
+// fileClient returns an HTTP client for downloading cookbook files from bookshelf, which is not covered by the
+// signed go-chef client
+func (s Service) fileClient() *http.Client {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = &tls.Config{InsecureSkipVerify: !s.config.Chef.SSLVerify}
+	return &http.Client{Transport: transport}
+}
@@ -0,0 +1,81 @@
+package chef
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/drewhammond/chefbrowser/config"
+	"github.com/go-chef/chef"
+)
+
+var testKey = sync.OnceValue(func() string {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		panic(err)
+	}
+	return string(pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)}))
+})
+
+// newTestService returns a Service that talks to the given test server
+func newTestService(t *testing.T, url string, cfg *config.Config) Service {
+	t.Helper()
+
+	client, err := chef.NewClient(&chef.Config{Name: "test", Key: testKey(), BaseURL: url + "/"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return Service{config: cfg, client: *client, cache: newCache(cfg.Cache.Enabled)}
+}
+
+func TestRequestTimeout(t *testing.T) {
+	release := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-release:
+		case <-r.Context().Done():
+		}
+	}))
+	defer srv.Close()
+	defer close(release)
+
+	cfg := &config.Config{}
+	cfg.Chef.RequestTimeout = 50 * time.Millisecond
+	s := newTestService(t, srv.URL, cfg)
+
+	_, err := s.GetNodes(context.Background())
+	if !errors.Is(err, ErrTimeout) {
+		t.Errorf("expected timeout error, actual: %v", err)
+	}
+}
+
+func TestRequestCancellation(t *testing.T) {
+	release := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-release:
+		case <-r.Context().Done():
+		}
+	}))
+	defer srv.Close()
+	defer close(release)
+
+	s := newTestService(t, srv.URL, &config.Config{})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	_, err := s.GetNodes(ctx)
+	if err == nil || errors.Is(err, ErrTimeout) {
+		t.Errorf("expected cancellation error, actual: %v", err)
+	}
+}
@@ -3,12 +3,14 @@ package chef
 import (
 	"context"
 	"errors"
+	"fmt"
+	"net/url"
 	"sort"
 
 	"github.com/go-chef/chef"
 )
 
-var ErrRoleNotFound = errors.New("role not found")
+var ErrRoleNotFound = fmt.Errorf("role %w", ErrNotFound)
 
 type RoleList struct {
 	Roles []string `json:"roles"`
@@ -18,25 +20,54 @@ type Role struct {
 	*chef.Role
 }
 
-// GetRole will return a single named role
+// GetRole will return a single named role without the attributes the viewer of ctx may not see
 func (s Service) GetRole(ctx context.Context, name string) (*Role, error) {
-	role, err := s.client.Roles.Get(name)
+	role, err := s.getRole(ctx, name)
 	if err != nil {
-		return nil, ErrRoleNotFound
+		return nil, err
 	}
 
-	return &Role{role}, nil
+	redactor := s.redactorFor(ctx)
+	if redactor.empty() {
+		return role, nil
+	}
+	// the cached role is shared, so redact a copy of it
+	r := *role.Role
+	r.DefaultAttributes = redactor.Redact(r.DefaultAttributes)
+	r.OverrideAttributes = redactor.Redact(r.OverrideAttributes)
+	return &Role{&r}, nil
+}
+
+// getRole returns a role with all of its attributes
+func (s Service) getRole(ctx context.Context, name string) (*Role, error) {
+	return cached(ctx, s.cache, "role:"+name, s.config.Cache.RoleTTL, func(ctx context.Context) (*Role, error) {
+		var role chef.Role
+		err := s.get(ctx, "roles/"+url.PathEscape(name), &role)
+		if err != nil {
+			if errors.Is(err, ErrNotFound) {
+				return nil, ErrRoleNotFound
+			}
+			return nil, err
+		}
+
+		return &Role{&role}, nil
+	})
 }
 
 // GetRoles will return a list of all roles found on the server
 func (s Service) GetRoles(ctx context.Context) (*RoleList, error) {
-	roles, err := s.client.Roles.List()
+	return cached(ctx, s.cache, "roles", s.config.Cache.RoleTTL, s.listRoles)
+}
+
+func (s Service) listRoles(ctx context.Context) (*RoleList, error) {
+	var roles chef.RoleListResult
+	err := s.get(ctx, "roles", &roles)
 	if err != nil {
 		return nil, err
 	}
 
 	var rl []string
-	for i := range *roles {
+	for i := range roles {
 		rl = append(rl, i)
 	}
 	sort.Strings(rl)
@@ -0,0 +1,185 @@
+package chef
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+)
+
+// Run list entry types
+const (
+	RunListRecipe = "recipe"
+	RunListRole   = "role"
+)
+
+// RunListEntry is a single entry of an expanded run list. Roles list the entries of their own run list as children.
+type RunListEntry struct {
+	Item    string `json:"item"`
+	Type    string `json:"type"`
+	Name    string `json:"name"`
+	Version string `json:"version,omitempty"`
+	// Duplicate is true for recipes and roles that were already added earlier in the run list, which chef-client
+	// skips
+	Duplicate bool `json:"duplicate,omitempty"`
+	// Cycle is true for roles that (indirectly) include themselves
+	Cycle bool `json:"cycle,omitempty"`
+	// Missing is true for roles that do not exist on the server
+	Missing  bool            `json:"missing,omitempty"`
+	Children []*RunListEntry `json:"children,omitempty"`
+}
+
+// ExpandedRecipe is a recipe that chef-client will run
+type ExpandedRecipe struct {
+	// Name is the fully qualified recipe name, e.g. "nginx::default"
+	Name    string `json:"name"`
+	Version string `json:"version,omitempty"`
+	// Via lists the roles that led to the recipe, outermost first. It is empty for recipes on the run list itself.
+	Via []string `json:"via,omitempty"`
+}
+
+// ExpandedRunList is a run list with every role recursively replaced by its own run list
+type ExpandedRunList struct {
+	Environment string   `json:"environment"`
+	RunList     []string `json:"run_list"`
+	// Recipes lists the recipes chef-client will run, in order
+	Recipes []ExpandedRecipe `json:"recipes"`
+	// Roles lists the roles that were applied, in order
+	Roles []string `json:"roles"`
+	// Missing lists the roles that do not exist on the server
+	Missing []string        `json:"missing,omitempty"`
+	Tree    []*RunListEntry `json:"tree"`
+}
+
+// runListExpansion holds the state of a single run list expansion
+type runListExpansion struct {
+	s       Service
+	result  *ExpandedRunList
+	applied map[string]bool
+	recipes map[string]bool
+}
+
+// ExpandNodeRunList expands the run list of a node in the node's environment. Nodes using policyfiles ignore roles
+// and environments, so their run list only contains recipes.
+func (s Service) ExpandNodeRunList(ctx context.Context, node *Node) (*ExpandedRunList, error) {
+	return s.ExpandRunList(ctx, node.RunList, node.Environment)
+}
+
+// ExpandRunList recursively replaces roles in a run list with their own run lists like chef-client does: roles use
+// the run list of the environment when they have one, roles and recipes are only added the first time they appear
+// and the recipe "nginx" is the same as "nginx::default". Roles that cannot be found are reported instead of failing
+// the expansion.
+// Ref: https://docs.chef.io/run_lists/#run-list-expansion
+func (s Service) ExpandRunList(ctx context.Context, runList []string, environment string) (*ExpandedRunList, error) {
+	if environment == "" {
+		environment = "_default"
+	}
+
+	e := &runListExpansion{
+		s: s,
+		result: &ExpandedRunList{
+			Environment: environment,
+			RunList:     runList,
+			Recipes:     []ExpandedRecipe{},
+			Roles:       []string{},
+		},
+		applied: map[string]bool{},
+		recipes: map[string]bool{},
+	}
+
+	tree, err := e.expand(ctx, runList, nil)
+	if err != nil {
+		return nil, err
+	}
+	e.result.Tree = tree
+
+	return e.result, nil
+}
+
+// expand expands the items of a run list. path holds the roles currently being expanded, outermost first.
+func (e *runListExpansion) expand(ctx context.Context, runList []string, path []string) ([]*RunListEntry, error) {
+	var entries []*RunListEntry
+	for _, item := range runList {
+		entry := newRunListEntry(item)
+		entries = append(entries, entry)
+
+		if entry.Type == RunListRecipe {
+			if e.recipes[entry.Name] {
+				entry.Duplicate = true
+				continue
+			}
+			e.recipes[entry.Name] = true
+			e.result.Recipes = append(e.result.Recipes, ExpandedRecipe{Name: entry.Name, Version: entry.Version, Via: path})
+			continue
+		}
+
+		if slices.Contains(path, entry.Name) {
+			entry.Cycle = true
+			continue
+		}
+		if e.applied[entry.Name] {
+			entry.Duplicate = true
+			continue
+		}
+		e.applied[entry.Name] = true
+
+		role, err := e.s.GetRole(ctx, entry.Name)
+		if err != nil {
+			if errors.Is(err, ErrNotFound) {
+				entry.Missing = true
+				e.result.Missing = append(e.result.Missing, entry.Name)
+				continue
+			}
+			return nil, err
+		}
+		e.result.Roles = append(e.result.Roles, entry.Name)
+
+		roleRunList := role.RunList
+		if envRunList, ok := role.EnvRunList[e.result.Environment]; ok {
+			roleRunList = envRunList
+		}
+
+		children, err := e.expand(ctx, roleRunList, append(path[:len(path):len(path)], entry.Name))
+		if err != nil {
+			return nil, err
+		}
+		entry.Children = children
+	}
+	return entries, nil
+}
+
+// RunListItem is a single parsed run list item
+type RunListItem struct {
+	Type string
+	// Name is the name of a role or the cookbook of a recipe
+	Name string
+	// Recipe is the name of a recipe within its cookbook, which is "default" if the item only names the cookbook
+	Recipe  string
+	Version string
+}
+
+// ParseRunListItem parses a run list item such as "role[web]", "recipe[nginx::server@1.0.0]" or "nginx::server".
+// Like chef-client, items that are neither roles nor recipes in brackets are taken to be recipes.
+func ParseRunListItem(item string) RunListItem {
+	if name, ok := strings.CutPrefix(item, "role["); ok {
+		return RunListItem{Type: RunListRole, Name: strings.TrimSuffix(name, "]")}
+	}
+
+	name := strings.TrimSuffix(strings.TrimPrefix(item, "recipe["), "]")
+	name, version, _ := strings.Cut(name, "@")
+	cookbook, recipe, _ := strings.Cut(name, "::")
+	if recipe == "" {
+		recipe = "default"
+	}
+	return RunListItem{Type: RunListRecipe, Name: cookbook, Recipe: recipe, Version: version}
+}
+
+// newRunListEntry returns the entry of a run list item. Recipe names are fully qualified.
+func newRunListEntry(item string) *RunListEntry {
+	p := ParseRunListItem(item)
+	entry := &RunListEntry{Item: item, Type: p.Type, Name: p.Name, Version: p.Version}
+	if p.Type == RunListRecipe {
+		entry.Name += "::" + p.Recipe
+	}
+	return entry
+}
@@ -0,0 +1,152 @@
+package chef
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/drewhammond/chefbrowser/config"
+)
+
+func TestParseRunListItem(t *testing.T) {
+	tests := map[string]RunListItem{
+		"role[web]":                   {Type: RunListRole, Name: "web"},
+		"recipe[nginx]":               {Type: RunListRecipe, Name: "nginx", Recipe: "default"},
+		"recipe[nginx::server@1.0.0]": {Type: RunListRecipe, Name: "nginx", Recipe: "server", Version: "1.0.0"},
+		"recipe[nginx@1.0.0]":         {Type: RunListRecipe, Name: "nginx", Recipe: "default", Version: "1.0.0"},
+		"nginx::server":               {Type: RunListRecipe, Name: "nginx", Recipe: "server"},
+		"nginx::server@1.0.0":         {Type: RunListRecipe, Name: "nginx", Recipe: "server", Version: "1.0.0"},
+	}
+	for item, expected := range tests {
+		if got := ParseRunListItem(item); got != expected {
+			t.Errorf("ParseRunListItem(%q) = %+v, want %+v", item, got, expected)
+		}
+	}
+}
+
+func TestNewRunListEntry(t *testing.T) {
+	tests := map[string]RunListEntry{
+		"role[web]":                   {Item: "role[web]", Type: RunListRole, Name: "web"},
+		"recipe[nginx]":               {Item: "recipe[nginx]", Type: RunListRecipe, Name: "nginx::default"},
+		"recipe[nginx::server@1.0.0]": {Item: "recipe[nginx::server@1.0.0]", Type: RunListRecipe, Name: "nginx::server", Version: "1.0.0"},
+		"nginx::server":               {Item: "nginx::server", Type: RunListRecipe, Name: "nginx::server"},
+	}
+	for item, expected := range tests {
+		if got := newRunListEntry(item); !reflect.DeepEqual(*got, expected) {
+			t.Errorf("newRunListEntry(%q) = %+v, want %+v", item, *got, expected)
+		}
+	}
+}
+
+func TestExpandRunList(t *testing.T) {
+	roles := map[string]string{
+		"/roles/base": `{"name": "base", "run_list": ["recipe[users]", "recipe[ntp]"]}`,
+		"/roles/web": `{"name": "web", "run_list": ["role[base]", "recipe[nginx]"],
+			"env_run_lists": {"prod": ["role[base]", "recipe[nginx::default]", "recipe[nginx::tls]", "role[missing]"]}}`,
+		"/roles/loop": `{"name": "loop", "run_list": ["recipe[loop]", "role[web]", "role[loop]"]}`,
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, ok := roles[r.URL.Path]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(body))
+	}))
+	defer srv.Close()
+
+	s := newTestService(t, srv.URL, &config.Config{})
+
+	tests := []struct {
+		name        string
+		runList     []string
+		environment string
+		recipes     []ExpandedRecipe
+		roles       []string
+		missing     []string
+	}{
+		{
+			name:        "default environment",
+			runList:     []string{"recipe[ntp]", "role[web]", "recipe[nginx::default]"},
+			environment: "",
+			recipes: []ExpandedRecipe{
+				{Name: "ntp::default"},
+				{Name: "users::default", Via: []string{"web", "base"}},
+				{Name: "nginx::default", Via: []string{"web"}},
+			},
+			roles: []string{"web", "base"},
+		},
+		{
+			name:        "environment run list",
+			runList:     []string{"role[web]", "recipe[app@1.2.0]"},
+			environment: "prod",
+			recipes: []ExpandedRecipe{
+				{Name: "users::default", Via: []string{"web", "base"}},
+				{Name: "ntp::default", Via: []string{"web", "base"}},
+				{Name: "nginx::default", Via: []string{"web"}},
+				{Name: "nginx::tls", Via: []string{"web"}},
+				{Name: "app::default", Version: "1.2.0"},
+			},
+			roles:   []string{"web", "base"},
+			missing: []string{"missing"},
+		},
+		{
+			name:        "cycle",
+			runList:     []string{"role[loop]"},
+			environment: "_default",
+			recipes: []ExpandedRecipe{
+				{Name: "loop::default", Via: []string{"loop"}},
+				{Name: "users::default", Via: []string{"loop", "web", "base"}},
+				{Name: "ntp::default", Via: []string{"loop", "web", "base"}},
+				{Name: "nginx::default", Via: []string{"loop", "web"}},
+			},
+			roles: []string{"loop", "web", "base"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := s.ExpandRunList(context.Background(), tt.runList, tt.environment)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got.Recipes, tt.recipes) {
+				t.Errorf("recipes = %+v, want %+v", got.Recipes, tt.recipes)
+			}
+			if !reflect.DeepEqual(got.Roles, tt.roles) {
+				t.Errorf("roles = %v, want %v", got.Roles, tt.roles)
+			}
+			if !reflect.DeepEqual(got.Missing, tt.missing) {
+				t.Errorf("missing = %v, want %v", got.Missing, tt.missing)
+			}
+		})
+	}
+}
+
+func TestExpandRunListTree(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"name": "loop", "run_list": ["recipe[ntp]", "role[loop]"]}`))
+	}))
+	defer srv.Close()
+
+	s := newTestService(t, srv.URL, &config.Config{})
+	got, err := s.ExpandRunList(context.Background(), []string{"recipe[ntp::default]", "role[loop]", "role[loop]"}, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []*RunListEntry{
+		{Item: "recipe[ntp::default]", Type: RunListRecipe, Name: "ntp::default"},
+		{Item: "role[loop]", Type: RunListRole, Name: "loop", Children: []*RunListEntry{
+			{Item: "recipe[ntp]", Type: RunListRecipe, Name: "ntp::default", Duplicate: true},
+			{Item: "role[loop]", Type: RunListRole, Name: "loop", Cycle: true},
+		}},
+		{Item: "role[loop]", Type: RunListRole, Name: "loop", Duplicate: true},
+	}
+	if !reflect.DeepEqual(got.Tree, expected) {
+		t.Errorf("tree = %+v, want %+v", got.Tree, expected)
+	}
+}
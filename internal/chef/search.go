@@ -0,0 +1,193 @@
+package chef
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/go-chef/chef"
+	"golang.org/x/sync/errgroup"
+)
+
+// searchPageSize is the number of rows requested per page when collecting all search results
+const searchPageSize = 1000
+
+// defaultSearchSort matches the default sort order used by chef's own search client
+const defaultSearchSort = "X_CHEF_id_CHEF_X asc"
+
+// partialSearchPage runs a single page of a partial search against the given index
+func (s Service) partialSearchPage(ctx context.Context, index string, q string, sort string, start int, rows int, keys map[string]interface{}) (chef.JSearchResult, error) {
+	var res chef.JSearchResult
+
+	params := url.Values{}
+	params.Set("q", q)
+	params.Set("sort", sort)
+	params.Set("start", strconv.Itoa(start))
+	params.Set("rows", strconv.Itoa(rows))
+
+	body, err := chef.JSONReader(keys)
+	if err != nil {
+		return res, err
+	}
+
+	err = s.do(ctx, http.MethodPost, fmt.Sprintf("search/%s?%s", url.PathEscape(index), params.Encode()), body, &res)
+	return res, err
+}
+
+// partialSearch runs a partial search against the given index and returns the data of every matching row
+func (s Service) partialSearch(ctx context.Context, index string, q string, keys map[string]interface{}) ([]json.RawMessage, error) {
+	var rows []json.RawMessage
+
+	for start := 0; ; start += searchPageSize {
+		res, err := s.partialSearchPage(ctx, index, q, defaultSearchSort, start, searchPageSize, keys)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, row := range res.Rows {
+			rows = append(rows, row.Data)
+		}
+
+		if len(res.Rows) == 0 || start+searchPageSize >= res.Total {
+			break
+		}
+	}
+
+	return rows, nil
+}
+
+// Built-in chef search indexes. Every data bag is also searchable using the data bag name as the index.
+const (
+	IndexNode        = "node"
+	IndexRole        = "role"
+	IndexEnvironment = "environment"
+	IndexClient      = "client"
+)
+
+var builtinIndexes = []string{IndexNode, IndexRole, IndexEnvironment, IndexClient}
+
+// SearchResult holds the names of the objects matching a query in a single index
+type SearchResult struct {
+	Index string `json:"index"`
+	// DataBag is true when Index refers to a data bag, in which case Items holds data bag item IDs
+	DataBag bool     `json:"data_bag"`
+	Items   []string `json:"items"`
+}
+
+// FuzzifyQuery mimics the fuzzy search functionality provided by knife: queries that do not target a specific field
+// are expanded to a wildcard search over the fields that are most useful for the given index.
+// Ref: https://github.com/chef/chef/blob/main/lib/chef/search/query.rb#L109
+func FuzzifyQuery(index string, q string) string {
+	if q == "" || strings.Contains(q, ":") {
+		return q
+	}
+
+	var format []string
+	switch index {
+	case IndexNode:
+		format = []string{
+			"tags:*%v*",
+			"roles:*%v*",
+			"fqdn:*%v*",
+			"addresses:*%v*",
+			"policy_name:*%v*",
+			"policy_group:*%v*",
+		}
+	case IndexRole, IndexEnvironment, IndexClient:
+		format = []string{"name:*%v*"}
+	default:
+		// data bags
+		format = []string{"id:*%v*"}
+	}
+
+	var b strings.Builder
+	for i, f := range format {
+		if i > 0 {
+			b.WriteString(" OR ")
+		}
+		b.WriteString(fmt.Sprintf(f, q))
+	}
+	return b.String()
+}
+
+// Search runs a query against a single index and returns the names (or data bag item IDs) of the matching objects.
+// Queries that do not target a specific field are fuzzified the same way knife does.
+func (s Service) Search(ctx context.Context, index string, q string) (*SearchResult, error) {
+	nameKey := "name"
+	dataBag := !slices.Contains(builtinIndexes, index)
+	if dataBag {
+		if !s.canView(ctx, ObjectTypeDataBags, index) {
+			return nil, ErrDatabagDenied
+		}
+		nameKey = "id"
+	}
+
+	rows, err := s.partialSearch(ctx, index, FuzzifyQuery(index, q), map[string]interface{}{
+		"name": []string{nameKey},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	result := &SearchResult{Index: index, DataBag: dataBag, Items: []string{}}
+	for _, i := range rows {
+		var row struct {
+			Name string `json:"name"`
+		}
+		_ = json.Unmarshal(i, &row)
+		result.Items = append(result.Items, row.Name)
+	}
+
+	sort.Strings(result.Items)
+
+	return result, nil
+}
+
+// SearchAll runs a query against every built-in index and every data bag. Results are returned in a stable
+// order (built-in indexes first, then data bags by name) and indexes without matches are omitted.
+func (s Service) SearchAll(ctx context.Context, q string) ([]SearchResult, error) {
+	databags, err := s.GetDatabags(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	indexes := slices.Clone(builtinIndexes)
+	var bags []string
+	for name := range databags {
+		bags = append(bags, name)
+	}
+	sort.Strings(bags)
+	indexes = append(indexes, bags...)
+
+	results := make([]*SearchResult, len(indexes))
+	g, ctx := errgroup.WithContext(ctx)
+	g.SetLimit(4)
+	for i, index := range indexes {
+		g.Go(func() error {
+			res, err := s.Search(ctx, index, q)
+			if err != nil {
+				return fmt.Errorf("failed to search %s index: %w", index, err)
+			}
+			results[i] = res
+			return nil
+		})
+	}
+	if err := g.Wait(); err != nil {
+		return nil, err
+	}
+
+	var out []SearchResult
+	for _, res := range results {
+		if len(res.Items) > 0 {
+			out = append(out, *res)
+		}
+	}
+
+	return out, nil
+}
@@ -0,0 +1,145 @@
+package chef
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/drewhammond/chefbrowser/config"
+)
+
+func TestFuzzifyQuery(t *testing.T) {
+	tests := []struct {
+		index    string
+		query    string
+		expected string
+	}{
+		{IndexNode, "web", "tags:*web* OR roles:*web* OR fqdn:*web* OR addresses:*web* OR policy_name:*web* OR policy_group:*web*"},
+		{IndexNode, "chef_environment:prod", "chef_environment:prod"},
+		{IndexRole, "web", "name:*web*"},
+		{IndexEnvironment, "prod", "name:*prod*"},
+		{IndexClient, "validator", "name:*validator*"},
+		{"users", "drew", "id:*drew*"},
+		{"users", "id:drew", "id:drew"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.index+"/"+tt.query, func(t *testing.T) {
+			if got := FuzzifyQuery(tt.index, tt.query); got != tt.expected {
+				t.Errorf("FuzzifyQuery() = %v, want %v", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestSearchPaging(t *testing.T) {
+	const total = 2500
+	var requests int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if r.URL.Path != "/search/role" || r.URL.Query().Get("q") != "name:*web*" {
+			t.Errorf("unexpected search request: %s", r.URL)
+		}
+		start, _ := strconv.Atoi(r.URL.Query().Get("start"))
+		rows, _ := strconv.Atoi(r.URL.Query().Get("rows"))
+
+		type row struct {
+			URL  string            `json:"url"`
+			Data map[string]string `json:"data"`
+		}
+		res := struct {
+			Total int   `json:"total"`
+			Start int   `json:"start"`
+			Rows  []row `json:"rows"`
+		}{Total: total, Start: start}
+		for i := start; i < start+rows && i < total; i++ {
+			res.Rows = append(res.Rows, row{Data: map[string]string{"name": fmt.Sprintf("web-%04d", i)}})
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(res)
+	}))
+	defer srv.Close()
+
+	s := newTestService(t, srv.URL, &config.Config{})
+	res, err := s.Search(context.Background(), IndexRole, "web")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res.Items) != total {
+		t.Errorf("expected %d results, actual: %d", total, len(res.Items))
+	}
+	if requests != 3 {
+		t.Errorf("expected 3 pages to be requested, actual: %d", requests)
+	}
+	if res.Items[0] != "web-0000" || res.Items[total-1] != "web-2499" {
+		t.Errorf("unexpected results: %v ... %v", res.Items[0], res.Items[total-1])
+	}
+}
+
+func TestGetNodesPage(t *testing.T) {
+	var requests int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		q := r.URL.Query()
+		if r.URL.Path != "/search/node" || q.Get("q") != "*:*" || q.Get("sort") != "name desc" ||
+			q.Get("start") != "20" || q.Get("rows") != "10" {
+			t.Errorf("unexpected search request: %s", r.URL)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"total": 35, "start": 20, "rows": [{"data": {"name": "node-b"}}, {"data": {"name": "node-a"}}]}`))
+	}))
+	defer srv.Close()
+
+	s := newTestService(t, srv.URL, &config.Config{})
+	nodes, err := s.GetNodesPage(context.Background(), "", Page{Start: 20, Rows: 10}, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if nodes.Total != 35 {
+		t.Errorf("expected a total of 35 nodes, actual: %d", nodes.Total)
+	}
+	if len(nodes.Nodes) != 2 || nodes.Nodes[0] != "node-b" || nodes.Nodes[1] != "node-a" {
+		t.Errorf("unexpected nodes: %v", nodes.Nodes)
+	}
+	if requests != 1 {
+		t.Errorf("expected a single search request, actual: %d", requests)
+	}
+}
+
+func TestRefreshNodeList(t *testing.T) {
+	for _, size := range []int{0, 25} {
+		var requests int
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			requests++
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = w.Write([]byte(`{"total": 2, "start": 0, "rows": [{"data": {"name": "node-b"}}, {"data": {"name": "node-a"}}]}`))
+		}))
+
+		cfg := &config.Config{}
+		cfg.Cache.Enabled = true
+		cfg.Cache.NodeTTL = time.Minute
+		cfg.UI.PageSize = size
+		s := newTestService(t, srv.URL, cfg)
+		if err := s.refreshNodeList(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+		refreshed := requests
+
+		// the first page of the UI node list is served from the refreshed cache
+		nodes, err := s.GetNodesPage(context.Background(), FuzzifyQuery(IndexNode, ""), Page{Rows: size}, false)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if requests != refreshed {
+			t.Errorf("page size %d: expected the first page to be cached, actual: %d requests", size, requests-refreshed)
+		}
+		if len(nodes.Nodes) != 2 || nodes.Nodes[0] != "node-a" {
+			t.Errorf("page size %d: unexpected nodes: %v", size, nodes.Nodes)
+		}
+		srv.Close()
+	}
+}
@@ -0,0 +1,252 @@
+package util
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// maxDiffEdits limits the work done when diffing two texts. Texts with more changed lines than this are shown
+// as completely replaced instead.
+const maxDiffEdits = 2000
+
+// Operations of a DiffLine
+const (
+	DiffEqual  = " "
+	DiffInsert = "+"
+	DiffDelete = "-"
+)
+
+// DiffLine is a single line of a unified diff
+type DiffLine struct {
+	Op   string `json:"op"`
+	Text string `json:"text"`
+}
+
+// DiffHunk is a group of nearby changed lines along with the unchanged lines surrounding them
+type DiffHunk struct {
+	FromLine  int        `json:"from_line"`
+	FromCount int        `json:"from_count"`
+	ToLine    int        `json:"to_line"`
+	ToCount   int        `json:"to_count"`
+	Lines     []DiffLine `json:"lines"`
+}
+
+// Header returns the unified diff hunk header, e.g. "@@ -1,4 +1,5 @@"
+func (h DiffHunk) Header() string {
+	return fmt.Sprintf("@@ -%d,%d +%d,%d @@", h.FromLine, h.FromCount, h.ToLine, h.ToCount)
+}
+
+// DiffText compares two texts line by line and returns the hunks of a unified diff with the given number of
+// context lines around each change. Identical texts have no hunks.
+func DiffText(from string, to string, context int) []DiffHunk {
+	return diffHunks(diffLines(splitLines(from), splitLines(to)), context)
+}
+
+// FormatUnifiedDiff formats hunks returned by DiffText as a unified diff
+func FormatUnifiedDiff(fromName string, toName string, hunks []DiffHunk) string {
+	if len(hunks) == 0 {
+		return ""
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "--- %s\n+++ %s\n", fromName, toName)
+	for _, h := range hunks {
+		b.WriteString(h.Header())
+		b.WriteString("\n")
+		for _, l := range h.Lines {
+			b.WriteString(l.Op)
+			b.WriteString(l.Text)
+			b.WriteString("\n")
+		}
+	}
+	return b.String()
+}
+
+func splitLines(s string) []string {
+	if s == "" {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(s, "\n"), "\n")
+}
+
+// diffLines returns the shortest edit script turning a into b
+func diffLines(a []string, b []string) []DiffLine {
+	// most changes are small, so skip the common prefix and suffix before doing any real work
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+
+	var ops []DiffLine
+	for _, l := range a[:prefix] {
+		ops = append(ops, DiffLine{DiffEqual, l})
+	}
+	ops = append(ops, myers(a[prefix:len(a)-suffix], b[prefix:len(b)-suffix])...)
+	for _, l := range a[len(a)-suffix:] {
+		ops = append(ops, DiffLine{DiffEqual, l})
+	}
+	return ops
+}
+
+// myers implements the Myers O(ND) difference algorithm.
+// Ref: http://www.xmailserver.org/diff2.pdf
+func myers(a []string, b []string) []DiffLine {
+	n, m := len(a), len(b)
+	if n == 0 || m == 0 {
+		return replaceLines(a, b)
+	}
+
+	offset := n + m
+	v := make([]int, 2*offset+2)
+
+	// trace[d] holds the part of v that the search for d edits started with, which is all that is
+	// needed to walk back through the edits once the end is reached
+	var trace [][]int
+	var bases []int
+
+	for d := 0; d <= n+m; d++ {
+		if d > maxDiffEdits {
+			return replaceLines(a, b)
+		}
+
+		lo, hi := max(offset-d-1, 0), min(offset+d+1, len(v)-1)
+		trace = append(trace, slices.Clone(v[lo:hi+1]))
+		bases = append(bases, lo)
+
+		for k := -d; k <= d; k += 2 {
+			var x int
+			if k == -d || (k != d && v[offset+k-1] < v[offset+k+1]) {
+				x = v[offset+k+1]
+			} else {
+				x = v[offset+k-1] + 1
+			}
+			y := x - k
+			for x < n && y < m && a[x] == b[y] {
+				x++
+				y++
+			}
+			v[offset+k] = x
+
+			if x >= n && y >= m {
+				return backtrack(a, b, trace, bases, offset)
+			}
+		}
+	}
+
+	return replaceLines(a, b)
+}
+
+func backtrack(a []string, b []string, trace [][]int, bases []int, offset int) []DiffLine {
+	var ops []DiffLine
+	x, y := len(a), len(b)
+
+	for d := len(trace) - 1; d >= 0; d-- {
+		v := func(k int) int {
+			return trace[d][offset+k-bases[d]]
+		}
+
+		k := x - y
+		var prevK int
+		if k == -d || (k != d && v(k-1) < v(k+1)) {
+			prevK = k + 1
+		} else {
+			prevK = k - 1
+		}
+		prevX := v(prevK)
+		prevY := prevX - prevK
+
+		for x > prevX && y > prevY {
+			ops = append(ops, DiffLine{DiffEqual, a[x-1]})
+			x--
+			y--
+		}
+		if d > 0 {
+			if x == prevX {
+				ops = append(ops, DiffLine{DiffInsert, b[y-1]})
+			} else {
+				ops = append(ops, DiffLine{DiffDelete, a[x-1]})
+			}
+		}
+		x, y = prevX, prevY
+	}
+
+	slices.Reverse(ops)
+	return ops
+}
+
+func replaceLines(a []string, b []string) []DiffLine {
+	ops := make([]DiffLine, 0, len(a)+len(b))
+	for _, l := range a {
+		ops = append(ops, DiffLine{DiffDelete, l})
+	}
+	for _, l := range b {
+		ops = append(ops, DiffLine{DiffInsert, l})
+	}
+	return ops
+}
+
+// diffHunks groups an edit script into hunks, merging changes that are separated by less than 2*context lines
+func diffHunks(ops []DiffLine, context int) []DiffHunk {
+	// line numbers (0-based) in a and b before each operation
+	from := make([]int, len(ops)+1)
+	to := make([]int, len(ops)+1)
+	for i, op := range ops {
+		from[i+1], to[i+1] = from[i], to[i]
+		if op.Op != DiffInsert {
+			from[i+1]++
+		}
+		if op.Op != DiffDelete {
+			to[i+1]++
+		}
+	}
+
+	var hunks []DiffHunk
+	for i := 0; i < len(ops); {
+		if ops[i].Op == DiffEqual {
+			i++
+			continue
+		}
+
+		start := max(i-context, 0)
+		end := i
+		for end < len(ops) {
+			if ops[end].Op != DiffEqual {
+				end++
+				continue
+			}
+			run := end
+			for run < len(ops) && ops[run].Op == DiffEqual {
+				run++
+			}
+			if run == len(ops) || run-end > 2*context {
+				end = min(end+context, len(ops))
+				break
+			}
+			end = run
+		}
+
+		h := DiffHunk{
+			FromCount: from[end] - from[start],
+			ToCount:   to[end] - to[start],
+			Lines:     ops[start:end],
+		}
+		// by convention an empty range refers to the line before it
+		h.FromLine, h.ToLine = from[start]+1, to[start]+1
+		if h.FromCount == 0 {
+			h.FromLine--
+		}
+		if h.ToCount == 0 {
+			h.ToLine--
+		}
+
+		hunks = append(hunks, h)
+		i = end
+	}
+
+	return hunks
+}
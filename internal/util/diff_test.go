@@ -0,0 +1,140 @@
+package util
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestDiffText(t *testing.T) {
+	tests := []struct {
+		name     string
+		from, to string
+		context  int
+		expected string
+	}{
+		{
+			"identical",
+			"a\nb\nc\n", "a\nb\nc\n", 3,
+			"",
+		},
+		{
+			"changed line",
+			"a\nb\nc\n", "a\nx\nc\n", 3,
+			"@@ -1,3 +1,3 @@\n a\n-b\n+x\n c\n",
+		},
+		{
+			"added to empty",
+			"", "a\nb\n", 3,
+			"@@ -0,0 +1,2 @@\n+a\n+b\n",
+		},
+		{
+			"removed everything",
+			"a\nb\n", "", 3,
+			"@@ -1,2 +0,0 @@\n-a\n-b\n",
+		},
+		{
+			"insert in the middle",
+			"a\nb\nc\nd\n", "a\nb\nx\nc\nd\n", 1,
+			"@@ -2,2 +2,3 @@\n b\n+x\n c\n",
+		},
+		{
+			"separate hunks",
+			"1\n2\n3\n4\n5\n6\n7\n8\n9\n", "x\n2\n3\n4\n5\n6\n7\n8\ny\n", 1,
+			"@@ -1,2 +1,2 @@\n-1\n+x\n 2\n@@ -8,2 +8,2 @@\n 8\n-9\n+y\n",
+		},
+		{
+			"nearby changes share a hunk",
+			"1\n2\n3\n4\n5\n", "x\n2\n3\n4\ny\n", 2,
+			"@@ -1,5 +1,5 @@\n-1\n+x\n 2\n 3\n 4\n-5\n+y\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got strings.Builder
+			for _, h := range DiffText(tt.from, tt.to, tt.context) {
+				got.WriteString(h.Header() + "\n")
+				for _, l := range h.Lines {
+					got.WriteString(l.Op + l.Text + "\n")
+				}
+			}
+			if got.String() != tt.expected {
+				t.Errorf("DiffText() =\n%s\nwant\n%s", got.String(), tt.expected)
+			}
+		})
+	}
+}
+
+func TestDiffTextApplies(t *testing.T) {
+	// the shortest edit script is not unique, but applying it must always produce the new text
+	tests := [][2]string{
+		{"a\nb\nc\na\nb\nb\na\n", "c\nb\na\nb\na\nc\n"},
+		{"x\ny\nz\n", "z\ny\nx\n"},
+		{"1\n2\n3\n4\n5\n6\n", "0\n2\n4\n6\n8\n"},
+	}
+	for _, tt := range tests {
+		hunks := DiffText(tt[0], tt[1], 0)
+		if got := applyHunks(tt[0], hunks); got != tt[1] {
+			t.Errorf("applying diff of %q to %q gave %q", tt[0], tt[1], got)
+		}
+	}
+}
+
+func TestDiffTextTooManyEdits(t *testing.T) {
+	var from, to strings.Builder
+	for i := 0; i <= maxDiffEdits; i++ {
+		from.WriteString("a\n")
+		to.WriteString("b\n")
+	}
+
+	hunks := DiffText(from.String(), to.String(), 3)
+	if len(hunks) != 1 {
+		t.Fatalf("expected a single hunk, got %d", len(hunks))
+	}
+	if got := applyHunks(from.String(), hunks); got != to.String() {
+		t.Errorf("applying the diff did not produce the new text")
+	}
+}
+
+func TestFormatUnifiedDiff(t *testing.T) {
+	expected := "--- a/README.md\n+++ b/README.md\n@@ -1,2 +1,2 @@\n # nginx\n-old\n+new\n"
+	got := FormatUnifiedDiff("a/README.md", "b/README.md", DiffText("# nginx\nold\n", "# nginx\nnew\n", 3))
+	if got != expected {
+		t.Errorf("FormatUnifiedDiff() = %q, want %q", got, expected)
+	}
+	if got := FormatUnifiedDiff("a", "b", nil); got != "" {
+		t.Errorf("FormatUnifiedDiff() of no hunks = %q, want empty", got)
+	}
+}
+
+func TestDiffLines(t *testing.T) {
+	expected := []DiffLine{{DiffEqual, "a"}, {DiffDelete, "b"}, {DiffInsert, "c"}, {DiffEqual, "d"}}
+	if got := diffLines([]string{"a", "b", "d"}, []string{"a", "c", "d"}); !reflect.DeepEqual(got, expected) {
+		t.Errorf("diffLines() = %v, want %v", got, expected)
+	}
+}
+
+// applyHunks applies hunks with complete context (as returned for small texts) to from
+func applyHunks(from string, hunks []DiffHunk) string {
+	lines := splitLines(from)
+	var out []string
+	next := 0
+	for _, h := range hunks {
+		start := h.FromLine - 1
+		if h.FromCount == 0 {
+			start = h.FromLine
+		}
+		out = append(out, lines[next:start]...)
+		for _, l := range h.Lines {
+			if l.Op != DiffDelete {
+				out = append(out, l.Text)
+			}
+		}
+		next = start + h.FromCount
+	}
+	out = append(out, lines[next:]...)
+	if len(out) == 0 {
+		return ""
+	}
+	return strings.Join(out, "\n") + "\n"
+}